@@ -0,0 +1,398 @@
+// Copyright 2025 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package egress
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/utils"
+)
+
+// Defaults of EncodingOptions fields, as documented in the proto.
+const (
+	DefaultPreset          = livekit.EncodingOptionsPreset_H264_720P_30
+	DefaultWidth           = 1920
+	DefaultHeight          = 1080
+	DefaultDepth           = 24
+	DefaultFramerate       = 30
+	DefaultAudioCodec      = livekit.AudioCodec_OPUS
+	DefaultAudioBitrate    = 128
+	DefaultAudioFrequency  = 44100
+	DefaultVideoCodec      = livekit.VideoCodec_H264_MAIN
+	DefaultVideoBitrate    = 4500
+	DefaultStreamKeyFrames = 4.0 // seconds
+	DefaultSegmentDuration = 4   // seconds
+	// DefaultOpusFrequency is used instead of DefaultAudioFrequency for OPUS, which doesn't support 44.1 kHz.
+	DefaultOpusFrequency = 48000
+)
+
+type presetParams struct {
+	width, height, framerate, videoBitrate int32
+}
+
+var presets = map[livekit.EncodingOptionsPreset]presetParams{
+	livekit.EncodingOptionsPreset_H264_720P_30:           {1280, 720, 30, 3000},
+	livekit.EncodingOptionsPreset_H264_720P_60:           {1280, 720, 60, 4500},
+	livekit.EncodingOptionsPreset_H264_1080P_30:          {1920, 1080, 30, 4500},
+	livekit.EncodingOptionsPreset_H264_1080P_60:          {1920, 1080, 60, 6000},
+	livekit.EncodingOptionsPreset_PORTRAIT_H264_720P_30:  {720, 1280, 30, 3000},
+	livekit.EncodingOptionsPreset_PORTRAIT_H264_720P_60:  {720, 1280, 60, 4500},
+	livekit.EncodingOptionsPreset_PORTRAIT_H264_1080P_30: {1080, 1920, 30, 4500},
+	livekit.EncodingOptionsPreset_PORTRAIT_H264_1080P_60: {1080, 1920, 60, 6000},
+}
+
+// PresetOptions expands a preset into concrete encoding options.
+func PresetOptions(p livekit.EncodingOptionsPreset) (*livekit.EncodingOptions, error) {
+	params, ok := presets[p]
+	if !ok {
+		return nil, NewInvalidEncodingOptionsError(fmt.Sprintf("unknown preset %d", p))
+	}
+	return &livekit.EncodingOptions{
+		Width:          params.width,
+		Height:         params.height,
+		Depth:          DefaultDepth,
+		Framerate:      params.framerate,
+		AudioCodec:     DefaultAudioCodec,
+		AudioBitrate:   DefaultAudioBitrate,
+		AudioFrequency: defaultAudioFrequency(DefaultAudioCodec),
+		VideoCodec:     DefaultVideoCodec,
+		VideoBitrate:   params.videoBitrate,
+	}, nil
+}
+
+// EncodingTarget describes a single output encoding options are resolved for.
+type EncodingTarget struct {
+	// OutputType is one of OutputTypeFile, OutputTypeStream, OutputTypeSegments or OutputTypeImages.
+	OutputType            string
+	FileType              livekit.EncodedFileType
+	SegmentedFileProtocol livekit.SegmentedFileProtocol
+	StreamProtocol        livekit.StreamProtocol
+	// SegmentDuration in seconds. Zero means DefaultSegmentDuration.
+	SegmentDuration uint32
+}
+
+func (t EncodingTarget) String() string {
+	switch t.OutputType {
+	case OutputTypeFile:
+		return fmt.Sprintf("file(%s)", t.FileType)
+	case OutputTypeSegments:
+		return fmt.Sprintf("segments(%s)", t.SegmentedFileProtocol)
+	case OutputTypeStream:
+		return fmt.Sprintf("stream(%s)", t.StreamProtocol)
+	}
+	return t.OutputType
+}
+
+// supportedAudio returns audio codecs supported by the target, in order of preference. Nil means no restriction.
+func (t EncodingTarget) supportedAudio() []livekit.AudioCodec {
+	switch t.OutputType {
+	case OutputTypeFile:
+		switch t.FileType {
+		case livekit.EncodedFileType_OGG:
+			return []livekit.AudioCodec{livekit.AudioCodec_OPUS}
+		default:
+			return []livekit.AudioCodec{livekit.AudioCodec_OPUS, livekit.AudioCodec_AAC}
+		}
+	case OutputTypeSegments, OutputTypeStream:
+		// Both MPEG-TS and FLV containers are only used with AAC.
+		return []livekit.AudioCodec{livekit.AudioCodec_AAC}
+	}
+	return nil
+}
+
+// supportsVideo returns false if the container cannot carry video at all.
+func (t EncodingTarget) supportsVideo() bool {
+	return !(t.OutputType == OutputTypeFile && t.FileType == livekit.EncodedFileType_OGG)
+}
+
+// supportsVP8 returns true if the target can carry VP8. None of the containers used by egress can.
+func (t EncodingTarget) supportsVP8() bool {
+	return t.OutputType == OutputTypeImages
+}
+
+// EncodingAdjustment describes a change made to requested or default encoding options to make them compatible with outputs.
+type EncodingAdjustment struct {
+	Field  string
+	From   string
+	To     string
+	Reason string
+}
+
+func (a EncodingAdjustment) String() string {
+	return fmt.Sprintf("%s: %s -> %s (%s)", a.Field, a.From, a.To, a.Reason)
+}
+
+// ResolvedEncoding is a result of encoding options resolution.
+type ResolvedEncoding struct {
+	// Options have all fields set to concrete values. Video fields are zero for audio-only egress,
+	// and audio fields are zero for video-only egress.
+	Options     *livekit.EncodingOptions
+	Adjustments []EncodingAdjustment
+}
+
+// EncodingRequest is an input for ResolveEncoding.
+type EncodingRequest struct {
+	// Preset is used if Advanced is not set.
+	Preset   livekit.EncodingOptionsPreset
+	Advanced *livekit.EncodingOptions
+	Audio    bool
+	Video    bool
+	Targets  []EncodingTarget
+}
+
+// ResolveEncoding expands presets, fills defaults and adjusts codecs to make them compatible with all targets.
+// It returns an error if no combination of codecs satisfies all targets.
+//
+// The result only depends on the input, so API servers and egress workers get identical answers.
+func ResolveEncoding(req EncodingRequest) (*ResolvedEncoding, error) {
+	var (
+		opts *livekit.EncodingOptions
+		err  error
+	)
+	if req.Advanced != nil {
+		opts = utils.CloneProto(req.Advanced)
+		fillEncodingDefaults(opts)
+	} else if opts, err = PresetOptions(req.Preset); err != nil {
+		return nil, err
+	}
+	res := &ResolvedEncoding{Options: opts}
+	adjust := func(field string, from, to any, reason string) {
+		res.Adjustments = append(res.Adjustments, EncodingAdjustment{
+			Field: field, From: fmt.Sprint(from), To: fmt.Sprint(to), Reason: reason,
+		})
+	}
+	if req.Video {
+		for _, t := range req.Targets {
+			if !t.supportsVideo() {
+				return nil, NewInvalidOutputError(fmt.Sprintf("%s cannot contain video", t))
+			}
+			if opts.VideoCodec == livekit.VideoCodec_VP8 && !t.supportsVP8() {
+				return nil, NewInvalidEncodingOptionsError(fmt.Sprintf("VP8 is not supported by %s", t))
+			}
+		}
+		if isH264(opts.VideoCodec) {
+			if w := opts.Width &^ 1; w != opts.Width {
+				adjust("width", opts.Width, w, "H.264 requires even dimensions")
+				opts.Width = w
+			}
+			if h := opts.Height &^ 1; h != opts.Height {
+				adjust("height", opts.Height, h, "H.264 requires even dimensions")
+				opts.Height = h
+			}
+		}
+		if opts.KeyFrameInterval == 0 {
+			opts.KeyFrameInterval = defaultKeyFrameInterval(req.Targets)
+		}
+	} else {
+		opts.Width, opts.Height, opts.Depth, opts.Framerate = 0, 0, 0, 0
+		opts.VideoCodec, opts.VideoBitrate, opts.VideoQuality, opts.KeyFrameInterval = livekit.VideoCodec_DEFAULT_VC, 0, 0, 0
+	}
+
+	if req.Audio {
+		explicitCodec := req.Advanced != nil && req.Advanced.AudioCodec != livekit.AudioCodec_DEFAULT_AC
+		allowed := []livekit.AudioCodec{livekit.AudioCodec_OPUS, livekit.AudioCodec_AAC}
+		for _, t := range req.Targets {
+			s := t.supportedAudio()
+			if s == nil {
+				continue
+			}
+			if explicitCodec && t.OutputType == OutputTypeFile && !slices.Contains(s, opts.AudioCodec) {
+				// Files are stored as requested, so the codec cannot be changed silently.
+				return nil, NewInvalidEncodingOptionsError(fmt.Sprintf("%s is not supported by %s", opts.AudioCodec, t))
+			}
+			allowed = slices.DeleteFunc(allowed, func(c livekit.AudioCodec) bool {
+				return !slices.Contains(s, c)
+			})
+			if len(allowed) == 0 {
+				return nil, NewInvalidOutputError(fmt.Sprintf("no audio codec is supported by all outputs, %s is incompatible with others", t))
+			}
+		}
+		if !slices.Contains(allowed, opts.AudioCodec) {
+			adjust("audio_codec", opts.AudioCodec, allowed[0], "not supported by outputs")
+			opts.AudioCodec = allowed[0]
+		}
+		if req.Advanced == nil || req.Advanced.AudioFrequency == 0 {
+			opts.AudioFrequency = defaultAudioFrequency(opts.AudioCodec)
+		} else if !supportsAudioFrequency(opts.AudioCodec, opts.AudioFrequency) {
+			f := defaultAudioFrequency(opts.AudioCodec)
+			adjust("audio_frequency", opts.AudioFrequency, f, fmt.Sprintf("not supported by %s", opts.AudioCodec))
+			opts.AudioFrequency = f
+		}
+	} else {
+		opts.AudioCodec, opts.AudioBitrate, opts.AudioQuality, opts.AudioFrequency = livekit.AudioCodec_DEFAULT_AC, 0, 0, 0
+	}
+	return res, nil
+}
+
+func defaultAudioFrequency(c livekit.AudioCodec) int32 {
+	if c == livekit.AudioCodec_OPUS {
+		return DefaultOpusFrequency
+	}
+	return DefaultAudioFrequency
+}
+
+func isH264(c livekit.VideoCodec) bool {
+	switch c {
+	case livekit.VideoCodec_H264_BASELINE, livekit.VideoCodec_H264_MAIN, livekit.VideoCodec_H264_HIGH:
+		return true
+	}
+	return false
+}
+
+func fillEncodingDefaults(o *livekit.EncodingOptions) {
+	if o.Width == 0 && o.Height == 0 {
+		o.Width, o.Height = DefaultWidth, DefaultHeight
+	}
+	if o.Depth == 0 {
+		o.Depth = DefaultDepth
+	}
+	if o.Framerate == 0 {
+		o.Framerate = DefaultFramerate
+	}
+	if o.AudioCodec == livekit.AudioCodec_DEFAULT_AC {
+		o.AudioCodec = DefaultAudioCodec
+	}
+	if o.AudioBitrate == 0 {
+		o.AudioBitrate = DefaultAudioBitrate
+	}
+	if o.VideoCodec == livekit.VideoCodec_DEFAULT_VC {
+		o.VideoCodec = DefaultVideoCodec
+	}
+	if o.VideoBitrate == 0 {
+		o.VideoBitrate = DefaultVideoBitrate
+	}
+}
+
+// defaultKeyFrameInterval returns the shortest key frame interval required by targets.
+// Zero means encoder default, which is only used if all outputs are files.
+func defaultKeyFrameInterval(targets []EncodingTarget) float64 {
+	var kfi float64
+	for _, t := range targets {
+		var v float64
+		switch t.OutputType {
+		case OutputTypeStream:
+			v = DefaultStreamKeyFrames
+		case OutputTypeSegments:
+			v = DefaultSegmentDuration
+			if t.SegmentDuration != 0 {
+				v = float64(t.SegmentDuration)
+			}
+		default:
+			continue
+		}
+		if kfi == 0 || v < kfi {
+			kfi = v
+		}
+	}
+	return kfi
+}
+
+// NewEncodingRequest collects encoding options and output targets from an egress start request.
+func NewEncodingRequest(request interface{}) (*EncodingRequest, error) {
+	req := &EncodingRequest{Preset: DefaultPreset, Audio: true, Video: true}
+	var (
+		opts interface{}
+		out  EncodedOutput
+	)
+	switch r := request.(type) {
+	case *livekit.RoomCompositeEgressRequest:
+		opts, out = r.GetOptions(), r
+		req.Audio, req.Video = !r.VideoOnly, !r.AudioOnly
+	case *livekit.WebEgressRequest:
+		opts, out = r.GetOptions(), r
+		req.Audio, req.Video = !r.VideoOnly, !r.AudioOnly
+	case *livekit.ParticipantEgressRequest:
+		opts, out = r.GetOptions(), r
+	case *livekit.TrackCompositeEgressRequest:
+		opts, out = r.GetOptions(), r
+		req.Audio, req.Video = r.AudioTrackId != "", r.VideoTrackId != ""
+	default:
+		return nil, ErrInvalidEgress(fmt.Sprintf("request type %T has no encoding options", request))
+	}
+	var err error
+	if req.Preset, req.Advanced, err = encodingOptions(opts); err != nil {
+		return nil, err
+	}
+
+	files, streams, segments := out.GetFileOutputs(), out.GetStreamOutputs(), out.GetSegmentOutputs()
+	if d, ok := out.(EncodedOutputDeprecated); ok {
+		if f := d.GetFile(); f != nil {
+			files = append(files, f)
+		}
+		if s := d.GetStream(); s != nil {
+			streams = append(streams, s)
+		}
+		if s := d.GetSegments(); s != nil {
+			segments = append(segments, s)
+		}
+	}
+	for _, f := range files {
+		req.Targets = append(req.Targets, EncodingTarget{OutputType: OutputTypeFile, FileType: f.FileType})
+	}
+	for _, s := range streams {
+		req.Targets = append(req.Targets, EncodingTarget{OutputType: OutputTypeStream, StreamProtocol: s.Protocol})
+	}
+	for _, s := range segments {
+		req.Targets = append(req.Targets, EncodingTarget{
+			OutputType:            OutputTypeSegments,
+			SegmentedFileProtocol: s.Protocol,
+			SegmentDuration:       s.SegmentDuration,
+		})
+	}
+	for range out.GetImageOutputs() {
+		req.Targets = append(req.Targets, EncodingTarget{OutputType: OutputTypeImages})
+	}
+	return req, nil
+}
+
+// ResolveRequestEncoding resolves encoding options of an egress start request against its outputs.
+func ResolveRequestEncoding(request interface{}) (*ResolvedEncoding, error) {
+	req, err := NewEncodingRequest(request)
+	if err != nil {
+		return nil, err
+	}
+	return ResolveEncoding(*req)
+}
+
+// encodingOptions unwraps the options oneof of egress requests. Missing options result in DefaultPreset.
+func encodingOptions(opts interface{}) (livekit.EncodingOptionsPreset, *livekit.EncodingOptions, error) {
+	switch o := opts.(type) {
+	case nil:
+		return DefaultPreset, nil, nil
+	case *livekit.RoomCompositeEgressRequest_Preset:
+		return o.Preset, nil, nil
+	case *livekit.WebEgressRequest_Preset:
+		return o.Preset, nil, nil
+	case *livekit.ParticipantEgressRequest_Preset:
+		return o.Preset, nil, nil
+	case *livekit.TrackCompositeEgressRequest_Preset:
+		return o.Preset, nil, nil
+	case *livekit.AutoParticipantEgress_Preset:
+		return o.Preset, nil, nil
+	case *livekit.RoomCompositeEgressRequest_Advanced:
+		return DefaultPreset, o.Advanced, nil
+	case *livekit.WebEgressRequest_Advanced:
+		return DefaultPreset, o.Advanced, nil
+	case *livekit.ParticipantEgressRequest_Advanced:
+		return DefaultPreset, o.Advanced, nil
+	case *livekit.TrackCompositeEgressRequest_Advanced:
+		return DefaultPreset, o.Advanced, nil
+	case *livekit.AutoParticipantEgress_Advanced:
+		return DefaultPreset, o.Advanced, nil
+	}
+	return DefaultPreset, nil, NewInvalidEncodingOptionsError(fmt.Sprintf("unsupported options type %T", opts))
+}
@@ -0,0 +1,154 @@
+package egress
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestPresetOptions(t *testing.T) {
+	for p := range livekit.EncodingOptionsPreset_name {
+		opts, err := PresetOptions(livekit.EncodingOptionsPreset(p))
+		require.NoError(t, err)
+		require.NoError(t, ValidateEncodingOptions(opts), livekit.EncodingOptionsPreset(p).String())
+	}
+	opts, err := PresetOptions(livekit.EncodingOptionsPreset_PORTRAIT_H264_1080P_60)
+	require.NoError(t, err)
+	require.EqualValues(t, 1080, opts.Width)
+	require.EqualValues(t, 1920, opts.Height)
+	require.EqualValues(t, 60, opts.Framerate)
+	require.EqualValues(t, 6000, opts.VideoBitrate)
+
+	_, err = PresetOptions(100)
+	require.Error(t, err)
+}
+
+func TestResolveRequestEncoding(t *testing.T) {
+	t.Run("default preset", func(t *testing.T) {
+		res, err := ResolveRequestEncoding(&livekit.RoomCompositeEgressRequest{
+			RoomName:    "room",
+			FileOutputs: []*livekit.EncodedFileOutput{{}},
+		})
+		require.NoError(t, err)
+		require.Empty(t, res.Adjustments)
+		require.EqualValues(t, 1280, res.Options.Width)
+		require.EqualValues(t, 720, res.Options.Height)
+		require.Equal(t, livekit.AudioCodec_OPUS, res.Options.AudioCodec)
+		require.Equal(t, livekit.VideoCodec_H264_MAIN, res.Options.VideoCodec)
+		require.EqualValues(t, 0, res.Options.KeyFrameInterval)
+		require.EqualValues(t, 48000, res.Options.AudioFrequency)
+	})
+
+	t.Run("advanced defaults", func(t *testing.T) {
+		res, err := ResolveRequestEncoding(&livekit.WebEgressRequest{
+			Url: "https://example.com",
+			Options: &livekit.WebEgressRequest_Advanced{Advanced: &livekit.EncodingOptions{
+				AudioCodec: livekit.AudioCodec_AAC,
+				Framerate:  60,
+			}},
+			SegmentOutputs: []*livekit.SegmentedFileOutput{{SegmentDuration: 6}},
+			StreamOutputs:  []*livekit.StreamOutput{{Urls: []string{"rtmp://example.com/live/key"}}},
+		})
+		require.NoError(t, err)
+		require.Empty(t, res.Adjustments)
+		require.EqualValues(t, 1920, res.Options.Width)
+		require.EqualValues(t, 1080, res.Options.Height)
+		require.EqualValues(t, 24, res.Options.Depth)
+		require.EqualValues(t, 60, res.Options.Framerate)
+		require.EqualValues(t, 4500, res.Options.VideoBitrate)
+		require.EqualValues(t, 128, res.Options.AudioBitrate)
+		require.EqualValues(t, 44100, res.Options.AudioFrequency)
+		require.EqualValues(t, 4, res.Options.KeyFrameInterval)
+	})
+
+	t.Run("stream switches to aac", func(t *testing.T) {
+		res, err := ResolveRequestEncoding(&livekit.RoomCompositeEgressRequest{
+			RoomName:      "room",
+			FileOutputs:   []*livekit.EncodedFileOutput{{FileType: livekit.EncodedFileType_MP4}},
+			StreamOutputs: []*livekit.StreamOutput{{Protocol: livekit.StreamProtocol_SRT}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, livekit.AudioCodec_AAC, res.Options.AudioCodec)
+		require.EqualValues(t, 44100, res.Options.AudioFrequency)
+		require.Equal(t, []EncodingAdjustment{{
+			Field: "audio_codec", From: "OPUS", To: "AAC", Reason: "not supported by outputs",
+		}}, res.Adjustments)
+	})
+
+	t.Run("default codec frequency", func(t *testing.T) {
+		req := &livekit.TrackCompositeEgressRequest{
+			RoomName:     "room",
+			AudioTrackId: "TR_audio",
+			Options: &livekit.TrackCompositeEgressRequest_Advanced{Advanced: &livekit.EncodingOptions{
+				AudioFrequency: 44100,
+			}},
+			FileOutputs: []*livekit.EncodedFileOutput{{FileType: livekit.EncodedFileType_OGG}},
+		}
+		require.NoError(t, ValidateTrackComposite(req))
+		res, err := ResolveRequestEncoding(req)
+		require.NoError(t, err)
+		require.Equal(t, livekit.AudioCodec_OPUS, res.Options.AudioCodec)
+		require.EqualValues(t, 48000, res.Options.AudioFrequency)
+		require.Equal(t, []EncodingAdjustment{{
+			Field: "audio_frequency", From: "44100", To: "48000", Reason: "not supported by OPUS",
+		}}, res.Adjustments)
+	})
+
+	t.Run("odd dimensions", func(t *testing.T) {
+		res, err := ResolveRequestEncoding(&livekit.ParticipantEgressRequest{
+			RoomName: "room",
+			Identity: "user",
+			Options: &livekit.ParticipantEgressRequest_Advanced{Advanced: &livekit.EncodingOptions{
+				Width: 641, Height: 481,
+			}},
+			FileOutputs: []*livekit.EncodedFileOutput{{}},
+		})
+		require.NoError(t, err)
+		require.EqualValues(t, 640, res.Options.Width)
+		require.EqualValues(t, 480, res.Options.Height)
+		require.Len(t, res.Adjustments, 2)
+	})
+
+	t.Run("audio only", func(t *testing.T) {
+		res, err := ResolveRequestEncoding(&livekit.TrackCompositeEgressRequest{
+			RoomName:     "room",
+			AudioTrackId: "TR_audio",
+			FileOutputs:  []*livekit.EncodedFileOutput{{FileType: livekit.EncodedFileType_OGG}},
+		})
+		require.NoError(t, err)
+		require.Zero(t, res.Options.Width)
+		require.Equal(t, livekit.VideoCodec_DEFAULT_VC, res.Options.VideoCodec)
+		require.Equal(t, livekit.AudioCodec_OPUS, res.Options.AudioCodec)
+	})
+
+	t.Run("incompatible", func(t *testing.T) {
+		_, err := ResolveRequestEncoding(&livekit.RoomCompositeEgressRequest{
+			RoomName:      "room",
+			AudioOnly:     true,
+			FileOutputs:   []*livekit.EncodedFileOutput{{FileType: livekit.EncodedFileType_OGG}},
+			StreamOutputs: []*livekit.StreamOutput{{}},
+		})
+		require.Error(t, err)
+
+		_, err = ResolveRequestEncoding(&livekit.RoomCompositeEgressRequest{
+			RoomName:    "room",
+			FileOutputs: []*livekit.EncodedFileOutput{{FileType: livekit.EncodedFileType_OGG}},
+		})
+		require.Error(t, err)
+
+		_, err = ResolveRequestEncoding(&livekit.RoomCompositeEgressRequest{
+			RoomName:  "room",
+			AudioOnly: true,
+			Options: &livekit.RoomCompositeEgressRequest_Advanced{Advanced: &livekit.EncodingOptions{
+				AudioCodec: livekit.AudioCodec_AAC,
+			}},
+			FileOutputs: []*livekit.EncodedFileOutput{{FileType: livekit.EncodedFileType_OGG}},
+		})
+		require.Error(t, err)
+
+		_, err = ResolveRequestEncoding(&livekit.TrackEgressRequest{})
+		require.Error(t, err)
+	})
+}
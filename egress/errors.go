@@ -15,6 +15,8 @@
 package egress
 
 import (
+	"fmt"
+
 	"github.com/livekit/psrpc"
 )
 
@@ -22,3 +24,19 @@ var (
 	ErrNoResponse     = psrpc.NewErrorf(psrpc.Unavailable, "no response from egress service")
 	ErrEgressTimedOut = psrpc.NewErrorf(psrpc.DeadlineExceeded, "egress timed out")
 )
+
+func ErrInvalidEgress(s string) psrpc.Error {
+	return psrpc.NewErrorf(psrpc.InvalidArgument, "invalid egress: %s", s)
+}
+
+func NewInvalidEncodingOptionsError(s string) error {
+	return psrpc.NewError(psrpc.InvalidArgument, fmt.Errorf("invalid encoding options: %s", s))
+}
+
+func NewInvalidOutputError(s string) error {
+	return psrpc.NewError(psrpc.InvalidArgument, fmt.Errorf("invalid output: %s", s))
+}
+
+func NewInvalidUploadError(s string) error {
+	return psrpc.NewError(psrpc.InvalidArgument, fmt.Errorf("invalid upload config: %s", s))
+}
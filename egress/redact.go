@@ -1,75 +1,189 @@
 package egress
 
 import (
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/utils"
 )
 
-func RedactUpload(req UploadRequest) {
-	if s3 := req.GetS3(); s3 != nil {
-		s3.AccessKey = utils.Redact(s3.AccessKey, "{access_key}")
-		s3.Secret = utils.Redact(s3.Secret, "{secret}")
-		return
-	}
+// secretFields lists string fields that carry credentials, together with a value used in place of the secret.
+//
+// Every new credential field in egress messages must be added here, otherwise tests will fail.
+var secretFields = map[protoreflect.FullName]string{
+	"livekit.S3Upload.access_key":          "{access_key}",
+	"livekit.S3Upload.secret":              "{secret}",
+	"livekit.S3Upload.session_token":       "{session_token}",
+	"livekit.GCPUpload.credentials":        "{credentials}",
+	"livekit.AzureBlobUpload.account_name": "{account_name}",
+	"livekit.AzureBlobUpload.account_key":  "{account_key}",
+	"livekit.AliOSSUpload.access_key":      "{access_key}",
+	"livekit.AliOSSUpload.secret":          "{secret}",
+	"livekit.ProxyConfig.password":         "{password}",
+	"livekit.WebhookConfig.signing_key":    "{signing_key}",
+}
+
+// streamURLFields lists string fields with stream urls, which may contain stream keys.
+var streamURLFields = map[protoreflect.FullName]struct{}{
+	"livekit.StreamOutput.urls":                      {},
+	"livekit.StreamInfo.url":                         {},
+	"livekit.UpdateStreamRequest.add_output_urls":    {},
+	"livekit.UpdateStreamRequest.remove_output_urls": {},
+}
+
+// Redactor removes credentials and stream keys from messages in place.
+// The zero value only redacts egress fields, see Redact.
+type Redactor struct {
+	// SecretField reports whether a string field, in addition to egress credentials, carries a secret.
+	// Such fields are replaced by "{<field name>}".
+	SecretField func(fd protoreflect.FieldDescriptor) bool
+	// SecretMapKey reports whether a value of a string map with the key, like a header, carries a secret.
+	// Such values are replaced by "{<key>}".
+	SecretMapKey func(key string) bool
+}
 
-	if gcp := req.GetGcp(); gcp != nil {
-		gcp.Credentials = utils.Redact(gcp.Credentials, "{credentials}")
+// Redact removes secrets from the message, in place.
+func (r Redactor) Redact(m proto.Message) {
+	if m == nil {
 		return
 	}
+	r.redactMessage(m.ProtoReflect())
+}
+
+// Redact removes credentials and stream keys from egress info, an egress request or any part of it, in place.
+// All outputs are redacted, including deprecated ones.
+func Redact(m proto.Message) {
+	Redactor{}.Redact(m)
+}
 
-	if azure := req.GetAzure(); azure != nil {
-		azure.AccountName = utils.Redact(azure.AccountName, "{account_name}")
-		azure.AccountKey = utils.Redact(azure.AccountKey, "{account_key}")
+// Redacted returns a redacted copy of the message.
+func Redacted[T proto.Message](m T) T {
+	m = utils.CloneProto(m)
+	Redact(m)
+	return m
+}
+
+func (r Redactor) redactMessage(m protoreflect.Message) {
+	if !m.IsValid() {
 		return
 	}
+	type update struct {
+		fd protoreflect.FieldDescriptor
+		v  protoreflect.Value
+	}
+	// Fields must not be modified while iterating.
+	var updates []update
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case fd.IsMap():
+			switch fd.MapValue().Kind() {
+			case protoreflect.MessageKind:
+				v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+					r.redactMessage(mv.Message())
+					return true
+				})
+			case protoreflect.StringKind:
+				if r.SecretMapKey == nil || fd.MapKey().Kind() != protoreflect.StringKind {
+					break
+				}
+				mp := v.Map()
+				var keys []protoreflect.MapKey
+				mp.Range(func(k protoreflect.MapKey, _ protoreflect.Value) bool {
+					if r.SecretMapKey(k.String()) {
+						keys = append(keys, k)
+					}
+					return true
+				})
+				for _, k := range keys {
+					mp.Set(k, protoreflect.ValueOfString(utils.Redact(mp.Get(k).String(), "{"+k.String()+"}")))
+				}
+			}
+		case fd.Kind() == protoreflect.MessageKind || fd.Kind() == protoreflect.GroupKind:
+			if fd.IsList() {
+				for i, l := 0, v.List(); i < l.Len(); i++ {
+					r.redactMessage(l.Get(i).Message())
+				}
+			} else {
+				r.redactMessage(v.Message())
+			}
+		case fd.Kind() == protoreflect.StringKind:
+			name, ok := secretFields[fd.FullName()]
+			if !ok && r.SecretField != nil && r.SecretField(fd) {
+				name, ok = "{"+string(fd.Name())+"}", true
+			}
+			if ok {
+				if fd.IsList() {
+					for i, l := 0, v.List(); i < l.Len(); i++ {
+						l.Set(i, protoreflect.ValueOfString(utils.Redact(l.Get(i).String(), name)))
+					}
+				} else {
+					updates = append(updates, update{fd, protoreflect.ValueOfString(utils.Redact(v.String(), name))})
+				}
+			} else if _, ok := streamURLFields[fd.FullName()]; ok {
+				if fd.IsList() {
+					for i, l := 0, v.List(); i < l.Len(); i++ {
+						if redacted, ok := utils.RedactStreamKey(l.Get(i).String()); ok {
+							l.Set(i, protoreflect.ValueOfString(redacted))
+						}
+					}
+				} else if redacted, ok := utils.RedactStreamKey(v.String()); ok {
+					updates = append(updates, update{fd, protoreflect.ValueOfString(redacted)})
+				}
+			}
+		}
+		return true
+	})
+	for _, u := range updates {
+		m.Set(u.fd, u.v)
+	}
+}
 
-	if aliOSS := req.GetAliOSS(); aliOSS != nil {
-		aliOSS.AccessKey = utils.Redact(aliOSS.AccessKey, "{access_key}")
-		aliOSS.Secret = utils.Redact(aliOSS.Secret, "{secret}")
+func RedactUpload(req UploadRequest) {
+	if m, ok := req.(proto.Message); ok {
+		Redact(m)
 		return
 	}
+	for _, m := range []proto.Message{req.GetS3(), req.GetGcp(), req.GetAzure(), req.GetAliOSS()} {
+		Redact(m)
+	}
 }
 
 func RedactAutoEncodedOutput(out AutoEncodedOutput) {
-	if files := out.GetFileOutputs(); len(files) == 1 {
-		RedactUpload(files[0])
+	if m, ok := out.(proto.Message); ok {
+		Redact(m)
+		return
+	}
+	for _, file := range out.GetFileOutputs() {
+		Redact(file)
 	}
-	if segments := out.GetSegmentOutputs(); len(segments) == 1 {
-		RedactUpload(segments[0])
+	for _, segment := range out.GetSegmentOutputs() {
+		Redact(segment)
 	}
 }
 
 func RedactEncodedOutputs(out EncodedOutput) {
-	RedactAutoEncodedOutput(out)
-
-	if streams := out.GetStreamOutputs(); len(streams) == 1 {
-		RedactStreamKeys(streams[0])
+	if m, ok := out.(proto.Message); ok {
+		Redact(m)
+		return
 	}
-	if images := out.GetImageOutputs(); len(images) == 1 {
-		RedactUpload(images[0])
+	RedactAutoEncodedOutput(out)
+	for _, stream := range out.GetStreamOutputs() {
+		Redact(stream)
 	}
-
-	if o, ok := out.(EncodedOutputDeprecated); ok {
-		if file := o.GetFile(); file != nil {
-			RedactUpload(file)
-		} else if stream := o.GetStream(); stream != nil {
-			RedactStreamKeys(stream)
-		} else if segment := o.GetSegments(); segment != nil {
-			RedactUpload(segment)
-		}
+	for _, image := range out.GetImageOutputs() {
+		Redact(image)
 	}
 }
 
 func RedactDirectOutputs(out DirectOutput) {
-	if f := out.GetFile(); f != nil {
-		RedactUpload(f)
+	if m, ok := out.(proto.Message); ok {
+		Redact(m)
+		return
 	}
+	Redact(out.GetFile())
 }
 
 func RedactStreamKeys(stream *livekit.StreamOutput) {
-	for i, url := range stream.Urls {
-		if redacted, ok := utils.RedactStreamKey(url); ok {
-			stream.Urls[i] = redacted
-		}
-	}
+	Redact(stream)
 }
@@ -1,9 +1,12 @@
 package egress
 
 import (
+	"regexp"
 	"testing"
 
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
 
 	"github.com/livekit/protocol/livekit"
 	"github.com/stretchr/testify/require"
@@ -137,3 +140,127 @@ func TestRedactDirectOutput(t *testing.T) {
 	require.Equal(t, "{access_key}", track.Output.(*livekit.TrackEgressRequest_File).File.Output.(*livekit.DirectFileOutput_S3).S3.AccessKey)
 	require.Equal(t, "{secret}", track.Output.(*livekit.TrackEgressRequest_File).File.Output.(*livekit.DirectFileOutput_S3).S3.Secret)
 }
+
+func TestRedactMultipleOutputs(t *testing.T) {
+	req := &livekit.RoomCompositeEgressRequest{
+		FileOutputs: []*livekit.EncodedFileOutput{file, {
+			Output: &livekit.EncodedFileOutput_Gcp{Gcp: &livekit.GCPUpload{
+				Credentials: "CREDENTIALS",
+				Proxy:       &livekit.ProxyConfig{Url: "http://proxy", Username: "user", Password: "PASSWORD"},
+			}},
+		}},
+		StreamOutputs: []*livekit.StreamOutput{
+			{Urls: []string{"rtmp://foo.bar.com/app/secret_stream_key"}},
+			{Urls: []string{"rtmps://foo.bar.com/app/other_stream_key"}},
+		},
+		SegmentOutputs: []*livekit.SegmentedFileOutput{segments, {
+			Output: &livekit.SegmentedFileOutput_S3{S3: &livekit.S3Upload{
+				AccessKey:    "ACCESS_KEY",
+				Secret:       "SECRET",
+				SessionToken: "SESSION_TOKEN",
+			}},
+		}},
+		Webhooks: []*livekit.WebhookConfig{{Url: "https://example.com", SigningKey: "SIGNING_KEY"}},
+	}
+	info := &livekit.EgressInfo{
+		Request: &livekit.EgressInfo_RoomComposite{RoomComposite: req},
+		StreamResults: []*livekit.StreamInfo{
+			{Url: "rtmp://foo.bar.com/app/secret_stream_key"},
+		},
+	}
+
+	cl := Redacted(info)
+	require.Equal(t, "CREDENTIALS", req.FileOutputs[1].GetGcp().Credentials, "original must not be modified")
+
+	r := cl.GetRoomComposite()
+	require.Equal(t, "{access_key}", r.FileOutputs[0].GetS3().AccessKey)
+	require.Equal(t, "{credentials}", r.FileOutputs[1].GetGcp().Credentials)
+	require.Equal(t, "user", r.FileOutputs[1].GetGcp().Proxy.Username)
+	require.Equal(t, "{password}", r.FileOutputs[1].GetGcp().Proxy.Password)
+	require.Equal(t, "rtmp://foo.bar.com/app/{sec...key}", r.StreamOutputs[0].Urls[0])
+	require.Equal(t, "rtmps://foo.bar.com/app/{oth...key}", r.StreamOutputs[1].Urls[0])
+	require.Equal(t, "{credentials}", r.SegmentOutputs[0].GetGcp().Credentials)
+	require.Equal(t, "{session_token}", r.SegmentOutputs[1].GetS3().SessionToken)
+	require.Equal(t, "{signing_key}", r.Webhooks[0].SigningKey)
+	require.Equal(t, "rtmp://foo.bar.com/app/{sec...key}", cl.StreamResults[0].Url)
+}
+
+var (
+	// secretLikeField matches names of fields that may carry credentials.
+	secretLikeField = regexp.MustCompile(`(?i)(key|secret|password|passwd|token|credential|auth)`)
+	// urlLikeField matches names of fields that may carry stream urls.
+	urlLikeField = regexp.MustCompile(`(?i)url`)
+
+	// notSecretFields lists fields matched by the patterns above that are known to be safe.
+	notSecretFields = map[protoreflect.FullName]bool{
+		"livekit.RoomCompositeEgressRequest.custom_base_url": true,
+		"livekit.WebEgressRequest.url":                       true,
+		"livekit.TrackEgressRequest.websocket_url":           true,
+		"livekit.WebhookConfig.url":                          true,
+		"livekit.ProxyConfig.url":                            true,
+	}
+)
+
+// TestRedactAllSecretFields walks all messages reachable from egress info and requests
+// and fails if a field that looks like it carries a secret is not redacted.
+func TestRedactAllSecretFields(t *testing.T) {
+	roots := []proto.Message{
+		&livekit.EgressInfo{},
+		&livekit.RoomCompositeEgressRequest{},
+		&livekit.WebEgressRequest{},
+		&livekit.ParticipantEgressRequest{},
+		&livekit.TrackCompositeEgressRequest{},
+		&livekit.TrackEgressRequest{},
+		&livekit.UpdateStreamRequest{},
+		&livekit.AutoParticipantEgress{},
+		&livekit.AutoTrackEgress{},
+	}
+	seen := make(map[protoreflect.FullName]bool)
+	var walk func(md protoreflect.MessageDescriptor)
+	walk = func(md protoreflect.MessageDescriptor) {
+		if seen[md.FullName()] {
+			return
+		}
+		seen[md.FullName()] = true
+		fields := md.Fields()
+		for i := 0; i < fields.Len(); i++ {
+			fd := fields.Get(i)
+			if fd.IsMap() {
+				fd = fd.MapValue()
+			}
+			switch fd.Kind() {
+			case protoreflect.MessageKind, protoreflect.GroupKind:
+				walk(fd.Message())
+			case protoreflect.StringKind:
+				name := fields.Get(i).FullName()
+				if notSecretFields[name] {
+					continue
+				}
+				_, isSecret := secretFields[name]
+				_, isURL := streamURLFields[name]
+				if secretLikeField.MatchString(string(fields.Get(i).Name())) {
+					require.True(t, isSecret, "field %s looks like a secret, add it to secretFields or notSecretFields", name)
+				}
+				if urlLikeField.MatchString(string(fields.Get(i).Name())) {
+					require.True(t, isURL, "field %s looks like a stream url, add it to streamURLFields or notSecretFields", name)
+				}
+			}
+		}
+	}
+	for _, m := range roots {
+		walk(m.ProtoReflect().Descriptor())
+	}
+
+	// Make sure every registered field is actually redacted.
+	for name, placeholder := range secretFields {
+		desc, err := protoregistry.GlobalFiles.FindDescriptorByName(name)
+		require.NoError(t, err, name)
+		fd := desc.(protoreflect.FieldDescriptor)
+		mt, err := protoregistry.GlobalTypes.FindMessageByName(fd.ContainingMessage().FullName())
+		require.NoError(t, err, name)
+		m := mt.New()
+		m.Set(fd, protoreflect.ValueOfString("SECRET_VALUE"))
+		Redact(m.Interface())
+		require.Equal(t, placeholder, m.Get(fd).String(), name)
+	}
+}
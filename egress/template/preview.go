@@ -0,0 +1,103 @@
+// Copyright 2025 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package template
+
+import (
+	"fmt"
+
+	"github.com/livekit/protocol/egress"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/utils"
+)
+
+// PreviewPath is an expanded path of a single egress output.
+type PreviewPath struct {
+	// OutputType is one of egress.OutputTypeFile, egress.OutputTypeSegments or egress.OutputTypeImages.
+	OutputType string
+	// Template used for the output, after applying defaults.
+	Template string
+	Backend  Backend
+	// Path is the expanded file path or filename prefix.
+	Path string
+}
+
+// Preview expands file paths and filename prefixes of all outputs of an egress start request,
+// as they would be expanded if the egress started at the clock's current time.
+//
+// Stream outputs have no paths and are skipped. Values which are unknown before the start (like room ID) expand to empty strings.
+func Preview(req interface{}, clock utils.Clock) ([]PreviewPath, error) {
+	vars := VarsFromRequest(req, clock)
+
+	def := DefaultRoomTemplate
+	if _, ok := req.(*livekit.TrackEgressRequest); ok {
+		def = DefaultTrackTemplate
+	}
+	var out []PreviewPath
+	add := func(typ, tmpl string, up egress.UploadRequest) error {
+		if tmpl == "" {
+			tmpl = def
+		}
+		t, err := Parse(tmpl)
+		if err != nil {
+			return fmt.Errorf("%s output: %w", typ, err)
+		}
+		b := BackendFor(up)
+		out = append(out, PreviewPath{
+			OutputType: typ,
+			Template:   tmpl,
+			Backend:    b,
+			Path:       t.Expand(vars, b),
+		})
+		return nil
+	}
+
+	switch r := req.(type) {
+	case *livekit.TrackEgressRequest:
+		if f := r.GetFile(); f != nil {
+			if err := add(egress.OutputTypeFile, f.Filepath, f); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	case egress.EncodedOutput:
+		files := r.GetFileOutputs()
+		segments := r.GetSegmentOutputs()
+		if d, ok := r.(egress.EncodedOutputDeprecated); ok {
+			if f := d.GetFile(); f != nil {
+				files = append([]*livekit.EncodedFileOutput{f}, files...)
+			}
+			if s := d.GetSegments(); s != nil {
+				segments = append([]*livekit.SegmentedFileOutput{s}, segments...)
+			}
+		}
+		for _, f := range files {
+			if err := add(egress.OutputTypeFile, f.Filepath, f); err != nil {
+				return nil, err
+			}
+		}
+		for _, s := range segments {
+			if err := add(egress.OutputTypeSegments, s.FilenamePrefix, s); err != nil {
+				return nil, err
+			}
+		}
+		for _, img := range r.GetImageOutputs() {
+			if err := add(egress.OutputTypeImages, img.FilenamePrefix, img); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("unsupported request type %T", req)
+}
@@ -0,0 +1,108 @@
+// Copyright 2025 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package template
+
+import (
+	"strings"
+
+	"github.com/livekit/protocol/egress"
+)
+
+// Backend is a storage backend the expanded path is used for.
+type Backend int
+
+const (
+	// BackendDefault is used when the output has no upload config and the egress service decides where to store it.
+	// It is the most restrictive one.
+	BackendDefault Backend = iota
+	BackendLocal
+	BackendS3
+	BackendGCP
+	BackendAzure
+	BackendAliOSS
+)
+
+// ReplacementChar replaces characters which are not allowed in paths.
+const ReplacementChar = '_'
+
+func (b Backend) String() string {
+	switch b {
+	case BackendDefault:
+		return "default"
+	case BackendLocal:
+		return "local"
+	case BackendS3:
+		return "s3"
+	case BackendGCP:
+		return "gcp"
+	case BackendAzure:
+		return "azure"
+	case BackendAliOSS:
+		return "alioss"
+	}
+	return "unknown"
+}
+
+// BackendFor returns a backend used by an output upload config.
+func BackendFor(req egress.UploadRequest) Backend {
+	switch {
+	case req.GetS3() != nil:
+		return BackendS3
+	case req.GetGcp() != nil:
+		return BackendGCP
+	case req.GetAzure() != nil:
+		return BackendAzure
+	case req.GetAliOSS() != nil:
+		return BackendAliOSS
+	}
+	return BackendDefault
+}
+
+// Characters that are not allowed or that must be avoided in object names, in addition to control characters.
+var illegalChars = map[Backend]string{
+	BackendLocal:  `<>:"\|?*`,
+	BackendS3:     "\\{}^%`[]\"<>~#|",
+	BackendGCP:    `\#[]*?`,
+	BackendAzure:  `\`,
+	BackendAliOSS: `\`,
+}
+
+func init() {
+	var all strings.Builder
+	for _, s := range illegalChars {
+		all.WriteString(s)
+	}
+	illegalChars[BackendDefault] = all.String()
+}
+
+// Sanitize replaces characters that are not allowed in a path for the backend. Slashes are preserved.
+func (b Backend) Sanitize(s string) string {
+	return b.sanitize(s, false)
+}
+
+func (b Backend) sanitize(s string, value bool) string {
+	chars := illegalChars[b]
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r < 0x20 || r == 0x7f:
+			return ReplacementChar
+		case value && r == '/':
+			return ReplacementChar
+		case strings.ContainsRune(chars, r):
+			return ReplacementChar
+		}
+		return r
+	}, s)
+}
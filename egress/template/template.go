@@ -0,0 +1,251 @@
+// Copyright 2025 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package template implements templating used in egress output file paths and filename prefixes,
+// for example "{room_name}/{time}.mp4".
+package template
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/utils"
+)
+
+// Placeholder is a name of the template variable, without braces.
+type Placeholder string
+
+const (
+	RoomName          Placeholder = "room_name"
+	RoomID            Placeholder = "room_id"
+	Time              Placeholder = "time"
+	UTC               Placeholder = "utc"
+	PublisherIdentity Placeholder = "publisher_identity"
+	TrackID           Placeholder = "track_id"
+	TrackType         Placeholder = "track_type"
+	TrackSource       Placeholder = "track_source"
+)
+
+// Placeholders lists all supported placeholders.
+var Placeholders = []Placeholder{
+	RoomName, RoomID, Time, UTC, PublisherIdentity, TrackID, TrackType, TrackSource,
+}
+
+const (
+	// TimeLayout is the layout of {time} expansion.
+	TimeLayout = "2006-01-02T150405"
+	// UTCLayout is the layout of {utc} expansion.
+	UTCLayout = "20060102150405"
+)
+
+const (
+	// DefaultRoomTemplate is used for files and prefixes of composite and participant egress.
+	DefaultRoomTemplate = "{room_name}-{time}"
+	// DefaultTrackTemplate is used for files of track egress.
+	DefaultTrackTemplate = "{track_id}-{time}"
+)
+
+func isPlaceholder(name string) bool {
+	for _, p := range Placeholders {
+		if string(p) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Error is returned when the template cannot be parsed.
+type Error struct {
+	Template string
+	Offset   int
+	Reason   string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("invalid template %q at offset %d: %s", e.Template, e.Offset, e.Reason)
+}
+
+type part struct {
+	text string
+	ph   Placeholder // set if this part is a placeholder
+}
+
+// Template is a parsed egress file path template.
+type Template struct {
+	raw   string
+	parts []part
+}
+
+// Parse the template. It returns an error for unknown placeholders and unbalanced braces.
+func Parse(s string) (*Template, error) {
+	t := &Template{raw: s}
+	for off := 0; off < len(s); {
+		i := strings.IndexAny(s[off:], "{}")
+		if i < 0 {
+			t.parts = append(t.parts, part{text: s[off:]})
+			break
+		}
+		if i > 0 {
+			t.parts = append(t.parts, part{text: s[off : off+i]})
+		}
+		off += i
+		if s[off] == '}' {
+			return nil, &Error{Template: s, Offset: off, Reason: "unexpected '}'"}
+		}
+		end := strings.IndexAny(s[off+1:], "{}")
+		if end < 0 || s[off+1+end] != '}' {
+			return nil, &Error{Template: s, Offset: off, Reason: "unclosed '{'"}
+		}
+		name := s[off+1 : off+1+end]
+		if !isPlaceholder(name) {
+			return nil, &Error{Template: s, Offset: off, Reason: fmt.Sprintf("unknown placeholder {%s}", name)}
+		}
+		t.parts = append(t.parts, part{ph: Placeholder(name)})
+		off += end + 2
+	}
+	return t, nil
+}
+
+// MustParse is like Parse, but panics on error.
+func MustParse(s string) *Template {
+	t, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Validate checks that the template can be parsed.
+func Validate(s string) error {
+	_, err := Parse(s)
+	return err
+}
+
+// String returns the original template.
+func (t *Template) String() string {
+	return t.raw
+}
+
+// Placeholders returns placeholders used in the template, in order of appearance.
+func (t *Template) Placeholders() []Placeholder {
+	var out []Placeholder
+	for _, p := range t.parts {
+		if p.ph != "" {
+			out = append(out, p.ph)
+		}
+	}
+	return out
+}
+
+// Vars are values used for template expansion. Empty values expand to empty strings.
+type Vars struct {
+	RoomName          string
+	RoomID            string
+	PublisherIdentity string
+	TrackID           string
+	TrackType         string
+	TrackSource       string
+	Time              time.Time
+}
+
+// Get returns a value for a given placeholder.
+func (v *Vars) Get(p Placeholder) string {
+	switch p {
+	case RoomName:
+		return v.RoomName
+	case RoomID:
+		return v.RoomID
+	case PublisherIdentity:
+		return v.PublisherIdentity
+	case TrackID:
+		return v.TrackID
+	case TrackType:
+		return v.TrackType
+	case TrackSource:
+		return v.TrackSource
+	case Time:
+		if v.Time.IsZero() {
+			return ""
+		}
+		return v.Time.Format(TimeLayout)
+	case UTC:
+		if v.Time.IsZero() {
+			return ""
+		}
+		return v.Time.UTC().Format(UTCLayout)
+	}
+	return ""
+}
+
+// Expand the template with given values, sanitizing the result for a given storage backend.
+//
+// Values never introduce new path segments: slashes in values are replaced.
+func (t *Template) Expand(vars Vars, b Backend) string {
+	var sb strings.Builder
+	for _, p := range t.parts {
+		if p.ph == "" {
+			sb.WriteString(b.sanitize(p.text, false))
+		} else {
+			sb.WriteString(b.sanitize(vars.Get(p.ph), true))
+		}
+	}
+	return sb.String()
+}
+
+// VarsFromInfo collects template values from egress info. Start time of the egress is used, if set.
+func VarsFromInfo(info *livekit.EgressInfo, clock utils.Clock) Vars {
+	v := VarsFromRequest(info.Request, clock)
+	v.RoomID = info.RoomId
+	if v.RoomName == "" {
+		v.RoomName = info.RoomName
+	}
+	if info.StartedAt != 0 {
+		v.Time = time.Unix(0, info.StartedAt).In(v.Time.Location())
+	}
+	return v
+}
+
+// VarsFromRequest collects template values from an egress start request or livekit.EgressInfo request field.
+// Time is taken from the clock.
+func VarsFromRequest(req interface{}, clock utils.Clock) Vars {
+	if clock == nil {
+		clock = utils.SystemClock{}
+	}
+	v := Vars{Time: clock.Now()}
+	switch r := req.(type) {
+	case *livekit.EgressInfo_RoomComposite:
+		return VarsFromRequest(r.RoomComposite, clock)
+	case *livekit.EgressInfo_Web:
+		return VarsFromRequest(r.Web, clock)
+	case *livekit.EgressInfo_Participant:
+		return VarsFromRequest(r.Participant, clock)
+	case *livekit.EgressInfo_TrackComposite:
+		return VarsFromRequest(r.TrackComposite, clock)
+	case *livekit.EgressInfo_Track:
+		return VarsFromRequest(r.Track, clock)
+	case *livekit.RoomCompositeEgressRequest:
+		v.RoomName = r.RoomName
+	case *livekit.ParticipantEgressRequest:
+		v.RoomName = r.RoomName
+		v.PublisherIdentity = r.Identity
+	case *livekit.TrackCompositeEgressRequest:
+		v.RoomName = r.RoomName
+	case *livekit.TrackEgressRequest:
+		v.RoomName = r.RoomName
+		v.TrackID = r.TrackId
+	}
+	return v
+}
@@ -0,0 +1,112 @@
+package template
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/egress"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/utils"
+)
+
+func newClock(t time.Time) *utils.SimulatedClock {
+	c := &utils.SimulatedClock{}
+	c.Set(t)
+	return c
+}
+
+func TestParse(t *testing.T) {
+	for _, c := range []struct {
+		tmpl string
+		ph   []Placeholder
+		err  string
+	}{
+		{tmpl: "", ph: nil},
+		{tmpl: "recording.mp4", ph: nil},
+		{tmpl: "{room_name}-{time}", ph: []Placeholder{RoomName, Time}},
+		{tmpl: "rooms/{room_id}/{publisher_identity}_{track_source}.{track_type}", ph: []Placeholder{RoomID, PublisherIdentity, TrackSource, TrackType}},
+		{tmpl: "{room}", err: "unknown placeholder {room}"},
+		{tmpl: "{room_name", err: "unclosed '{'"},
+		{tmpl: "{room_{name}}", err: "unclosed '{'"},
+		{tmpl: "room}", err: "unexpected '}'"},
+		{tmpl: "{}", err: "unknown placeholder {}"},
+	} {
+		t.Run(c.tmpl, func(t *testing.T) {
+			tm, err := Parse(c.tmpl)
+			if c.err != "" {
+				require.ErrorContains(t, err, c.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.ph, tm.Placeholders())
+			require.Equal(t, c.tmpl, tm.String())
+		})
+	}
+}
+
+func TestExpand(t *testing.T) {
+	now := time.Date(2025, 3, 4, 5, 6, 7, 0, time.UTC)
+	vars := Vars{
+		RoomName:          "my/room?",
+		RoomID:            "RM_123",
+		PublisherIdentity: "user#1",
+		Time:              now,
+	}
+	tm := MustParse("{room_id}/{room_name}-{publisher_identity}-{time}-{utc}{track_id}.mp4")
+	require.Equal(t, "RM_123/my_room_-user_1-2025-03-04T050607-20250304050607.mp4", tm.Expand(vars, BackendDefault))
+	require.Equal(t, "RM_123/my_room?-user#1-2025-03-04T050607-20250304050607.mp4", tm.Expand(vars, BackendAzure))
+	require.Equal(t, "RM_123/my_room?-user_1-2025-03-04T050607-20250304050607.mp4", tm.Expand(vars, BackendS3))
+
+	require.Equal(t, "a_b/c", BackendLocal.Sanitize("a:b/c"))
+	require.Equal(t, "a_b", BackendGCP.Sanitize("a\nb"))
+}
+
+func TestVarsFromInfo(t *testing.T) {
+	clock := newClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	started := time.Date(2025, 2, 2, 10, 0, 0, 0, time.UTC)
+	info := &livekit.EgressInfo{
+		RoomId:    "RM_1",
+		RoomName:  "room",
+		StartedAt: started.UnixNano(),
+		Request: &livekit.EgressInfo_Track{Track: &livekit.TrackEgressRequest{
+			RoomName: "room",
+			TrackId:  "TR_1",
+		}},
+	}
+	v := VarsFromInfo(info, clock)
+	require.Equal(t, Vars{RoomName: "room", RoomID: "RM_1", TrackID: "TR_1", Time: started}, v)
+}
+
+func TestPreview(t *testing.T) {
+	clock := newClock(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	req := &livekit.ParticipantEgressRequest{
+		RoomName: "room",
+		Identity: "alice",
+		FileOutputs: []*livekit.EncodedFileOutput{{
+			Filepath: "{room_name}/{publisher_identity}.mp4",
+			Output:   &livekit.EncodedFileOutput_S3{S3: &livekit.S3Upload{Bucket: "b"}},
+		}},
+		SegmentOutputs: []*livekit.SegmentedFileOutput{{}},
+	}
+	paths, err := Preview(req, clock)
+	require.NoError(t, err)
+	require.Equal(t, []PreviewPath{
+		{OutputType: egress.OutputTypeFile, Template: "{room_name}/{publisher_identity}.mp4", Backend: BackendS3, Path: "room/alice.mp4"},
+		{OutputType: egress.OutputTypeSegments, Template: DefaultRoomTemplate, Backend: BackendDefault, Path: "room-2025-01-01T120000"},
+	}, paths)
+
+	paths, err = Preview(&livekit.TrackEgressRequest{
+		RoomName: "room",
+		TrackId:  "TR_1",
+		Output:   &livekit.TrackEgressRequest_File{File: &livekit.DirectFileOutput{}},
+	}, clock)
+	require.NoError(t, err)
+	require.Equal(t, "TR_1-2025-01-01T120000", paths[0].Path)
+
+	_, err = Preview(&livekit.RoomCompositeEgressRequest{
+		FileOutputs: []*livekit.EncodedFileOutput{{Filepath: "{bad}"}},
+	}, clock)
+	require.Error(t, err)
+}
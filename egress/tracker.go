@@ -0,0 +1,112 @@
+// Copyright 2025 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package egress
+
+import (
+	"sync"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/utils"
+)
+
+// TransitionHandler is called after each egress status change with a snapshot of egress info.
+type TransitionHandler func(info *livekit.EgressInfo, t livekit.EgressTransition)
+
+// Tracker owns the egress info, enforces lifecycle transitions and keeps a history of status changes.
+// It is safe for concurrent use.
+type Tracker struct {
+	mu           sync.Mutex
+	info         *livekit.EgressInfo
+	history      []livekit.EgressTransition
+	onTransition TransitionHandler
+}
+
+type TrackerOption func(t *Tracker)
+
+// WithTransitionHandler sets a handler called on each status change, for example to send egress_updated webhooks.
+// The handler is called while holding the tracker lock, so transitions are observed in order.
+func WithTransitionHandler(h TransitionHandler) TrackerOption {
+	return func(t *Tracker) {
+		t.onTransition = h
+	}
+}
+
+// NewTracker creates a tracker for a copy of the egress info.
+func NewTracker(info *livekit.EgressInfo, opts ...TrackerOption) *Tracker {
+	t := &Tracker{info: utils.CloneProto(info)}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Info returns a copy of the current egress info.
+func (t *Tracker) Info() *livekit.EgressInfo {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return utils.CloneProto(t.info)
+}
+
+// History returns all status changes, oldest first.
+func (t *Tracker) History() []livekit.EgressTransition {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]livekit.EgressTransition(nil), t.history...)
+}
+
+// Transition changes egress status. See livekit.EgressInfo.Transition.
+func (t *Tracker) Transition(to livekit.EgressStatus, reason string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	from := t.info.Status
+	if err := t.info.Transition(to, reason); err != nil {
+		return err
+	}
+	t.record(from, reason)
+	return nil
+}
+
+// Update applies a newer version of egress info, for example received via IOInfo.UpdateEgress.
+// Stale updates and illegal status changes are rejected. See livekit.EgressInfo.ApplyUpdate.
+func (t *Tracker) Update(update *livekit.EgressInfo) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	from := t.info.Status
+	if err := t.info.ApplyUpdate(update); err != nil {
+		return err
+	}
+	reason := t.info.Error
+	if reason == "" {
+		reason = t.info.Details
+	}
+	t.record(from, reason)
+	return nil
+}
+
+func (t *Tracker) record(from livekit.EgressStatus, reason string) {
+	if from == t.info.Status {
+		return
+	}
+	tr := livekit.EgressTransition{
+		From:   from,
+		To:     t.info.Status,
+		Reason: reason,
+		At:     t.info.UpdatedAt,
+	}
+	t.history = append(t.history, tr)
+	if t.onTransition != nil {
+		t.onTransition(utils.CloneProto(t.info), tr)
+	}
+}
@@ -0,0 +1,41 @@
+package egress
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/webhook"
+)
+
+func TestTracker(t *testing.T) {
+	var events []*livekit.WebhookEvent
+	tr := NewTracker(&livekit.EgressInfo{EgressId: "EG_1"}, WithTransitionHandler(func(info *livekit.EgressInfo, t livekit.EgressTransition) {
+		events = append(events, webhook.EgressTransitionEvent(info, t))
+	}))
+
+	require.NoError(t, tr.Transition(livekit.EgressStatus_EGRESS_ACTIVE, ""))
+
+	update := tr.Info()
+	update.Status = livekit.EgressStatus_EGRESS_COMPLETE
+	update.UpdatedAt++
+	require.NoError(t, tr.Update(update))
+
+	stale := tr.Info()
+	stale.Status = livekit.EgressStatus_EGRESS_ACTIVE
+	stale.UpdatedAt--
+	require.ErrorIs(t, tr.Update(stale), livekit.ErrEgressStaleUpdate)
+	require.Error(t, tr.Transition(livekit.EgressStatus_EGRESS_ACTIVE, ""))
+
+	h := tr.History()
+	require.Len(t, h, 2)
+	require.Equal(t, livekit.EgressStatus_EGRESS_STARTING, h[0].From)
+	require.Equal(t, livekit.EgressStatus_EGRESS_ACTIVE, h[0].To)
+	require.Equal(t, livekit.EgressStatus_EGRESS_COMPLETE, h[1].To)
+
+	require.Len(t, events, 2)
+	require.Equal(t, webhook.EventEgressUpdated, events[0].Event)
+	require.Equal(t, livekit.EgressStatus_EGRESS_ACTIVE, events[0].EgressInfo.Status)
+	require.Equal(t, webhook.EventEgressEnded, events[1].Event)
+}
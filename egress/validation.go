@@ -0,0 +1,510 @@
+// Copyright 2025 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package egress
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+// This validates that egress start requests have no consistency issues and provide enough parameters
+// to be usable by the egress service. Optional fields left empty will be populated with default values by the egress service.
+
+// Validate checks one of the egress start requests: RoomCompositeEgressRequest, WebEgressRequest,
+// ParticipantEgressRequest, TrackCompositeEgressRequest or TrackEgressRequest.
+func Validate(request interface{}) error {
+	switch req := request.(type) {
+	case *livekit.RoomCompositeEgressRequest:
+		return ValidateRoomComposite(req)
+	case *livekit.WebEgressRequest:
+		return ValidateWeb(req)
+	case *livekit.ParticipantEgressRequest:
+		return ValidateParticipant(req)
+	case *livekit.TrackCompositeEgressRequest:
+		return ValidateTrackComposite(req)
+	case *livekit.TrackEgressRequest:
+		return ValidateTrack(req)
+	case nil:
+		return ErrInvalidEgress("missing request")
+	}
+	return ErrInvalidEgress(fmt.Sprintf("unsupported request type %T", request))
+}
+
+// mediaKinds describes which media an egress will produce.
+type mediaKinds struct {
+	audio bool
+	video bool
+	// videoUnknown is set if video may or may not be produced, so audio-only outputs are allowed.
+	videoUnknown bool
+}
+
+func ValidateRoomComposite(req *livekit.RoomCompositeEgressRequest) error {
+	if req == nil {
+		return ErrInvalidEgress("missing request")
+	}
+	if req.RoomName == "" {
+		return ErrInvalidEgress("no room name")
+	}
+	if req.AudioOnly && req.VideoOnly {
+		return ErrInvalidEgress("audio_only and video_only are mutually exclusive")
+	}
+	if req.CustomBaseUrl != "" {
+		if err := validateHTTPURL(req.CustomBaseUrl); err != nil {
+			return ErrInvalidEgress(fmt.Sprintf("custom base url: %v", err))
+		}
+	}
+	media := mediaKinds{audio: !req.VideoOnly, video: !req.AudioOnly}
+	if err := validateEncodingOptions(req.GetOptions()); err != nil {
+		return err
+	}
+	if err := validateEncodedOutputs(req, media); err != nil {
+		return err
+	}
+	return validateWebhooks(req.Webhooks)
+}
+
+func ValidateWeb(req *livekit.WebEgressRequest) error {
+	if req == nil {
+		return ErrInvalidEgress("missing request")
+	}
+	if req.Url == "" {
+		return ErrInvalidEgress("no url")
+	}
+	if err := validateHTTPURL(req.Url); err != nil {
+		return ErrInvalidEgress(fmt.Sprintf("url: %v", err))
+	}
+	if req.AudioOnly && req.VideoOnly {
+		return ErrInvalidEgress("audio_only and video_only are mutually exclusive")
+	}
+	media := mediaKinds{audio: !req.VideoOnly, video: !req.AudioOnly}
+	if err := validateEncodingOptions(req.GetOptions()); err != nil {
+		return err
+	}
+	if err := validateEncodedOutputs(req, media); err != nil {
+		return err
+	}
+	return validateWebhooks(req.Webhooks)
+}
+
+func ValidateParticipant(req *livekit.ParticipantEgressRequest) error {
+	if req == nil {
+		return ErrInvalidEgress("missing request")
+	}
+	if req.RoomName == "" {
+		return ErrInvalidEgress("no room name")
+	}
+	if req.Identity == "" {
+		return ErrInvalidEgress("no participant identity")
+	}
+	// Participant may not publish video, but we cannot know it in advance.
+	media := mediaKinds{audio: true, video: true, videoUnknown: true}
+	if err := validateEncodingOptions(req.GetOptions()); err != nil {
+		return err
+	}
+	if err := validateEncodedOutputs(req, media); err != nil {
+		return err
+	}
+	return validateWebhooks(req.Webhooks)
+}
+
+func ValidateTrackComposite(req *livekit.TrackCompositeEgressRequest) error {
+	if req == nil {
+		return ErrInvalidEgress("missing request")
+	}
+	if req.RoomName == "" {
+		return ErrInvalidEgress("no room name")
+	}
+	if req.AudioTrackId == "" && req.VideoTrackId == "" {
+		return ErrInvalidEgress("no audio or video track id")
+	}
+	media := mediaKinds{audio: req.AudioTrackId != "", video: req.VideoTrackId != ""}
+	if err := validateEncodingOptions(req.GetOptions()); err != nil {
+		return err
+	}
+	if err := validateEncodedOutputs(req, media); err != nil {
+		return err
+	}
+	return validateWebhooks(req.Webhooks)
+}
+
+func ValidateTrack(req *livekit.TrackEgressRequest) error {
+	if req == nil {
+		return ErrInvalidEgress("missing request")
+	}
+	if req.RoomName == "" {
+		return ErrInvalidEgress("no room name")
+	}
+	if req.TrackId == "" {
+		return ErrInvalidEgress("no track id")
+	}
+	switch out := req.Output.(type) {
+	case *livekit.TrackEgressRequest_File:
+		if out.File == nil {
+			return NewInvalidOutputError("missing file output")
+		}
+		if err := validateUpload(out.File); err != nil {
+			return err
+		}
+	case *livekit.TrackEgressRequest_WebsocketUrl:
+		u, err := url.Parse(out.WebsocketUrl)
+		if err != nil || u.Host == "" {
+			return NewInvalidOutputError("invalid websocket url")
+		}
+		if u.Scheme != "ws" && u.Scheme != "wss" {
+			return NewInvalidOutputError("websocket url must use ws or wss scheme")
+		}
+	default:
+		return NewInvalidOutputError("no output")
+	}
+	return validateWebhooks(req.Webhooks)
+}
+
+func validateHTTPURL(s string) error {
+	u, err := url.Parse(s)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("scheme must be http or https")
+	}
+	if u.Host == "" {
+		return fmt.Errorf("missing host")
+	}
+	return nil
+}
+
+func validateWebhooks(hooks []*livekit.WebhookConfig) error {
+	for _, h := range hooks {
+		if h == nil || h.Url == "" {
+			return ErrInvalidEgress("webhook without url")
+		}
+		if err := validateHTTPURL(h.Url); err != nil {
+			return ErrInvalidEgress(fmt.Sprintf("webhook url: %v", err))
+		}
+	}
+	return nil
+}
+
+func validateEncodedOutputs(req EncodedOutput, media mediaKinds) error {
+	files := req.GetFileOutputs()
+	streams := req.GetStreamOutputs()
+	segments := req.GetSegmentOutputs()
+	images := req.GetImageOutputs()
+	hasOutputs := len(files)+len(streams)+len(segments)+len(images) != 0
+
+	if d, ok := req.(EncodedOutputDeprecated); ok {
+		if f, s, seg := d.GetFile(), d.GetStream(), d.GetSegments(); f != nil || s != nil || seg != nil {
+			if hasOutputs {
+				return NewInvalidOutputError("deprecated output field cannot be used together with repeated outputs")
+			}
+			switch {
+			case f != nil:
+				files = []*livekit.EncodedFileOutput{f}
+			case s != nil:
+				streams = []*livekit.StreamOutput{s}
+			case seg != nil:
+				segments = []*livekit.SegmentedFileOutput{seg}
+			}
+			hasOutputs = true
+		}
+	}
+	if !hasOutputs {
+		return NewInvalidOutputError("no outputs")
+	}
+	if len(files) > 1 || len(streams) > 1 || len(segments) > 1 || len(images) > 1 {
+		return NewInvalidOutputError("multiple outputs of the same type are not supported")
+	}
+	var (
+		vcodec livekit.VideoCodec
+		acodec livekit.AudioCodec
+	)
+	if o, ok := req.(interface {
+		GetAdvanced() *livekit.EncodingOptions
+	}); ok {
+		vcodec = o.GetAdvanced().GetVideoCodec()
+		acodec = o.GetAdvanced().GetAudioCodec()
+	}
+	for _, f := range files {
+		if err := validateFileOutput(f, media, vcodec, acodec); err != nil {
+			return err
+		}
+	}
+	for _, s := range streams {
+		if err := validateStreamOutput(s, media, vcodec); err != nil {
+			return err
+		}
+	}
+	for _, s := range segments {
+		if err := validateSegmentedOutput(s, media, vcodec); err != nil {
+			return err
+		}
+	}
+	for _, img := range images {
+		if err := validateImageOutput(img, media); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateFileOutput(f *livekit.EncodedFileOutput, media mediaKinds, vcodec livekit.VideoCodec, acodec livekit.AudioCodec) error {
+	if f == nil {
+		return NewInvalidOutputError("missing file output")
+	}
+	switch f.FileType {
+	case livekit.EncodedFileType_DEFAULT_FILETYPE, livekit.EncodedFileType_MP4:
+		if media.video && vcodec == livekit.VideoCodec_VP8 {
+			return NewInvalidOutputError("VP8 is not supported in MP4 files")
+		}
+	case livekit.EncodedFileType_OGG:
+		if media.video && !media.videoUnknown {
+			return NewInvalidOutputError("OGG files can only contain audio")
+		}
+		if acodec == livekit.AudioCodec_AAC {
+			return NewInvalidOutputError("AAC is not supported in OGG files")
+		}
+	default:
+		return NewInvalidOutputError("unsupported file type")
+	}
+	return validateUpload(f)
+}
+
+func validateStreamOutput(s *livekit.StreamOutput, media mediaKinds, vcodec livekit.VideoCodec) error {
+	if s == nil {
+		return NewInvalidOutputError("missing stream output")
+	}
+	if len(s.Urls) == 0 {
+		return NewInvalidOutputError("no stream urls")
+	}
+	if media.video && vcodec == livekit.VideoCodec_VP8 {
+		return NewInvalidOutputError("VP8 is not supported for streaming")
+	}
+	proto := s.Protocol
+	for _, raw := range s.Urls {
+		u, err := url.Parse(raw)
+		if err != nil || u.Host == "" {
+			return NewInvalidOutputError("invalid stream url")
+		}
+		var p livekit.StreamProtocol
+		switch strings.ToLower(u.Scheme) {
+		case "rtmp", "rtmps":
+			p = livekit.StreamProtocol_RTMP
+		case "srt":
+			p = livekit.StreamProtocol_SRT
+		default:
+			return NewInvalidOutputError(fmt.Sprintf("unsupported stream url scheme %q", u.Scheme))
+		}
+		if proto == livekit.StreamProtocol_DEFAULT_PROTOCOL {
+			// Protocol chosen based on the first url, the rest must match.
+			proto = p
+		} else if proto != p {
+			return NewInvalidOutputError(fmt.Sprintf("stream url scheme %q does not match protocol %s", u.Scheme, proto))
+		}
+	}
+	return nil
+}
+
+func validateSegmentedOutput(s *livekit.SegmentedFileOutput, media mediaKinds, vcodec livekit.VideoCodec) error {
+	if s == nil {
+		return NewInvalidOutputError("missing segmented output")
+	}
+	switch s.Protocol {
+	case livekit.SegmentedFileProtocol_DEFAULT_SEGMENTED_FILE_PROTOCOL, livekit.SegmentedFileProtocol_HLS_PROTOCOL:
+	default:
+		return NewInvalidOutputError("unsupported segmented file protocol")
+	}
+	if media.video && vcodec == livekit.VideoCodec_VP8 {
+		return NewInvalidOutputError("VP8 is not supported for HLS")
+	}
+	if s.PlaylistName != "" && !strings.HasSuffix(s.PlaylistName, ".m3u8") {
+		return NewInvalidOutputError("playlist name must have .m3u8 extension")
+	}
+	if s.LivePlaylistName != "" {
+		if !strings.HasSuffix(s.LivePlaylistName, ".m3u8") {
+			return NewInvalidOutputError("live playlist name must have .m3u8 extension")
+		}
+		if s.LivePlaylistName == s.PlaylistName {
+			return NewInvalidOutputError("live playlist name must differ from playlist name")
+		}
+	}
+	return validateUpload(s)
+}
+
+func validateImageOutput(img *livekit.ImageOutput, media mediaKinds) error {
+	if img == nil {
+		return NewInvalidOutputError("missing image output")
+	}
+	if !media.video {
+		return NewInvalidOutputError("image output requires video")
+	}
+	if img.CaptureInterval == 0 {
+		return NewInvalidOutputError("no image capture interval")
+	}
+	if img.Width < 0 || img.Height < 0 {
+		return NewInvalidOutputError("negative image dimensions")
+	}
+	return validateUpload(img)
+}
+
+// validateUpload checks upload config, if present. Outputs without upload config use the storage configured on the egress service.
+func validateUpload(req UploadRequest) error {
+	if s3 := req.GetS3(); s3 != nil {
+		if s3.Bucket == "" {
+			return NewInvalidUploadError("s3: no bucket")
+		}
+		if (s3.AccessKey == "") != (s3.Secret == "") {
+			return NewInvalidUploadError("s3: access key and secret must be set together")
+		}
+		if s3.SessionToken != "" && s3.AccessKey == "" {
+			return NewInvalidUploadError("s3: session token requires access key and secret")
+		}
+		if s3.Endpoint != "" {
+			if err := validateHTTPURL(s3.Endpoint); err != nil {
+				return NewInvalidUploadError(fmt.Sprintf("s3: endpoint: %v", err))
+			}
+		}
+		return validateProxy("s3", s3.Proxy)
+	}
+	if gcp := req.GetGcp(); gcp != nil {
+		if gcp.Bucket == "" {
+			return NewInvalidUploadError("gcp: no bucket")
+		}
+		return validateProxy("gcp", gcp.Proxy)
+	}
+	if azure := req.GetAzure(); azure != nil {
+		if azure.AccountName == "" || azure.AccountKey == "" {
+			return NewInvalidUploadError("azure: account name and key are required")
+		}
+		if azure.ContainerName == "" {
+			return NewInvalidUploadError("azure: no container name")
+		}
+		return nil
+	}
+	if oss := req.GetAliOSS(); oss != nil {
+		if oss.AccessKey == "" || oss.Secret == "" {
+			return NewInvalidUploadError("aliOSS: access key and secret are required")
+		}
+		if oss.Bucket == "" {
+			return NewInvalidUploadError("aliOSS: no bucket")
+		}
+		return nil
+	}
+	return nil
+}
+
+func validateProxy(name string, p *livekit.ProxyConfig) error {
+	if p == nil {
+		return nil
+	}
+	if p.Url == "" {
+		return NewInvalidUploadError(name + ": proxy without url")
+	}
+	if err := validateHTTPURL(p.Url); err != nil {
+		return NewInvalidUploadError(fmt.Sprintf("%s: proxy url: %v", name, err))
+	}
+	if p.Password != "" && p.Username == "" {
+		return NewInvalidUploadError(name + ": proxy password without username")
+	}
+	return nil
+}
+
+// audioFrequencies lists sample rates supported by each audio codec. Both ValidateEncodingOptions and
+// ResolveEncoding use it, so a frequency accepted by validation never fails during resolution.
+var audioFrequencies = map[livekit.AudioCodec][]int32{
+	livekit.AudioCodec_OPUS: {8000, 12000, 16000, 24000, 48000},
+	livekit.AudioCodec_AAC:  {8000, 11025, 12000, 16000, 22050, 24000, 32000, 44100, 48000, 64000, 88200, 96000},
+}
+
+// supportsAudioFrequency reports whether the codec can use the frequency. The default codec accepts
+// frequencies supported by any codec, since the actual codec is only chosen during resolution.
+func supportsAudioFrequency(c livekit.AudioCodec, f int32) bool {
+	if c != livekit.AudioCodec_DEFAULT_AC {
+		return slices.Contains(audioFrequencies[c], f)
+	}
+	for _, freqs := range audioFrequencies {
+		if slices.Contains(freqs, f) {
+			return true
+		}
+	}
+	return false
+}
+
+func validateEncodingOptions(opts interface{}) error {
+	preset, advanced, err := encodingOptions(opts)
+	if err != nil {
+		return err
+	}
+	if advanced != nil {
+		return ValidateEncodingOptions(advanced)
+	}
+	return validatePreset(preset)
+}
+
+func validatePreset(p livekit.EncodingOptionsPreset) error {
+	if _, ok := livekit.EncodingOptionsPreset_name[int32(p)]; !ok {
+		return NewInvalidEncodingOptionsError("unknown preset")
+	}
+	return nil
+}
+
+// ValidateEncodingOptions checks that advanced encoding options are within supported ranges. Zero values mean defaults.
+func ValidateEncodingOptions(o *livekit.EncodingOptions) error {
+	if o == nil {
+		return nil
+	}
+	if (o.Width == 0) != (o.Height == 0) {
+		return NewInvalidEncodingOptionsError("width and height must be set together")
+	}
+	if o.Width < 0 || o.Height < 0 || o.Width > 3840 || o.Height > 3840 {
+		return NewInvalidEncodingOptionsError("dimensions must be up to 3840")
+	}
+	switch o.VideoCodec {
+	case livekit.VideoCodec_DEFAULT_VC, livekit.VideoCodec_H264_BASELINE, livekit.VideoCodec_H264_MAIN, livekit.VideoCodec_H264_HIGH:
+		if o.Width%2 != 0 || o.Height%2 != 0 {
+			return NewInvalidEncodingOptionsError("H.264 requires even dimensions")
+		}
+	case livekit.VideoCodec_VP8:
+	default:
+		return NewInvalidEncodingOptionsError("unsupported video codec")
+	}
+	switch o.Depth {
+	case 0, 8, 16, 24, 32:
+	default:
+		return NewInvalidEncodingOptionsError("depth must be 8, 16, 24 or 32")
+	}
+	if o.Framerate < 0 || o.Framerate > 60 {
+		return NewInvalidEncodingOptionsError("framerate must be from 0 (default) to 60")
+	}
+	if o.VideoBitrate < 0 {
+		return NewInvalidEncodingOptionsError("negative video bitrate")
+	}
+	if o.KeyFrameInterval < 0 {
+		return NewInvalidEncodingOptionsError("negative key frame interval")
+	}
+	if o.AudioBitrate < 0 || o.AudioBitrate > 512 {
+		return NewInvalidEncodingOptionsError("audio bitrate must be from 0 (default) to 512 kbps")
+	}
+	if _, ok := audioFrequencies[o.AudioCodec]; !ok && o.AudioCodec != livekit.AudioCodec_DEFAULT_AC {
+		return NewInvalidEncodingOptionsError("unsupported audio codec")
+	}
+	if o.AudioFrequency != 0 && !supportsAudioFrequency(o.AudioCodec, o.AudioFrequency) {
+		return NewInvalidEncodingOptionsError(fmt.Sprintf("audio frequency %d is not supported by %s", o.AudioFrequency, o.AudioCodec))
+	}
+	return nil
+}
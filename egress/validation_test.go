@@ -0,0 +1,255 @@
+package egress
+
+import (
+	"testing"
+
+	"github.com/livekit/psrpc"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestValidate(t *testing.T) {
+	mp4 := &livekit.EncodedFileOutput{Filepath: "out.mp4"}
+	cases := []struct {
+		name string
+		req  interface{}
+		err  bool
+	}{
+		{
+			name: "room composite",
+			req: &livekit.RoomCompositeEgressRequest{
+				RoomName:    "room",
+				FileOutputs: []*livekit.EncodedFileOutput{mp4},
+			},
+		},
+		{
+			name: "no room",
+			req:  &livekit.RoomCompositeEgressRequest{FileOutputs: []*livekit.EncodedFileOutput{mp4}},
+			err:  true,
+		},
+		{
+			name: "no outputs",
+			req:  &livekit.RoomCompositeEgressRequest{RoomName: "room"},
+			err:  true,
+		},
+		{
+			name: "deprecated and repeated outputs",
+			req: &livekit.RoomCompositeEgressRequest{
+				RoomName:    "room",
+				Output:      &livekit.RoomCompositeEgressRequest_File{File: mp4},
+				FileOutputs: []*livekit.EncodedFileOutput{mp4},
+			},
+			err: true,
+		},
+		{
+			name: "deprecated output",
+			req: &livekit.RoomCompositeEgressRequest{
+				RoomName: "room",
+				Output:   &livekit.RoomCompositeEgressRequest_File{File: mp4},
+			},
+		},
+		{
+			name: "audio and video only",
+			req: &livekit.RoomCompositeEgressRequest{
+				RoomName:    "room",
+				AudioOnly:   true,
+				VideoOnly:   true,
+				FileOutputs: []*livekit.EncodedFileOutput{mp4},
+			},
+			err: true,
+		},
+		{
+			// Audio mixing only applies to audio only egress and is ignored otherwise.
+			name: "audio mixing without audio only",
+			req: &livekit.RoomCompositeEgressRequest{
+				RoomName:    "room",
+				AudioMixing: livekit.AudioMixing_DUAL_CHANNEL_AGENT,
+				FileOutputs: []*livekit.EncodedFileOutput{mp4},
+			},
+		},
+		{
+			name: "ogg with video",
+			req: &livekit.RoomCompositeEgressRequest{
+				RoomName:    "room",
+				FileOutputs: []*livekit.EncodedFileOutput{{FileType: livekit.EncodedFileType_OGG}},
+			},
+			err: true,
+		},
+		{
+			name: "ogg audio only",
+			req: &livekit.RoomCompositeEgressRequest{
+				RoomName:    "room",
+				AudioOnly:   true,
+				FileOutputs: []*livekit.EncodedFileOutput{{FileType: livekit.EncodedFileType_OGG}},
+			},
+		},
+		{
+			name: "images audio only",
+			req: &livekit.RoomCompositeEgressRequest{
+				RoomName:     "room",
+				AudioOnly:    true,
+				ImageOutputs: []*livekit.ImageOutput{{CaptureInterval: 5}},
+			},
+			err: true,
+		},
+		{
+			name: "vp8 stream",
+			req: &livekit.RoomCompositeEgressRequest{
+				RoomName: "room",
+				Options: &livekit.RoomCompositeEgressRequest_Advanced{Advanced: &livekit.EncodingOptions{
+					VideoCodec: livekit.VideoCodec_VP8,
+				}},
+				StreamOutputs: []*livekit.StreamOutput{{Urls: []string{"rtmp://example.com/live"}}},
+			},
+			err: true,
+		},
+		{
+			name: "stream protocol mismatch",
+			req: &livekit.RoomCompositeEgressRequest{
+				RoomName: "room",
+				StreamOutputs: []*livekit.StreamOutput{{
+					Protocol: livekit.StreamProtocol_SRT,
+					Urls:     []string{"rtmp://example.com/live"},
+				}},
+			},
+			err: true,
+		},
+		{
+			name: "mixed stream urls",
+			req: &livekit.RoomCompositeEgressRequest{
+				RoomName: "room",
+				StreamOutputs: []*livekit.StreamOutput{{
+					Urls: []string{"rtmps://example.com/live", "srt://example.com:9000"},
+				}},
+			},
+			err: true,
+		},
+		{
+			name: "web",
+			req: &livekit.WebEgressRequest{
+				Url:           "https://example.com",
+				StreamOutputs: []*livekit.StreamOutput{{Urls: []string{"rtmp://example.com/live"}}},
+			},
+		},
+		{
+			name: "web bad url",
+			req: &livekit.WebEgressRequest{
+				Url:         "ftp://example.com",
+				FileOutputs: []*livekit.EncodedFileOutput{mp4},
+			},
+			err: true,
+		},
+		{
+			name: "participant",
+			req: &livekit.ParticipantEgressRequest{
+				RoomName:       "room",
+				Identity:       "user",
+				SegmentOutputs: []*livekit.SegmentedFileOutput{{PlaylistName: "index.m3u8"}},
+			},
+		},
+		{
+			name: "participant audio only file",
+			req: &livekit.ParticipantEgressRequest{
+				RoomName:    "room",
+				Identity:    "user",
+				FileOutputs: []*livekit.EncodedFileOutput{{FileType: livekit.EncodedFileType_OGG}},
+			},
+		},
+		{
+			name: "participant no identity",
+			req: &livekit.ParticipantEgressRequest{
+				RoomName:    "room",
+				FileOutputs: []*livekit.EncodedFileOutput{mp4},
+			},
+			err: true,
+		},
+		{
+			name: "track composite no tracks",
+			req: &livekit.TrackCompositeEgressRequest{
+				RoomName:    "room",
+				FileOutputs: []*livekit.EncodedFileOutput{mp4},
+			},
+			err: true,
+		},
+		{
+			name: "track composite audio to ogg",
+			req: &livekit.TrackCompositeEgressRequest{
+				RoomName:     "room",
+				AudioTrackId: "TR_audio",
+				FileOutputs:  []*livekit.EncodedFileOutput{{FileType: livekit.EncodedFileType_OGG}},
+			},
+		},
+		{
+			name: "track websocket",
+			req: &livekit.TrackEgressRequest{
+				RoomName: "room",
+				TrackId:  "TR_audio",
+				Output:   &livekit.TrackEgressRequest_WebsocketUrl{WebsocketUrl: "wss://example.com/ws"},
+			},
+		},
+		{
+			name: "track bad websocket",
+			req: &livekit.TrackEgressRequest{
+				RoomName: "room",
+				TrackId:  "TR_audio",
+				Output:   &livekit.TrackEgressRequest_WebsocketUrl{WebsocketUrl: "https://example.com/ws"},
+			},
+			err: true,
+		},
+		{
+			name: "s3 key without secret",
+			req: &livekit.RoomCompositeEgressRequest{
+				RoomName: "room",
+				FileOutputs: []*livekit.EncodedFileOutput{{
+					Output: &livekit.EncodedFileOutput_S3{S3: &livekit.S3Upload{Bucket: "b", AccessKey: "key"}},
+				}},
+			},
+			err: true,
+		},
+		{
+			name: "s3 proxy without url",
+			req: &livekit.RoomCompositeEgressRequest{
+				RoomName: "room",
+				FileOutputs: []*livekit.EncodedFileOutput{{
+					Output: &livekit.EncodedFileOutput_S3{S3: &livekit.S3Upload{Bucket: "b", Proxy: &livekit.ProxyConfig{}}},
+				}},
+			},
+			err: true,
+		},
+		{
+			name: "unsupported type",
+			req:  &livekit.StopEgressRequest{},
+			err:  true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := Validate(c.req)
+			if !c.err {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			var perr psrpc.Error
+			require.ErrorAs(t, err, &perr)
+			require.Equal(t, psrpc.InvalidArgument, perr.Code())
+		})
+	}
+}
+
+func TestValidateEncodingOptions(t *testing.T) {
+	require.NoError(t, ValidateEncodingOptions(nil))
+	require.NoError(t, ValidateEncodingOptions(&livekit.EncodingOptions{
+		Width: 1280, Height: 720, Framerate: 30, AudioCodec: livekit.AudioCodec_AAC, AudioFrequency: 44100,
+	}))
+	require.Error(t, ValidateEncodingOptions(&livekit.EncodingOptions{Width: 1280}))
+	require.Error(t, ValidateEncodingOptions(&livekit.EncodingOptions{Width: 1281, Height: 720}))
+	require.NoError(t, ValidateEncodingOptions(&livekit.EncodingOptions{Width: 1281, Height: 721, VideoCodec: livekit.VideoCodec_VP8}))
+	require.Error(t, ValidateEncodingOptions(&livekit.EncodingOptions{Framerate: 120}))
+	require.Error(t, ValidateEncodingOptions(&livekit.EncodingOptions{Depth: 12}))
+	require.NoError(t, ValidateEncodingOptions(&livekit.EncodingOptions{AudioFrequency: 44100}))
+	require.Error(t, ValidateEncodingOptions(&livekit.EncodingOptions{AudioCodec: livekit.AudioCodec_OPUS, AudioFrequency: 44100}))
+	require.Error(t, ValidateEncodingOptions(&livekit.EncodingOptions{AudioFrequency: 44000}))
+	require.Error(t, ValidateEncodingOptions(&livekit.EncodingOptions{AudioBitrate: -1}))
+}
@@ -0,0 +1,227 @@
+// Copyright 2025 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ingress
+
+import (
+	"math"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/utils"
+)
+
+const (
+	DefaultVideoPreset = livekit.IngressVideoEncodingPreset_H264_720P_30FPS_3_LAYERS
+	DefaultAudioPreset = livekit.IngressAudioEncodingPreset_OPUS_STEREO_96KBPS
+
+	// MinLayerSize is the minimal width or height of a simulcast layer. Smaller layers are dropped.
+	MinLayerSize = 16
+)
+
+type videoPreset struct {
+	// width and height of the top layer for landscape sources
+	width, height uint32
+	frameRate     float64
+	bitrate       uint32
+	layers        int
+}
+
+// Parameters from preset descriptions. Note that 1080p presets are 1920x1080, not 1980x1080 as the proto comment says.
+var videoPresets = map[livekit.IngressVideoEncodingPreset]videoPreset{
+	livekit.IngressVideoEncodingPreset_H264_720P_30FPS_3_LAYERS:              {1280, 720, 30, 1_900_000, 3},
+	livekit.IngressVideoEncodingPreset_H264_1080P_30FPS_3_LAYERS:             {1920, 1080, 30, 3_500_000, 3},
+	livekit.IngressVideoEncodingPreset_H264_540P_25FPS_2_LAYERS:              {960, 540, 25, 1_000_000, 2},
+	livekit.IngressVideoEncodingPreset_H264_720P_30FPS_1_LAYER:               {1280, 720, 30, 1_900_000, 1},
+	livekit.IngressVideoEncodingPreset_H264_1080P_30FPS_1_LAYER:              {1920, 1080, 30, 3_500_000, 1},
+	livekit.IngressVideoEncodingPreset_H264_720P_30FPS_3_LAYERS_HIGH_MOTION:  {1280, 720, 30, 2_500_000, 3},
+	livekit.IngressVideoEncodingPreset_H264_1080P_30FPS_3_LAYERS_HIGH_MOTION: {1920, 1080, 30, 4_500_000, 3},
+	livekit.IngressVideoEncodingPreset_H264_540P_25FPS_2_LAYERS_HIGH_MOTION:  {960, 540, 25, 1_300_000, 2},
+	livekit.IngressVideoEncodingPreset_H264_720P_30FPS_1_LAYER_HIGH_MOTION:   {1280, 720, 30, 2_500_000, 1},
+	livekit.IngressVideoEncodingPreset_H264_1080P_30FPS_1_LAYER_HIGH_MOTION:  {1920, 1080, 30, 4_500_000, 1},
+}
+
+var audioPresets = map[livekit.IngressAudioEncodingPreset]*livekit.IngressAudioEncodingOptions{
+	livekit.IngressAudioEncodingPreset_OPUS_STEREO_96KBPS: {AudioCodec: livekit.AudioCodec_OPUS, Channels: 2, Bitrate: 96_000},
+	livekit.IngressAudioEncodingPreset_OPUS_MONO_64KBS:    {AudioCodec: livekit.AudioCodec_OPUS, Channels: 1, Bitrate: 64_000},
+}
+
+// VideoPresetOptions expands a video preset into encoding options for a landscape source at least as large as the preset.
+func VideoPresetOptions(p livekit.IngressVideoEncodingPreset) (*livekit.IngressVideoEncodingOptions, error) {
+	return ResolveVideoEncodingOptions(&livekit.IngressVideoOptions{
+		EncodingOptions: &livekit.IngressVideoOptions_Preset{Preset: p},
+	}, nil)
+}
+
+// AudioPresetOptions expands an audio preset into encoding options.
+func AudioPresetOptions(p livekit.IngressAudioEncodingPreset) (*livekit.IngressAudioEncodingOptions, error) {
+	o, ok := audioPresets[p]
+	if !ok {
+		return nil, NewInvalidAudioParamsError("invalid preset")
+	}
+	return utils.CloneProto(o), nil
+}
+
+// ResolveVideoEncodingOptions returns effective encoding options for the given video options and source.
+//
+// For presets, the top layer is the preset resolution fitted into the source, keeping the source aspect ratio
+// and never upscaling. Portrait sources use the preset resolution rotated by 90 degrees. Lower layers are 1/2 and 1/4
+// of the top layer, and bitrates are scaled with the number of pixels. Explicit options without layers get
+// layers computed the same way from the source resolution.
+//
+// Source may be nil if it is not known yet, in which case the preset resolution is used.
+func ResolveVideoEncodingOptions(opts *livekit.IngressVideoOptions, source *livekit.InputVideoState) (*livekit.IngressVideoEncodingOptions, error) {
+	if err := ValidateVideoOptionsConsistency(opts); err != nil {
+		return nil, err
+	}
+	var srcW, srcH uint32
+	var srcFPS float64
+	if source != nil {
+		srcW, srcH, srcFPS = source.Width, source.Height, source.Framerate
+	}
+
+	switch o := opts.GetEncodingOptions().(type) {
+	case *livekit.IngressVideoOptions_Options:
+		out := utils.CloneProto(o.Options)
+		if out.VideoCodec == livekit.VideoCodec_DEFAULT_VC {
+			out.VideoCodec = livekit.VideoCodec_H264_BASELINE
+		}
+		if out.FrameRate == 0 {
+			out.FrameRate = limitFrameRate(videoPresets[DefaultVideoPreset].frameRate, srcFPS)
+		}
+		if len(out.Layers) == 0 {
+			if srcW == 0 || srcH == 0 {
+				return nil, NewInvalidVideoParamsError("no layers and unknown source resolution")
+			}
+			p := videoPresets[DefaultVideoPreset]
+			p.width, p.height = srcW, srcH
+			p.bitrate = scaleBitrate(p.bitrate, pixelRatio(srcW, srcH, videoPresets[DefaultVideoPreset].width, videoPresets[DefaultVideoPreset].height))
+			out.Layers = computeLayers(p, 0, 0)
+		}
+		return out, nil
+	default:
+		preset := DefaultVideoPreset
+		if o, ok := o.(*livekit.IngressVideoOptions_Preset); ok {
+			preset = o.Preset
+		}
+		p := videoPresets[preset]
+		return &livekit.IngressVideoEncodingOptions{
+			VideoCodec: livekit.VideoCodec_H264_BASELINE,
+			FrameRate:  limitFrameRate(p.frameRate, srcFPS),
+			Layers:     computeLayers(p, srcW, srcH),
+		}, nil
+	}
+}
+
+// ResolveAudioEncodingOptions returns effective encoding options for the given audio options and source.
+// Source channel count limits the number of channels. Source may be nil if it is not known yet.
+func ResolveAudioEncodingOptions(opts *livekit.IngressAudioOptions, source *livekit.InputAudioState) (*livekit.IngressAudioEncodingOptions, error) {
+	if err := ValidateAudioOptionsConsistency(opts); err != nil {
+		return nil, err
+	}
+	var out *livekit.IngressAudioEncodingOptions
+	switch o := opts.GetEncodingOptions().(type) {
+	case *livekit.IngressAudioOptions_Options:
+		out = utils.CloneProto(o.Options)
+		def := audioPresets[DefaultAudioPreset]
+		if out.AudioCodec == livekit.AudioCodec_DEFAULT_AC {
+			out.AudioCodec = def.AudioCodec
+		}
+		if out.Channels == 0 {
+			out.Channels = def.Channels
+		}
+		if out.Bitrate == 0 {
+			out.Bitrate = def.Bitrate
+		}
+	case *livekit.IngressAudioOptions_Preset:
+		out = utils.CloneProto(audioPresets[o.Preset])
+	default:
+		out = utils.CloneProto(audioPresets[DefaultAudioPreset])
+	}
+	if source != nil && source.Channels != 0 && source.Channels < out.Channels {
+		out.Channels = source.Channels
+	}
+	return out, nil
+}
+
+func limitFrameRate(fps, source float64) float64 {
+	if source > 0 && source < fps {
+		return source
+	}
+	return fps
+}
+
+func pixelRatio(w, h, refW, refH uint32) float64 {
+	return float64(w) * float64(h) / (float64(refW) * float64(refH))
+}
+
+// scaleBitrate scales bitrate sub-linearly with the number of pixels, since smaller frames compress worse.
+func scaleBitrate(bitrate uint32, ratio float64) uint32 {
+	return uint32(math.Round(float64(bitrate) * math.Pow(ratio, 0.75)))
+}
+
+func even(v float64) uint32 {
+	return uint32(math.Round(v/2)) * 2
+}
+
+// fitSize returns the size of the top layer: box fitted into the source without upscaling,
+// with box orientation matching the source.
+func fitSize(boxW, boxH, srcW, srcH uint32) (uint32, uint32) {
+	if srcW == 0 || srcH == 0 {
+		return boxW, boxH
+	}
+	if srcH > srcW {
+		boxW, boxH = boxH, boxW
+	}
+	scale := math.Min(float64(boxW)/float64(srcW), float64(boxH)/float64(srcH))
+	if scale > 1 {
+		scale = 1
+	}
+	return even(float64(srcW) * scale), even(float64(srcH) * scale)
+}
+
+var layerQualities = []livekit.VideoQuality{livekit.VideoQuality_HIGH, livekit.VideoQuality_MEDIUM, livekit.VideoQuality_LOW}
+
+// computeLayers returns simulcast layers for the preset and source, ordered from the lowest quality.
+func computeLayers(p videoPreset, srcW, srcH uint32) []*livekit.VideoLayer {
+	w, h := fitSize(p.width, p.height, srcW, srcH)
+	// Bitrate of the top layer is scaled relative to the preset size in the source orientation.
+	refW, refH := p.width, p.height
+	if h > w {
+		refW, refH = refH, refW
+	}
+	bitrate := p.bitrate
+	if ratio := pixelRatio(w, h, refW, refH); ratio < 1 {
+		bitrate = scaleBitrate(p.bitrate, ratio)
+	}
+
+	var layers []*livekit.VideoLayer
+	for i := 0; i < p.layers && i < len(layerQualities); i++ {
+		div := float64(uint32(1) << i)
+		lw, lh := even(float64(w)/div), even(float64(h)/div)
+		if i > 0 && (lw < MinLayerSize || lh < MinLayerSize) {
+			break
+		}
+		layers = append(layers, &livekit.VideoLayer{
+			Quality: layerQualities[i],
+			Width:   lw,
+			Height:  lh,
+			Bitrate: scaleBitrate(bitrate, 1/(div*div)),
+		})
+	}
+	// Qualities are assigned from the top, but layers are ordered from the lowest one.
+	for i, j := 0, len(layers)-1; i < j; i, j = i+1, j-1 {
+		layers[i], layers[j] = layers[j], layers[i]
+	}
+	return layers
+}
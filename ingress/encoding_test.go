@@ -0,0 +1,147 @@
+package ingress
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+type layerSize struct {
+	q    livekit.VideoQuality
+	w, h uint32
+}
+
+func layerSizes(layers []*livekit.VideoLayer) []layerSize {
+	var out []layerSize
+	for _, l := range layers {
+		out = append(out, layerSize{l.Quality, l.Width, l.Height})
+	}
+	return out
+}
+
+func TestVideoPresetOptions(t *testing.T) {
+	for p := range livekit.IngressVideoEncodingPreset_name {
+		preset := livekit.IngressVideoEncodingPreset(p)
+		opts, err := VideoPresetOptions(preset)
+		require.NoError(t, err)
+		require.NoError(t, ValidateVideoEncodingOptionsConsistency(opts), preset.String())
+		require.Equal(t, videoPresets[preset].layers, len(opts.Layers), preset.String())
+		top := opts.Layers[len(opts.Layers)-1]
+		require.Equal(t, livekit.VideoQuality_HIGH, top.Quality)
+		require.Equal(t, videoPresets[preset].bitrate, top.Bitrate)
+	}
+
+	opts, err := VideoPresetOptions(livekit.IngressVideoEncodingPreset_H264_720P_30FPS_3_LAYERS)
+	require.NoError(t, err)
+	require.Equal(t, []layerSize{
+		{livekit.VideoQuality_LOW, 320, 180},
+		{livekit.VideoQuality_MEDIUM, 640, 360},
+		{livekit.VideoQuality_HIGH, 1280, 720},
+	}, layerSizes(opts.Layers))
+	require.Less(t, opts.Layers[0].Bitrate, opts.Layers[1].Bitrate)
+	require.Less(t, opts.Layers[1].Bitrate, opts.Layers[2].Bitrate)
+}
+
+func TestResolveVideoEncodingOptions(t *testing.T) {
+	preset := func(p livekit.IngressVideoEncodingPreset) *livekit.IngressVideoOptions {
+		return &livekit.IngressVideoOptions{EncodingOptions: &livekit.IngressVideoOptions_Preset{Preset: p}}
+	}
+	for _, c := range []struct {
+		name   string
+		opts   *livekit.IngressVideoOptions
+		source *livekit.InputVideoState
+		exp    []layerSize
+		fps    float64
+	}{
+		{
+			name:   "portrait",
+			opts:   preset(livekit.IngressVideoEncodingPreset_H264_1080P_30FPS_1_LAYER),
+			source: &livekit.InputVideoState{Width: 1080, Height: 1920, Framerate: 60},
+			exp:    []layerSize{{livekit.VideoQuality_HIGH, 1080, 1920}},
+			fps:    30,
+		},
+		{
+			name:   "portrait downscaled",
+			opts:   preset(livekit.IngressVideoEncodingPreset_H264_540P_25FPS_2_LAYERS),
+			source: &livekit.InputVideoState{Width: 1080, Height: 1920, Framerate: 24},
+			exp:    []layerSize{{livekit.VideoQuality_MEDIUM, 270, 480}, {livekit.VideoQuality_HIGH, 540, 960}},
+			fps:    24,
+		},
+		{
+			name:   "ultra wide",
+			opts:   preset(livekit.IngressVideoEncodingPreset_H264_720P_30FPS_3_LAYERS),
+			source: &livekit.InputVideoState{Width: 2560, Height: 1080},
+			exp: []layerSize{
+				{livekit.VideoQuality_LOW, 320, 136},
+				{livekit.VideoQuality_MEDIUM, 640, 270},
+				{livekit.VideoQuality_HIGH, 1280, 540},
+			},
+			fps: 30,
+		},
+		{
+			name:   "odd aspect",
+			opts:   preset(livekit.IngressVideoEncodingPreset_H264_720P_30FPS_1_LAYER),
+			source: &livekit.InputVideoState{Width: 1001, Height: 1901},
+			exp:    []layerSize{{livekit.VideoQuality_HIGH, 674, 1280}},
+			fps:    30,
+		},
+		{
+			name:   "small source is not upscaled",
+			opts:   nil,
+			source: &livekit.InputVideoState{Width: 64, Height: 48},
+			// 16x12 layer is below MinLayerSize.
+			exp: []layerSize{{livekit.VideoQuality_MEDIUM, 32, 24}, {livekit.VideoQuality_HIGH, 64, 48}},
+			fps: 30,
+		},
+		{
+			name: "explicit without layers",
+			opts: &livekit.IngressVideoOptions{EncodingOptions: &livekit.IngressVideoOptions_Options{
+				Options: &livekit.IngressVideoEncodingOptions{FrameRate: 15},
+			}},
+			source: &livekit.InputVideoState{Width: 853, Height: 480},
+			exp: []layerSize{
+				{livekit.VideoQuality_LOW, 214, 120},
+				{livekit.VideoQuality_MEDIUM, 426, 240},
+				{livekit.VideoQuality_HIGH, 854, 480},
+			},
+			fps: 15,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			opts, err := ResolveVideoEncodingOptions(c.opts, c.source)
+			require.NoError(t, err)
+			require.Equal(t, c.exp, layerSizes(opts.Layers))
+			require.Equal(t, c.fps, opts.FrameRate)
+			require.NoError(t, ValidateVideoEncodingOptionsConsistency(opts))
+		})
+	}
+
+	_, err := ResolveVideoEncodingOptions(&livekit.IngressVideoOptions{EncodingOptions: &livekit.IngressVideoOptions_Options{
+		Options: &livekit.IngressVideoEncodingOptions{},
+	}}, nil)
+	require.Error(t, err)
+}
+
+func TestResolveAudioEncodingOptions(t *testing.T) {
+	opts, err := ResolveAudioEncodingOptions(nil, &livekit.InputAudioState{Channels: 1})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, opts.Channels)
+	require.EqualValues(t, 96000, opts.Bitrate)
+
+	opts, err = ResolveAudioEncodingOptions(&livekit.IngressAudioOptions{
+		EncodingOptions: &livekit.IngressAudioOptions_Preset{Preset: livekit.IngressAudioEncodingPreset_OPUS_MONO_64KBS},
+	}, &livekit.InputAudioState{Channels: 2})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, opts.Channels)
+	require.EqualValues(t, 64000, opts.Bitrate)
+
+	opts, err = ResolveAudioEncodingOptions(&livekit.IngressAudioOptions{
+		EncodingOptions: &livekit.IngressAudioOptions_Options{Options: &livekit.IngressAudioEncodingOptions{Bitrate: 128000}},
+	}, nil)
+	require.NoError(t, err)
+	require.Equal(t, livekit.AudioCodec_OPUS, opts.AudioCodec)
+	require.EqualValues(t, 2, opts.Channels)
+	require.EqualValues(t, 128000, opts.Bitrate)
+}
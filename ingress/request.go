@@ -0,0 +1,381 @@
+// Copyright 2025 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ingress
+
+import (
+	"net"
+	"net/netip"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/utils"
+)
+
+// URLSourceKind is a kind of media source used by URL_INPUT ingress.
+type URLSourceKind string
+
+const (
+	URLSourceHTTP URLSourceKind = "http"
+	URLSourceHLS  URLSourceKind = "hls"
+	URLSourceSRT  URLSourceKind = "srt"
+	URLSourceRTMP URLSourceKind = "rtmp"
+)
+
+// URLPolicy restricts sources URL_INPUT ingress can pull media from.
+type URLPolicy struct {
+	// Kinds of sources allowed. Empty means all kinds.
+	Kinds []URLSourceKind
+	// AllowedHosts restricts hosts sources can be pulled from. Entries starting with "*." match any subdomain.
+	// Empty means any host.
+	AllowedHosts []string
+	// DeniedHosts are never allowed, even if matched by AllowedHosts. Same syntax as AllowedHosts.
+	DeniedHosts []string
+	// AllowPrivate allows loopback, private and link-local IP addresses, as well as "localhost".
+	AllowPrivate bool
+}
+
+// DefaultURLPolicy allows all source kinds from any public host.
+var DefaultURLPolicy = &URLPolicy{}
+
+// URLSourceKindOf returns a kind of media source for a given URL. HTTP URLs with .m3u8 path are considered HLS.
+func URLSourceKindOf(u *url.URL) (URLSourceKind, bool) {
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		if strings.EqualFold(path.Ext(u.Path), ".m3u8") {
+			return URLSourceHLS, true
+		}
+		return URLSourceHTTP, true
+	case "srt":
+		return URLSourceSRT, true
+	case "rtmp", "rtmps":
+		return URLSourceRTMP, true
+	}
+	return "", false
+}
+
+func matchHost(patterns []string, host string) bool {
+	for _, p := range patterns {
+		p = strings.ToLower(p)
+		if suffix, ok := strings.CutPrefix(p, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+		} else if host == p {
+			return true
+		}
+	}
+	return false
+}
+
+// parseNumericIPv4 parses IPv4 addresses in the forms accepted by inet_aton, such as "2130706433",
+// "0x7f.1" or "0177.0.0.1", which many HTTP clients and resolvers still connect to.
+func parseNumericIPv4(host string) (netip.Addr, bool) {
+	parts := strings.Split(host, ".")
+	if len(parts) > 4 {
+		return netip.Addr{}, false
+	}
+	vals := make([]uint64, len(parts))
+	for i, part := range parts {
+		base := 10
+		switch {
+		case strings.HasPrefix(part, "0x") || strings.HasPrefix(part, "0X"):
+			part, base = part[2:], 16
+		case len(part) > 1 && part[0] == '0':
+			part, base = part[1:], 8
+		}
+		if part == "" {
+			return netip.Addr{}, false
+		}
+		v, err := strconv.ParseUint(part, base, 32)
+		if err != nil {
+			return netip.Addr{}, false
+		}
+		vals[i] = v
+	}
+	// All parts but the last one are single bytes, the last one fills the remaining bytes.
+	var ip uint64
+	for _, v := range vals[:len(vals)-1] {
+		if v > 0xff {
+			return netip.Addr{}, false
+		}
+		ip = ip<<8 | v
+	}
+	rest := 8 * (5 - len(vals))
+	last := vals[len(vals)-1]
+	if last >= 1<<rest {
+		return netip.Addr{}, false
+	}
+	ip = ip<<rest | last
+	return netip.AddrFrom4([4]byte{byte(ip >> 24), byte(ip >> 16), byte(ip >> 8), byte(ip)}), true
+}
+
+func isPrivateHost(host string) bool {
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified()
+}
+
+// ValidateURL checks that a source URL of URL_INPUT ingress is allowed by the policy.
+// Only host names and IP literals are checked, names are not resolved.
+func (p *URLPolicy) ValidateURL(raw string) error {
+	if raw == "" {
+		return ErrInvalidIngress("no source URL")
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ErrInvalidIngress("malformed source URL")
+	}
+	kind, ok := URLSourceKindOf(u)
+	if !ok {
+		return ErrInvalidIngress("unsupported source URL scheme " + u.Scheme)
+	}
+	if p == nil {
+		p = DefaultURLPolicy
+	}
+	if len(p.Kinds) != 0 {
+		allowed := false
+		for _, k := range p.Kinds {
+			allowed = allowed || k == kind
+		}
+		if !allowed {
+			return ErrInvalidIngress("source kind " + string(kind) + " is not allowed")
+		}
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "" {
+		return ErrInvalidIngress("no host in source URL")
+	}
+	if ip, ok := parseNumericIPv4(host); ok && ip.String() != host {
+		return ErrInvalidIngress("source host must be a name or a dotted-decimal IP address")
+	}
+	if !p.AllowPrivate && isPrivateHost(host) {
+		return ErrInvalidIngress("source host is not allowed")
+	}
+	if matchHost(p.DeniedHosts, host) {
+		return ErrInvalidIngress("source host is not allowed")
+	}
+	if len(p.AllowedHosts) != 0 && !matchHost(p.AllowedHosts, host) {
+		return ErrInvalidIngress("source host is not allowed")
+	}
+	return nil
+}
+
+type requestOpts struct {
+	urlPolicy *URLPolicy
+}
+
+type RequestOption func(o *requestOpts)
+
+// WithURLPolicy sets a policy for sources of URL_INPUT ingress. DefaultURLPolicy is used otherwise.
+func WithURLPolicy(p *URLPolicy) RequestOption {
+	return func(o *requestOpts) {
+		o.urlPolicy = p
+	}
+}
+
+func newRequestOpts(opts []RequestOption) *requestOpts {
+	o := &requestOpts{urlPolicy: DefaultURLPolicy}
+	for _, fnc := range opts {
+		fnc(o)
+	}
+	return o
+}
+
+// NewIngressInfo converts a create request into ingress info. Fields generated by the server,
+// like ingress ID, stream key or push URL, are left empty.
+func NewIngressInfo(req *livekit.CreateIngressRequest) *livekit.IngressInfo {
+	info := &livekit.IngressInfo{
+		Name:                req.Name,
+		InputType:           req.InputType,
+		BypassTranscoding:   req.BypassTranscoding,
+		EnableTranscoding:   cloneBool(req.EnableTranscoding),
+		Audio:               utils.CloneProto(req.Audio),
+		Video:               utils.CloneProto(req.Video),
+		RoomName:            req.RoomName,
+		ParticipantIdentity: req.ParticipantIdentity,
+		ParticipantName:     req.ParticipantName,
+		ParticipantMetadata: req.ParticipantMetadata,
+		Enabled:             cloneBool(req.Enabled),
+		State:               &livekit.IngressState{},
+	}
+	if req.InputType == livekit.IngressInput_URL_INPUT {
+		info.Url = req.Url
+	} else {
+		info.Reusable = true
+	}
+	return info
+}
+
+// ValidateCreateRequest checks a create request, including the source URL of URL_INPUT ingress.
+func ValidateCreateRequest(req *livekit.CreateIngressRequest, opts ...RequestOption) error {
+	if req == nil {
+		return ErrInvalidIngress("missing CreateIngressRequest")
+	}
+	o := newRequestOpts(opts)
+	switch req.InputType {
+	case livekit.IngressInput_URL_INPUT:
+		if err := o.urlPolicy.ValidateURL(req.Url); err != nil {
+			return err
+		}
+	case livekit.IngressInput_RTMP_INPUT, livekit.IngressInput_WHIP_INPUT:
+		if req.Url != "" {
+			return ErrInvalidIngress("source URL is only supported for URL input")
+		}
+	default:
+		return ErrInvalidIngress("unsupported input type")
+	}
+	info := NewIngressInfo(req)
+	if info.InputType != livekit.IngressInput_URL_INPUT {
+		// Generated by the server on creation.
+		info.StreamKey = "placeholder"
+	}
+	return Validate(info)
+}
+
+// ApplyUpdate returns ingress info the update request would result in. The original info is not modified.
+//
+// Empty fields of the request leave corresponding fields unchanged. Audio and video options are merged:
+// name and source are replaced when set, a preset replaces any encoding options, and explicit encoding options
+// are merged field by field with the current explicit options, if any.
+// The URL of URL ingress cannot be updated, so it is not checked against the URL policy again.
+func ApplyUpdate(info *livekit.IngressInfo, req *livekit.UpdateIngressRequest) (*livekit.IngressInfo, error) {
+	if info == nil {
+		return nil, ErrInvalidIngress("missing IngressInfo")
+	}
+	if req == nil {
+		return nil, ErrInvalidIngress("missing UpdateIngressRequest")
+	}
+	if req.IngressId != "" && req.IngressId != info.IngressId {
+		return nil, ErrInvalidIngress("ingress ID mismatch")
+	}
+	out := utils.CloneProto(info)
+	setString(&out.Name, req.Name)
+	setString(&out.RoomName, req.RoomName)
+	setString(&out.ParticipantIdentity, req.ParticipantIdentity)
+	setString(&out.ParticipantName, req.ParticipantName)
+	setString(&out.ParticipantMetadata, req.ParticipantMetadata)
+	if req.BypassTranscoding != nil {
+		out.BypassTranscoding = *req.BypassTranscoding
+	}
+	if req.EnableTranscoding != nil {
+		out.EnableTranscoding = cloneBool(req.EnableTranscoding)
+	}
+	if req.Enabled != nil {
+		out.Enabled = cloneBool(req.Enabled)
+	}
+	out.Audio = mergeAudioOptions(out.Audio, req.Audio)
+	out.Video = mergeVideoOptions(out.Video, req.Video)
+
+	if err := ValidateForSerialization(out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ValidateUpdateRequest checks that the update request results in valid ingress info.
+func ValidateUpdateRequest(info *livekit.IngressInfo, req *livekit.UpdateIngressRequest) error {
+	_, err := ApplyUpdate(info, req)
+	return err
+}
+
+func cloneBool(b *bool) *bool {
+	if b == nil {
+		return nil
+	}
+	v := *b
+	return &v
+}
+
+func setString(dst *string, v string) {
+	if v != "" {
+		*dst = v
+	}
+}
+
+func mergeAudioOptions(cur, upd *livekit.IngressAudioOptions) *livekit.IngressAudioOptions {
+	if upd == nil {
+		return cur
+	}
+	if cur == nil {
+		return utils.CloneProto(upd)
+	}
+	setString(&cur.Name, upd.Name)
+	if upd.Source != livekit.TrackSource_UNKNOWN {
+		cur.Source = upd.Source
+	}
+	switch e := upd.EncodingOptions.(type) {
+	case *livekit.IngressAudioOptions_Preset:
+		cur.EncodingOptions = &livekit.IngressAudioOptions_Preset{Preset: e.Preset}
+	case *livekit.IngressAudioOptions_Options:
+		prev := cur.GetOptions()
+		if prev == nil || e.Options == nil {
+			cur.EncodingOptions = &livekit.IngressAudioOptions_Options{Options: utils.CloneProto(e.Options)}
+			break
+		}
+		if e.Options.AudioCodec != livekit.AudioCodec_DEFAULT_AC {
+			prev.AudioCodec = e.Options.AudioCodec
+		}
+		if e.Options.Bitrate != 0 {
+			prev.Bitrate = e.Options.Bitrate
+		}
+		if e.Options.Channels != 0 {
+			prev.Channels = e.Options.Channels
+		}
+		// Cannot tell unset from false, so the update always wins.
+		prev.DisableDtx = e.Options.DisableDtx
+	}
+	return cur
+}
+
+func mergeVideoOptions(cur, upd *livekit.IngressVideoOptions) *livekit.IngressVideoOptions {
+	if upd == nil {
+		return cur
+	}
+	if cur == nil {
+		return utils.CloneProto(upd)
+	}
+	setString(&cur.Name, upd.Name)
+	if upd.Source != livekit.TrackSource_UNKNOWN {
+		cur.Source = upd.Source
+	}
+	switch e := upd.EncodingOptions.(type) {
+	case *livekit.IngressVideoOptions_Preset:
+		cur.EncodingOptions = &livekit.IngressVideoOptions_Preset{Preset: e.Preset}
+	case *livekit.IngressVideoOptions_Options:
+		prev := cur.GetOptions()
+		if prev == nil || e.Options == nil {
+			cur.EncodingOptions = &livekit.IngressVideoOptions_Options{Options: utils.CloneProto(e.Options)}
+			break
+		}
+		if e.Options.VideoCodec != livekit.VideoCodec_DEFAULT_VC {
+			prev.VideoCodec = e.Options.VideoCodec
+		}
+		if e.Options.FrameRate != 0 {
+			prev.FrameRate = e.Options.FrameRate
+		}
+		if len(e.Options.Layers) != 0 {
+			prev.Layers = utils.CloneProtoSlice(e.Options.Layers)
+		}
+	}
+	return cur
+}
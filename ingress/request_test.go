@@ -0,0 +1,160 @@
+package ingress
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestURLPolicy(t *testing.T) {
+	for _, c := range []struct {
+		url    string
+		policy *URLPolicy
+		ok     bool
+	}{
+		{url: "https://example.com/video.mp4", ok: true},
+		{url: "http://example.com/live/index.M3U8", ok: true},
+		{url: "srt://example.com:9000?streamid=abc", ok: true},
+		{url: "rtmps://example.com/live/key", ok: true},
+		{url: "", ok: false},
+		{url: "ftp://example.com/video.mp4", ok: false},
+		{url: "file:///etc/passwd", ok: false},
+		{url: "http://localhost:8080/video.mp4", ok: false},
+		{url: "http://127.0.0.1/video.mp4", ok: false},
+		{url: "http://[::1]/video.mp4", ok: false},
+		{url: "http://[::ffff:127.0.0.1]/video.mp4", ok: false},
+		{url: "http://2130706433/video.mp4", ok: false},
+		{url: "http://0x7f.1/video.mp4", ok: false},
+		{url: "http://0177.0.0.1/video.mp4", ok: false},
+		{url: "http://127.1/video.mp4", ok: false},
+		{url: "http://0/video.mp4", ok: false},
+		{url: "http://134744072/video.mp4", ok: false},
+		{url: "http://8.8.8.8/video.mp4", ok: true},
+		{url: "http://1e100.net/video.mp4", ok: true},
+		{url: "http://10.1.2.3/video.mp4", ok: false},
+		{url: "http://10.1.2.3/video.mp4", policy: &URLPolicy{AllowPrivate: true}, ok: true},
+		{url: "https://cdn.example.com/index.m3u8", policy: &URLPolicy{Kinds: []URLSourceKind{URLSourceHLS}}, ok: true},
+		{url: "https://cdn.example.com/video.mp4", policy: &URLPolicy{Kinds: []URLSourceKind{URLSourceHLS}}, ok: false},
+		{url: "https://cdn.example.com/video.mp4", policy: &URLPolicy{AllowedHosts: []string{"*.example.com"}}, ok: true},
+		{url: "https://example.com/video.mp4", policy: &URLPolicy{AllowedHosts: []string{"*.example.com"}}, ok: false},
+		{url: "https://bad.example.com/video.mp4", policy: &URLPolicy{AllowedHosts: []string{"*.example.com"}, DeniedHosts: []string{"bad.example.com"}}, ok: false},
+	} {
+		t.Run(c.url, func(t *testing.T) {
+			err := c.policy.ValidateURL(c.url)
+			if c.ok {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateCreateRequest(t *testing.T) {
+	req := &livekit.CreateIngressRequest{
+		InputType:           livekit.IngressInput_RTMP_INPUT,
+		RoomName:            "room",
+		ParticipantIdentity: "user",
+	}
+	require.NoError(t, ValidateCreateRequest(req))
+
+	req.Url = "https://example.com/video.mp4"
+	require.Error(t, ValidateCreateRequest(req))
+
+	req.InputType = livekit.IngressInput_URL_INPUT
+	require.NoError(t, ValidateCreateRequest(req))
+	require.Error(t, ValidateCreateRequest(req, WithURLPolicy(&URLPolicy{Kinds: []URLSourceKind{URLSourceSRT}})))
+
+	info := NewIngressInfo(req)
+	require.Equal(t, req.Url, info.Url)
+	require.False(t, info.Reusable)
+
+	req.Url = "http://192.168.0.1/video.mp4"
+	require.Error(t, ValidateCreateRequest(req))
+
+	f := false
+	req = &livekit.CreateIngressRequest{
+		InputType:           livekit.IngressInput_RTMP_INPUT,
+		RoomName:            "room",
+		ParticipantIdentity: "user",
+		EnableTranscoding:   &f,
+	}
+	require.Error(t, ValidateCreateRequest(req))
+}
+
+func TestApplyUpdate(t *testing.T) {
+	info := &livekit.IngressInfo{
+		IngressId:           "IN_1",
+		StreamKey:           "key",
+		InputType:           livekit.IngressInput_RTMP_INPUT,
+		RoomName:            "room",
+		ParticipantIdentity: "user",
+		Video: &livekit.IngressVideoOptions{
+			Name:   "video",
+			Source: livekit.TrackSource_CAMERA,
+			EncodingOptions: &livekit.IngressVideoOptions_Options{Options: &livekit.IngressVideoEncodingOptions{
+				VideoCodec: livekit.VideoCodec_H264_BASELINE,
+				FrameRate:  30,
+				Layers:     []*livekit.VideoLayer{{Quality: livekit.VideoQuality_HIGH, Width: 1280, Height: 720}},
+			}},
+		},
+		Audio: &livekit.IngressAudioOptions{
+			EncodingOptions: &livekit.IngressAudioOptions_Options{Options: &livekit.IngressAudioEncodingOptions{
+				Bitrate:  64000,
+				Channels: 1,
+			}},
+		},
+	}
+	orig := proto.Clone(info)
+
+	out, err := ApplyUpdate(info, &livekit.UpdateIngressRequest{
+		IngressId:       "IN_1",
+		ParticipantName: "User",
+		Video: &livekit.IngressVideoOptions{
+			EncodingOptions: &livekit.IngressVideoOptions_Options{Options: &livekit.IngressVideoEncodingOptions{
+				FrameRate: 25,
+			}},
+		},
+		Audio: &livekit.IngressAudioOptions{
+			EncodingOptions: &livekit.IngressAudioOptions_Options{Options: &livekit.IngressAudioEncodingOptions{
+				Channels: 2,
+			}},
+		},
+	})
+	require.NoError(t, err)
+	require.True(t, proto.Equal(orig, info), "original must not change")
+
+	require.Equal(t, "room", out.RoomName)
+	require.Equal(t, "User", out.ParticipantName)
+	require.Equal(t, "video", out.Video.Name)
+	require.Equal(t, livekit.TrackSource_CAMERA, out.Video.Source)
+	vo := out.Video.GetOptions()
+	require.Equal(t, livekit.VideoCodec_H264_BASELINE, vo.VideoCodec)
+	require.EqualValues(t, 25, vo.FrameRate)
+	require.Len(t, vo.Layers, 1)
+	ao := out.Audio.GetOptions()
+	require.EqualValues(t, 64000, ao.Bitrate)
+	require.EqualValues(t, 2, ao.Channels)
+
+	out, err = ApplyUpdate(info, &livekit.UpdateIngressRequest{
+		Video: &livekit.IngressVideoOptions{
+			EncodingOptions: &livekit.IngressVideoOptions_Preset{Preset: livekit.IngressVideoEncodingPreset_H264_540P_25FPS_2_LAYERS},
+		},
+	})
+	require.NoError(t, err)
+	require.Nil(t, out.Video.GetOptions())
+	require.Equal(t, livekit.IngressVideoEncodingPreset_H264_540P_25FPS_2_LAYERS, out.Video.GetPreset())
+
+	_, err = ApplyUpdate(info, &livekit.UpdateIngressRequest{IngressId: "IN_2"})
+	require.Error(t, err)
+
+	_, err = ApplyUpdate(info, &livekit.UpdateIngressRequest{
+		Audio: &livekit.IngressAudioOptions{
+			EncodingOptions: &livekit.IngressAudioOptions_Options{Options: &livekit.IngressAudioEncodingOptions{Channels: 6}},
+		},
+	})
+	require.Error(t, err)
+}
@@ -0,0 +1,371 @@
+// Copyright 2025 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ingress
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/livekit/psrpc"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/rpc"
+	"github.com/livekit/protocol/utils"
+)
+
+// stateTransitions lists allowed ingress status changes. Staying in the same status is always allowed.
+// Reusable ingress goes back to ENDPOINT_INACTIVE after each session, while ENDPOINT_COMPLETE is final.
+var stateTransitions = map[livekit.IngressState_Status][]livekit.IngressState_Status{
+	livekit.IngressState_ENDPOINT_INACTIVE: {
+		livekit.IngressState_ENDPOINT_BUFFERING,
+		livekit.IngressState_ENDPOINT_PUBLISHING,
+		livekit.IngressState_ENDPOINT_ERROR,
+		livekit.IngressState_ENDPOINT_COMPLETE,
+	},
+	livekit.IngressState_ENDPOINT_BUFFERING: {
+		livekit.IngressState_ENDPOINT_INACTIVE,
+		livekit.IngressState_ENDPOINT_PUBLISHING,
+		livekit.IngressState_ENDPOINT_ERROR,
+		livekit.IngressState_ENDPOINT_COMPLETE,
+	},
+	livekit.IngressState_ENDPOINT_PUBLISHING: {
+		livekit.IngressState_ENDPOINT_INACTIVE,
+		livekit.IngressState_ENDPOINT_BUFFERING,
+		livekit.IngressState_ENDPOINT_ERROR,
+		livekit.IngressState_ENDPOINT_COMPLETE,
+	},
+	livekit.IngressState_ENDPOINT_ERROR: {
+		livekit.IngressState_ENDPOINT_INACTIVE,
+		livekit.IngressState_ENDPOINT_BUFFERING,
+	},
+}
+
+// CanTransition checks if ingress status can change from one value to another.
+func CanTransition(from, to livekit.IngressState_Status) bool {
+	if from == to {
+		return true
+	}
+	return slices.Contains(stateTransitions[from], to)
+}
+
+func ErrInvalidStateTransition(from, to livekit.IngressState_Status) psrpc.Error {
+	return psrpc.NewErrorf(psrpc.FailedPrecondition, "invalid ingress state transition from %s to %s", from, to)
+}
+
+// MergeState applies a partial state update. Fields are only taken from the update if set.
+// Since ENDPOINT_INACTIVE is the zero status, it only changes the status together with EndedAt,
+// which marks the end of a session. Error is cleared when leaving ENDPOINT_ERROR.
+// Updates older than the current state are rejected with ErrIngressOutOfDate.
+func MergeState(cur, upd *livekit.IngressState) (*livekit.IngressState, error) {
+	if upd == nil {
+		return utils.CloneProto(cur), nil
+	}
+	if cur == nil {
+		cur = &livekit.IngressState{}
+	}
+	if upd.UpdatedAt != 0 && upd.UpdatedAt < cur.UpdatedAt {
+		return nil, ErrIngressOutOfDate
+	}
+	status := upd.Status
+	if status == livekit.IngressState_ENDPOINT_INACTIVE && upd.EndedAt == 0 {
+		status = cur.Status
+	}
+	if !CanTransition(cur.Status, status) {
+		return nil, ErrInvalidStateTransition(cur.Status, status)
+	}
+	out := utils.CloneProto(cur)
+	out.Status = status
+	if upd.Error != "" {
+		out.Error = upd.Error
+	} else if status != livekit.IngressState_ENDPOINT_ERROR {
+		out.Error = ""
+	}
+	if upd.Video != nil {
+		out.Video = mergeInputVideoState(out.Video, upd.Video)
+	}
+	if upd.Audio != nil {
+		out.Audio = mergeInputAudioState(out.Audio, upd.Audio)
+	}
+	setString(&out.RoomId, upd.RoomId)
+	setString(&out.ResourceId, upd.ResourceId)
+	if upd.StartedAt != 0 {
+		out.StartedAt = upd.StartedAt
+	}
+	if upd.EndedAt != 0 {
+		out.EndedAt = upd.EndedAt
+	}
+	if upd.UpdatedAt != 0 {
+		out.UpdatedAt = upd.UpdatedAt
+	}
+	if upd.Tracks != nil {
+		out.Tracks = utils.CloneProtoSlice(upd.Tracks)
+	}
+	return out, nil
+}
+
+func mergeInputVideoState(cur, upd *livekit.InputVideoState) *livekit.InputVideoState {
+	if cur == nil {
+		return utils.CloneProto(upd)
+	}
+	setString(&cur.MimeType, upd.MimeType)
+	if upd.AverageBitrate != 0 {
+		cur.AverageBitrate = upd.AverageBitrate
+	}
+	if upd.Width != 0 && upd.Height != 0 {
+		cur.Width, cur.Height = upd.Width, upd.Height
+	}
+	if upd.Framerate != 0 {
+		cur.Framerate = upd.Framerate
+	}
+	return cur
+}
+
+func mergeInputAudioState(cur, upd *livekit.InputAudioState) *livekit.InputAudioState {
+	if cur == nil {
+		return utils.CloneProto(upd)
+	}
+	setString(&cur.MimeType, upd.MimeType)
+	if upd.AverageBitrate != 0 {
+		cur.AverageBitrate = upd.AverageBitrate
+	}
+	if upd.Channels != 0 {
+		cur.Channels = upd.Channels
+	}
+	if upd.SampleRate != 0 {
+		cur.SampleRate = upd.SampleRate
+	}
+	return cur
+}
+
+// HealthIssue is a problem with the ingress input detected by StateTracker.
+type HealthIssue string
+
+const (
+	// HealthStalled means no state updates were received while publishing for longer than HealthConfig.StallTimeout.
+	HealthStalled HealthIssue = "stalled"
+	// HealthVideoBitrateDrop means input video bitrate fell below HealthConfig.BitrateDropRatio of its recent average.
+	HealthVideoBitrateDrop HealthIssue = "video_bitrate_drop"
+	// HealthAudioBitrateDrop means input audio bitrate fell below HealthConfig.BitrateDropRatio of its recent average.
+	HealthAudioBitrateDrop HealthIssue = "audio_bitrate_drop"
+	// HealthResolutionChange means input video resolution changed during the session.
+	HealthResolutionChange HealthIssue = "resolution_change"
+)
+
+// Health summarizes ingress input health.
+type Health struct {
+	Status livekit.IngressState_Status
+	Issues []HealthIssue
+}
+
+// Healthy returns true if no issues were detected.
+func (h Health) Healthy() bool {
+	return len(h.Issues) == 0
+}
+
+// Has checks if a given issue was detected.
+func (h Health) Has(issue HealthIssue) bool {
+	return slices.Contains(h.Issues, issue)
+}
+
+func (h Health) String() string {
+	if h.Healthy() {
+		return fmt.Sprintf("%s: healthy", h.Status)
+	}
+	return fmt.Sprintf("%s: %v", h.Status, h.Issues)
+}
+
+type HealthConfig struct {
+	// StallTimeout is the maximal interval between state updates while publishing.
+	StallTimeout time.Duration `yaml:"stall_timeout,omitempty"`
+	// BitrateDropRatio of the recent average bitrate, below which a drop is reported.
+	BitrateDropRatio float64 `yaml:"bitrate_drop_ratio,omitempty"`
+	// BitrateSmoothing is a weight of the new sample in the exponential moving average of the bitrate.
+	BitrateSmoothing float64 `yaml:"bitrate_smoothing,omitempty"`
+}
+
+var DefaultHealthConfig = HealthConfig{
+	StallTimeout:     30 * time.Second,
+	BitrateDropRatio: 0.5,
+	BitrateSmoothing: 0.2,
+}
+
+// HealthHandler is called when the set of detected health issues changes after a state update.
+type HealthHandler func(ingressID string, state *livekit.IngressState, h Health)
+
+// StateTracker keeps the current state of an ingress, enforcing status transitions, and tracks input health.
+// It is safe for concurrent use.
+type StateTracker struct {
+	ingressID string
+	conf      HealthConfig
+	clock     utils.Clock
+	onHealth  HealthHandler
+
+	mu           sync.Mutex
+	state        *livekit.IngressState
+	lastUpdate   time.Time
+	videoBitrate float64
+	audioBitrate float64
+	width        uint32 // video resolution seen in the current session
+	height       uint32
+	issues       []HealthIssue // issues detected on the last update
+	reported     []HealthIssue
+}
+
+type StateTrackerOption func(t *StateTracker)
+
+// WithHealthConfig overrides DefaultHealthConfig. Zero fields keep default values.
+func WithHealthConfig(conf HealthConfig) StateTrackerOption {
+	return func(t *StateTracker) {
+		if conf.StallTimeout != 0 {
+			t.conf.StallTimeout = conf.StallTimeout
+		}
+		if conf.BitrateDropRatio != 0 {
+			t.conf.BitrateDropRatio = conf.BitrateDropRatio
+		}
+		if conf.BitrateSmoothing != 0 {
+			t.conf.BitrateSmoothing = conf.BitrateSmoothing
+		}
+	}
+}
+
+// WithStateClock sets a clock used for stall detection.
+func WithStateClock(c utils.Clock) StateTrackerOption {
+	return func(t *StateTracker) {
+		t.clock = c
+	}
+}
+
+// WithHealthHandler sets a handler called when health issues change, for alerts or webhooks.
+func WithHealthHandler(h HealthHandler) StateTrackerOption {
+	return func(t *StateTracker) {
+		t.onHealth = h
+	}
+}
+
+// NewStateTracker creates a tracker for an ingress with a given initial state, which may be nil.
+func NewStateTracker(ingressID string, state *livekit.IngressState, opts ...StateTrackerOption) *StateTracker {
+	if state == nil {
+		state = &livekit.IngressState{}
+	}
+	t := &StateTracker{
+		ingressID: ingressID,
+		conf:      DefaultHealthConfig,
+		clock:     utils.SystemClock{},
+		state:     utils.CloneProto(state),
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	t.lastUpdate = t.clock.Now()
+	return t
+}
+
+// State returns a copy of the current state.
+func (t *StateTracker) State() *livekit.IngressState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return utils.CloneProto(t.state)
+}
+
+// Update merges a partial state update received from the ingress service.
+func (t *StateTracker) Update(req *rpc.UpdateIngressStateRequest) error {
+	if req.IngressId != t.ingressID {
+		return ErrInvalidIngress("ingress ID mismatch")
+	}
+	return t.UpdateState(req.State)
+}
+
+// UpdateState merges a partial state update. See MergeState.
+func (t *StateTracker) UpdateState(upd *livekit.IngressState) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	next, err := MergeState(t.state, upd)
+	if err != nil {
+		return err
+	}
+	prev := t.state
+	t.state = next
+	t.lastUpdate = t.clock.Now()
+
+	newSession := (next.Status == livekit.IngressState_ENDPOINT_BUFFERING && prev.Status != next.Status) ||
+		next.StartedAt != prev.StartedAt
+	if newSession {
+		t.videoBitrate, t.audioBitrate = 0, 0
+		t.width, t.height = 0, 0
+	}
+
+	var issues []HealthIssue
+	if next.Status == livekit.IngressState_ENDPOINT_PUBLISHING {
+		if t.bitrateDropped(&t.videoBitrate, next.Video.GetAverageBitrate(), upd.GetVideo().GetAverageBitrate()) {
+			issues = append(issues, HealthVideoBitrateDrop)
+		}
+		if t.bitrateDropped(&t.audioBitrate, next.Audio.GetAverageBitrate(), upd.GetAudio().GetAverageBitrate()) {
+			issues = append(issues, HealthAudioBitrateDrop)
+		}
+		if w, h := next.Video.GetWidth(), next.Video.GetHeight(); w != 0 && h != 0 {
+			if t.width != 0 && (t.width != w || t.height != h) {
+				issues = append(issues, HealthResolutionChange)
+			}
+			t.width, t.height = w, h
+		}
+	}
+	t.issues = issues
+	t.notify()
+	return nil
+}
+
+// bitrateDropped compares a new bitrate sample with the moving average and updates the average.
+func (t *StateTracker) bitrateDropped(avg *float64, cur, sample uint32) bool {
+	if sample == 0 {
+		// Not reported in this update.
+		return false
+	}
+	dropped := *avg > 0 && float64(cur) < *avg*t.conf.BitrateDropRatio
+	if *avg == 0 {
+		*avg = float64(cur)
+	} else {
+		*avg += t.conf.BitrateSmoothing * (float64(cur) - *avg)
+	}
+	return dropped
+}
+
+func (t *StateTracker) health() Health {
+	h := Health{Status: t.state.Status, Issues: slices.Clone(t.issues)}
+	if t.state.Status == livekit.IngressState_ENDPOINT_PUBLISHING && t.clock.Now().Sub(t.lastUpdate) > t.conf.StallTimeout {
+		h.Issues = append([]HealthIssue{HealthStalled}, h.Issues...)
+	}
+	return h
+}
+
+func (t *StateTracker) notify() {
+	h := t.health()
+	if slices.Equal(h.Issues, t.reported) {
+		return
+	}
+	t.reported = h.Issues
+	if t.onHealth != nil {
+		t.onHealth(t.ingressID, utils.CloneProto(t.state), h)
+	}
+}
+
+// Health returns the current input health. Stall detection uses the tracker clock, so it should be checked periodically.
+func (t *StateTracker) Health() Health {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.notify()
+	return t.health()
+}
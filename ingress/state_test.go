@@ -0,0 +1,132 @@
+package ingress
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/rpc"
+	"github.com/livekit/protocol/utils"
+)
+
+func TestMergeState(t *testing.T) {
+	cur := &livekit.IngressState{
+		Status:    livekit.IngressState_ENDPOINT_PUBLISHING,
+		RoomId:    "RM_1",
+		UpdatedAt: 100,
+		Video:     &livekit.InputVideoState{MimeType: "video/h264", Width: 1280, Height: 720, AverageBitrate: 2_000_000},
+		Tracks:    []*livekit.TrackInfo{{Sid: "TR_1"}},
+	}
+
+	out, err := MergeState(cur, &livekit.IngressState{
+		Status:    livekit.IngressState_ENDPOINT_PUBLISHING,
+		UpdatedAt: 200,
+		Video:     &livekit.InputVideoState{AverageBitrate: 1_500_000},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "RM_1", out.RoomId)
+	require.Equal(t, "video/h264", out.Video.MimeType)
+	require.EqualValues(t, 1280, out.Video.Width)
+	require.EqualValues(t, 1_500_000, out.Video.AverageBitrate)
+	require.Len(t, out.Tracks, 1)
+	require.EqualValues(t, 2_000_000, cur.Video.AverageBitrate, "original must not change")
+
+	_, err = MergeState(cur, &livekit.IngressState{Status: livekit.IngressState_ENDPOINT_INACTIVE, UpdatedAt: 99})
+	require.ErrorIs(t, err, ErrIngressOutOfDate)
+
+	out, err = MergeState(cur, &livekit.IngressState{Status: livekit.IngressState_ENDPOINT_ERROR, Error: "bad input"})
+	require.NoError(t, err)
+	require.Equal(t, "bad input", out.Error)
+
+	out, err = MergeState(out, &livekit.IngressState{Status: livekit.IngressState_ENDPOINT_BUFFERING})
+	require.NoError(t, err)
+	require.Empty(t, out.Error)
+
+	// Zero status leaves the status unchanged, unless the session ended.
+	out, err = MergeState(cur, &livekit.IngressState{RoomId: "RM_2"})
+	require.NoError(t, err)
+	require.Equal(t, livekit.IngressState_ENDPOINT_PUBLISHING, out.Status)
+	require.Equal(t, "RM_2", out.RoomId)
+	out, err = MergeState(cur, &livekit.IngressState{EndedAt: 300})
+	require.NoError(t, err)
+	require.Equal(t, livekit.IngressState_ENDPOINT_INACTIVE, out.Status)
+	require.EqualValues(t, 300, out.EndedAt)
+	out, err = MergeState(&livekit.IngressState{Status: livekit.IngressState_ENDPOINT_ERROR, Error: "bad input"}, &livekit.IngressState{UpdatedAt: 1})
+	require.NoError(t, err)
+	require.Equal(t, livekit.IngressState_ENDPOINT_ERROR, out.Status)
+	require.Equal(t, "bad input", out.Error)
+
+	_, err = MergeState(&livekit.IngressState{Status: livekit.IngressState_ENDPOINT_COMPLETE}, &livekit.IngressState{Status: livekit.IngressState_ENDPOINT_PUBLISHING})
+	require.Error(t, err)
+	_, err = MergeState(&livekit.IngressState{Status: livekit.IngressState_ENDPOINT_ERROR}, &livekit.IngressState{Status: livekit.IngressState_ENDPOINT_PUBLISHING})
+	require.Error(t, err)
+}
+
+func TestStateTrackerHealth(t *testing.T) {
+	clock := &utils.SimulatedClock{}
+	clock.Set(time.Unix(1000, 0))
+
+	var reported []Health
+	tr := NewStateTracker("IN_1", nil,
+		WithStateClock(clock),
+		WithHealthConfig(HealthConfig{StallTimeout: 10 * time.Second}),
+		WithHealthHandler(func(id string, state *livekit.IngressState, h Health) {
+			require.Equal(t, "IN_1", id)
+			reported = append(reported, h)
+		}),
+	)
+	require.Error(t, tr.Update(&rpc.UpdateIngressStateRequest{IngressId: "IN_2"}))
+
+	update := func(status livekit.IngressState_Status, w, h, bitrate uint32) {
+		t.Helper()
+		clock.Add(time.Second)
+		require.NoError(t, tr.Update(&rpc.UpdateIngressStateRequest{
+			IngressId: "IN_1",
+			State: &livekit.IngressState{
+				Status:    status,
+				UpdatedAt: clock.Now().UnixNano(),
+				Video:     &livekit.InputVideoState{Width: w, Height: h, AverageBitrate: bitrate},
+			},
+		}))
+	}
+
+	update(livekit.IngressState_ENDPOINT_BUFFERING, 1280, 720, 0)
+	update(livekit.IngressState_ENDPOINT_PUBLISHING, 1280, 720, 2_000_000)
+	update(livekit.IngressState_ENDPOINT_PUBLISHING, 1280, 720, 1_900_000)
+	require.True(t, tr.Health().Healthy())
+
+	update(livekit.IngressState_ENDPOINT_PUBLISHING, 1280, 720, 500_000)
+	require.True(t, tr.Health().Has(HealthVideoBitrateDrop))
+
+	update(livekit.IngressState_ENDPOINT_PUBLISHING, 1920, 1080, 1_800_000)
+	h := tr.Health()
+	require.False(t, h.Has(HealthVideoBitrateDrop))
+	require.True(t, h.Has(HealthResolutionChange))
+
+	update(livekit.IngressState_ENDPOINT_PUBLISHING, 1920, 1080, 1_800_000)
+	require.True(t, tr.Health().Healthy())
+
+	clock.Add(11 * time.Second)
+	require.Equal(t, []HealthIssue{HealthStalled}, tr.Health().Issues)
+
+	// New session resets stall detection and bitrate baseline.
+	clock.Add(time.Second)
+	require.NoError(t, tr.UpdateState(&livekit.IngressState{
+		Status:    livekit.IngressState_ENDPOINT_INACTIVE,
+		UpdatedAt: clock.Now().UnixNano(),
+		EndedAt:   clock.Now().UnixNano(),
+	}))
+	require.Equal(t, livekit.IngressState_ENDPOINT_INACTIVE, tr.State().Status)
+	update(livekit.IngressState_ENDPOINT_BUFFERING, 0, 0, 0)
+	update(livekit.IngressState_ENDPOINT_PUBLISHING, 640, 360, 300_000)
+	require.True(t, tr.Health().Healthy())
+
+	require.Equal(t, []HealthIssue{HealthVideoBitrateDrop}, reported[0].Issues)
+	require.Equal(t, []HealthIssue{HealthResolutionChange}, reported[1].Issues)
+	require.True(t, reported[2].Healthy())
+	require.Equal(t, []HealthIssue{HealthStalled}, reported[3].Issues)
+	require.True(t, reported[4].Healthy())
+	require.Len(t, reported, 5)
+}
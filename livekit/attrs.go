@@ -14,7 +14,7 @@ const (
 	// This attribute will be omitted if HidePhoneNumber is set.
 	AttrSIPTrunkNumber = AttrSIPPrefix + "trunkPhoneNumber"
 	// AttrSIPPhoneNumber attribute contains number external to LiveKit SIP (caller for inbound and called number for outbound).
-	// This attribute will be omitted if HidePhoneNumber is set.
+	// This attribute will be omitted if HidePhoneNumber is set, unless phone mask policy requests a masked number.
 	AttrSIPPhoneNumber = AttrSIPPrefix + "phoneNumber"
 	// AttrSIPHostName attribute contains host name external to LiveKit SIP (caller for inbound and called number for outbound).
 	AttrSIPHostName = AttrSIPPrefix + "hostname"
@@ -4,9 +4,11 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"time"
 
 	"github.com/livekit/psrpc"
+	"google.golang.org/protobuf/proto"
 )
 
 const (
@@ -23,46 +25,178 @@ const (
 	EndReasonFailure        = "Failure"
 )
 
-func (e *EgressInfo) UpdateStatus(status EgressStatus) {
-	e.Status = status
-	e.UpdatedAt = time.Now().UnixNano()
+// ErrEgressStaleUpdate is returned when an update is older than the current egress info.
+var ErrEgressStaleUpdate = errors.New("stale egress update")
+
+// egressTransitions lists allowed egress status changes. Staying in the same status is always allowed.
+var egressTransitions = map[EgressStatus][]EgressStatus{
+	EgressStatus_EGRESS_STARTING: {
+		EgressStatus_EGRESS_ACTIVE,
+		EgressStatus_EGRESS_ENDING,
+		EgressStatus_EGRESS_COMPLETE,
+		EgressStatus_EGRESS_FAILED,
+		EgressStatus_EGRESS_ABORTED,
+		EgressStatus_EGRESS_LIMIT_REACHED,
+	},
+	EgressStatus_EGRESS_ACTIVE: {
+		EgressStatus_EGRESS_ENDING,
+		EgressStatus_EGRESS_COMPLETE,
+		EgressStatus_EGRESS_FAILED,
+		EgressStatus_EGRESS_ABORTED,
+		EgressStatus_EGRESS_LIMIT_REACHED,
+	},
+	EgressStatus_EGRESS_ENDING: {
+		EgressStatus_EGRESS_COMPLETE,
+		EgressStatus_EGRESS_FAILED,
+		EgressStatus_EGRESS_ABORTED,
+		EgressStatus_EGRESS_LIMIT_REACHED,
+	},
+}
+
+// IsFinal returns true if egress cannot leave this status.
+func (s EgressStatus) IsFinal() bool {
+	switch s {
+	case EgressStatus_EGRESS_COMPLETE, EgressStatus_EGRESS_FAILED, EgressStatus_EGRESS_ABORTED, EgressStatus_EGRESS_LIMIT_REACHED:
+		return true
+	}
+	return false
+}
+
+// CanTransitionEgress checks if egress status can change from one value to another.
+func CanTransitionEgress(from, to EgressStatus) bool {
+	if from == to {
+		return true
+	}
+	return slices.Contains(egressTransitions[from], to)
+}
+
+// EgressTransitionError is returned for status changes not allowed by the egress lifecycle.
+type EgressTransitionError struct {
+	EgressID string
+	From     EgressStatus
+	To       EgressStatus
+}
+
+func (e *EgressTransitionError) Error() string {
+	return fmt.Sprintf("egress %s: invalid status transition from %s to %s", e.EgressID, e.From, e.To)
+}
+
+// EgressTransition is a single change of egress status.
+type EgressTransition struct {
+	From   EgressStatus
+	To     EgressStatus
+	Reason string
+	// At is the UpdatedAt value of the egress after the change.
+	At int64
+}
+
+// touch updates UpdatedAt, making sure it never goes backwards.
+func (e *EgressInfo) touch() int64 {
+	now := time.Now().UnixNano()
+	if now <= e.UpdatedAt {
+		now = e.UpdatedAt + 1
+	}
+	e.UpdatedAt = now
+	return now
+}
+
+// Transition changes egress status, if it is allowed by the egress lifecycle. Reason is used as an error message
+// for failed and aborted egress and as an end reason otherwise.
+func (e *EgressInfo) Transition(to EgressStatus, reason string) error {
+	from := e.Status
+	if !CanTransitionEgress(from, to) {
+		return &EgressTransitionError{EgressID: e.EgressId, From: from, To: to}
+	}
+	if from == to {
+		return nil
+	}
+	now := e.touch()
+	e.Status = to
+	switch to {
+	case EgressStatus_EGRESS_ACTIVE:
+		if e.StartedAt == 0 {
+			e.StartedAt = now
+		}
+	case EgressStatus_EGRESS_LIMIT_REACHED:
+		e.Error = MsgLimitReached
+		e.ErrorCode = int32(http.StatusRequestEntityTooLarge)
+		e.SetEndReason(EndReasonLimitReached)
+	case EgressStatus_EGRESS_ABORTED:
+		e.Error = reason
+		e.ErrorCode = int32(http.StatusPreconditionFailed)
+	case EgressStatus_EGRESS_FAILED:
+		e.Error = reason
+		if e.Details == "" {
+			e.SetEndReason(EndReasonFailure)
+		}
+	default:
+		if reason != "" {
+			e.SetEndReason(reason)
+		}
+	}
+	if to.IsFinal() {
+		e.EndedAt = now
+	}
+	return nil
+}
+
+// ApplyUpdate replaces egress info with a newer version of it. Updates older than the current info
+// are rejected with ErrEgressStaleUpdate, and status changes are checked against the egress lifecycle.
+// An update with the same UpdatedAt is only accepted if it moves egress to a later status,
+// so duplicate or conflicting updates for the same moment are rejected as stale.
+func (e *EgressInfo) ApplyUpdate(update *EgressInfo) error {
+	if update.EgressId != e.EgressId {
+		return fmt.Errorf("egress id mismatch: %s != %s", update.EgressId, e.EgressId)
+	}
+	if update.UpdatedAt < e.UpdatedAt || (update.UpdatedAt == e.UpdatedAt && update.Status == e.Status) {
+		return ErrEgressStaleUpdate
+	}
+	if !CanTransitionEgress(e.Status, update.Status) {
+		return &EgressTransitionError{EgressID: e.EgressId, From: e.Status, To: update.Status}
+	}
+	proto.Reset(e)
+	proto.Merge(e, update)
+	return nil
+}
+
+// UpdateStatus changes egress status using Transition. If the status is unchanged, only UpdatedAt is bumped,
+// so the info is still accepted by ApplyUpdate, e.g. when new results are added.
+// Changes not allowed by the egress lifecycle are not applied and return EgressTransitionError.
+func (e *EgressInfo) UpdateStatus(status EgressStatus) error {
+	if status == e.Status {
+		e.touch()
+		return nil
+	}
+	return e.Transition(status, "")
 }
 
 func (e *EgressInfo) SetBackupUsed() {
 	e.BackupStorageUsed = true
-	e.UpdatedAt = time.Now().UnixNano()
+	e.touch()
 }
 
 func (e *EgressInfo) SetEndReason(reason string) {
 	e.Details = fmt.Sprintf("End reason: %s", reason)
 }
 
-func (e *EgressInfo) SetLimitReached() {
-	now := time.Now().UnixNano()
-	e.Status = EgressStatus_EGRESS_LIMIT_REACHED
-	e.Error = MsgLimitReached
-	e.ErrorCode = int32(http.StatusRequestEntityTooLarge)
-	e.UpdatedAt = now
-	e.EndedAt = now
+// SetLimitReached ends egress with EGRESS_LIMIT_REACHED. If egress has already ended, the info is not changed
+// and EgressTransitionError is returned, unless it ended with the same status.
+func (e *EgressInfo) SetLimitReached() error {
+	return e.Transition(EgressStatus_EGRESS_LIMIT_REACHED, "")
 }
 
-func (e *EgressInfo) SetAborted(msg string) {
-	now := time.Now().UnixNano()
-	e.Status = EgressStatus_EGRESS_ABORTED
-	e.Error = msg
-	e.ErrorCode = int32(http.StatusPreconditionFailed)
-	e.UpdatedAt = now
-	e.EndedAt = now
+// SetAborted ends egress with EGRESS_ABORTED. If egress has already ended, the info is not changed
+// and EgressTransitionError is returned, unless it ended with the same status.
+func (e *EgressInfo) SetAborted(msg string) error {
+	return e.Transition(EgressStatus_EGRESS_ABORTED, msg)
 }
 
-func (e *EgressInfo) SetFailed(err error) {
-	now := time.Now().UnixNano()
-	e.Status = EgressStatus_EGRESS_FAILED
-	e.UpdatedAt = now
-	e.EndedAt = now
-	e.Error = err.Error()
-	if e.Details == "" {
-		e.SetEndReason(EndReasonFailure)
+// SetFailed ends egress with EGRESS_FAILED. If egress has already ended, the info is not changed
+// and EgressTransitionError is returned, unless it ended with the same status.
+func (e *EgressInfo) SetFailed(err error) error {
+	ended := e.Status.IsFinal()
+	if terr := e.Transition(EgressStatus_EGRESS_FAILED, err.Error()); terr != nil || ended {
+		return terr
 	}
 
 	var p psrpc.Error
@@ -72,11 +206,11 @@ func (e *EgressInfo) SetFailed(err error) {
 			e.ErrorCode = int32(p.ToHttp())
 		}
 	}
+	return nil
 }
 
-func (e *EgressInfo) SetComplete() {
-	now := time.Now().UnixNano()
-	e.Status = EgressStatus_EGRESS_COMPLETE
-	e.UpdatedAt = now
-	e.EndedAt = now
+// SetComplete ends egress with EGRESS_COMPLETE. If egress has already ended, the info is not changed
+// and EgressTransitionError is returned, unless it ended with the same status.
+func (e *EgressInfo) SetComplete() error {
+	return e.Transition(EgressStatus_EGRESS_COMPLETE, "")
 }
@@ -0,0 +1,110 @@
+package livekit
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/livekit/psrpc"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestEgressTransition(t *testing.T) {
+	info := &EgressInfo{EgressId: "EG_1"}
+	require.NoError(t, info.Transition(EgressStatus_EGRESS_ACTIVE, ""))
+	require.NotZero(t, info.StartedAt)
+	updated := info.UpdatedAt
+
+	// Same status is a no-op.
+	require.NoError(t, info.Transition(EgressStatus_EGRESS_ACTIVE, ""))
+	require.Equal(t, updated, info.UpdatedAt)
+
+	require.NoError(t, info.Transition(EgressStatus_EGRESS_ENDING, EndReasonAPI))
+	require.Equal(t, "End reason: StopEgress API", info.Details)
+	require.Greater(t, info.UpdatedAt, updated)
+
+	require.NoError(t, info.Transition(EgressStatus_EGRESS_FAILED, "upload failed"))
+	require.Equal(t, "upload failed", info.Error)
+	require.NotZero(t, info.EndedAt)
+
+	err := info.Transition(EgressStatus_EGRESS_ACTIVE, "")
+	var terr *EgressTransitionError
+	require.True(t, errors.As(err, &terr))
+	require.Equal(t, EgressStatus_EGRESS_FAILED, terr.From)
+	require.Equal(t, EgressStatus_EGRESS_FAILED, info.Status)
+
+	for from := range EgressStatus_name {
+		s := EgressStatus(from)
+		if s.IsFinal() {
+			for to := range EgressStatus_name {
+				require.Equal(t, from == to, CanTransitionEgress(s, EgressStatus(to)))
+			}
+		}
+	}
+	require.False(t, CanTransitionEgress(EgressStatus_EGRESS_ENDING, EgressStatus_EGRESS_ACTIVE))
+}
+
+func TestEgressApplyUpdate(t *testing.T) {
+	info := &EgressInfo{EgressId: "EG_1", Status: EgressStatus_EGRESS_ACTIVE, UpdatedAt: 100}
+
+	require.ErrorIs(t, info.ApplyUpdate(&EgressInfo{EgressId: "EG_1", Status: EgressStatus_EGRESS_ENDING, UpdatedAt: 99}), ErrEgressStaleUpdate)
+	require.Error(t, info.ApplyUpdate(&EgressInfo{EgressId: "EG_2", UpdatedAt: 200}))
+	require.Error(t, info.ApplyUpdate(&EgressInfo{EgressId: "EG_1", Status: EgressStatus_EGRESS_STARTING, UpdatedAt: 200}))
+	require.Equal(t, EgressStatus_EGRESS_ACTIVE, info.Status)
+
+	// Same timestamp is only accepted if status moves forward.
+	require.ErrorIs(t, info.ApplyUpdate(&EgressInfo{EgressId: "EG_1", Status: EgressStatus_EGRESS_ACTIVE, UpdatedAt: 100}), ErrEgressStaleUpdate)
+	require.NoError(t, info.ApplyUpdate(&EgressInfo{EgressId: "EG_1", Status: EgressStatus_EGRESS_ENDING, UpdatedAt: 100}))
+	require.Error(t, info.ApplyUpdate(&EgressInfo{EgressId: "EG_1", Status: EgressStatus_EGRESS_ACTIVE, UpdatedAt: 100}))
+
+	require.NoError(t, info.ApplyUpdate(&EgressInfo{EgressId: "EG_1", Status: EgressStatus_EGRESS_COMPLETE, UpdatedAt: 200}))
+	require.Equal(t, EgressStatus_EGRESS_COMPLETE, info.Status)
+	require.EqualValues(t, 200, info.UpdatedAt)
+
+	// Timestamps stay monotonic even if the clock is behind.
+	info.UpdatedAt = 1 << 62
+	info.SetBackupUsed()
+	require.EqualValues(t, 1<<62+1, info.UpdatedAt)
+}
+
+func TestEgressSetters(t *testing.T) {
+	info := &EgressInfo{EgressId: "EG_1"}
+	require.NoError(t, info.UpdateStatus(EgressStatus_EGRESS_ACTIVE))
+	require.Equal(t, EgressStatus_EGRESS_ACTIVE, info.Status)
+	require.NotZero(t, info.StartedAt)
+
+	// Progress updates with the same status are newer than the current info.
+	current := proto.Clone(info).(*EgressInfo)
+	update := proto.Clone(info).(*EgressInfo)
+	update.FileResults = []*FileInfo{{Filename: "out.mp4"}}
+	require.NoError(t, update.UpdateStatus(EgressStatus_EGRESS_ACTIVE))
+	require.Greater(t, update.UpdatedAt, current.UpdatedAt)
+	require.NoError(t, current.ApplyUpdate(update))
+	require.Len(t, current.FileResults, 1)
+
+	require.NoError(t, info.SetFailed(psrpc.NewErrorf(psrpc.NotFound, "not found")))
+	require.Equal(t, EgressStatus_EGRESS_FAILED, info.Status)
+	require.Equal(t, "not found", info.Error)
+	require.EqualValues(t, http.StatusNotFound, info.ErrorCode)
+	require.NotZero(t, info.EndedAt)
+	ended := info.EndedAt
+
+	// Final status is kept.
+	var terr *EgressTransitionError
+	require.ErrorAs(t, info.SetComplete(), &terr)
+	require.ErrorAs(t, info.SetAborted("aborted"), &terr)
+	require.ErrorAs(t, info.SetLimitReached(), &terr)
+	require.ErrorAs(t, info.UpdateStatus(EgressStatus_EGRESS_ACTIVE), &terr)
+	require.NoError(t, info.SetFailed(errors.New("other")))
+	require.Equal(t, EgressStatus_EGRESS_FAILED, info.Status)
+	require.Equal(t, "not found", info.Error)
+	require.Equal(t, ended, info.EndedAt)
+
+	info = &EgressInfo{EgressId: "EG_2", Status: EgressStatus_EGRESS_ENDING}
+	require.ErrorAs(t, info.UpdateStatus(EgressStatus_EGRESS_ACTIVE), &terr)
+	require.Equal(t, EgressStatus_EGRESS_ENDING, info.Status)
+	require.NoError(t, info.SetLimitReached())
+	require.Equal(t, EgressStatus_EGRESS_LIMIT_REACHED, info.Status)
+	require.Equal(t, MsgLimitReached, info.Error)
+}
@@ -426,8 +426,10 @@ func (p *SIPOutboundTrunkInfo) Validate() error {
 		return errors.New("trunk transport should be set as a field, not a URI parameter")
 	} else if strings.ContainsAny(p.Address, "@;") || strings.HasPrefix(p.Address, "sip:") || strings.HasPrefix(p.Address, "sips:") {
 		return errors.New("trunk address should be a hostname or IP, not SIP URI")
+	} else if err := ValidateSIPHostPort(p.Address); err != nil {
+		return err
 	}
-	if err := validateHeaderKeys(p.Headers); err != nil {
+	if err := validateHeaders(p.Headers); err != nil {
 		return err
 	}
 	if err := validateHeaderKeys(p.HeadersToAttributes); err != nil {
@@ -446,6 +448,8 @@ func (p *SIPOutboundConfig) Validate() error {
 		return errors.New("trunk transport should be set as a field, not a URI parameter")
 	} else if strings.ContainsAny(p.Hostname, "@;") || strings.HasPrefix(p.Hostname, "sip:") || strings.HasPrefix(p.Hostname, "sips:") {
 		return errors.New("trunk hostname should be a domain name or IP, not SIP URI")
+	} else if err := ValidateSIPHostPort(p.Hostname); err != nil {
+		return err
 	}
 	if err := validateHeaderKeys(p.HeadersToAttributes); err != nil {
 		return err
@@ -647,11 +651,13 @@ func (p *CreateSIPParticipantRequest) Validate() error {
 		return errors.New("missing sip callee number")
 	} else if strings.Contains(p.SipCallTo, "@") {
 		return errors.New("SipCallTo should be a phone number or SIP user, not a full SIP URI")
+	} else if err := ValidateSIPUser(p.SipCallTo); err != nil {
+		return err
 	}
 	if p.RoomName == "" {
 		return errors.New("missing room name")
 	}
-	if err := validateHeaderKeys(p.Headers); err != nil {
+	if err := validateHeaders(p.Headers); err != nil {
 		return err
 	}
 	return nil
@@ -666,14 +672,37 @@ func (p *TransferSIPParticipantRequest) Validate() error {
 	}
 	if p.TransferTo == "" {
 		return errors.New("missing transfer to")
+	} else if err := validateTransferTo(p.TransferTo); err != nil {
+		return err
 	}
-	if err := validateHeaderKeys(p.Headers); err != nil {
+	if err := validateHeaders(p.Headers); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+func validateTransferTo(to string) error {
+	to = strings.TrimSuffix(strings.TrimPrefix(to, "<"), ">")
+	scheme, rest, ok := strings.Cut(to, ":")
+	if ok {
+		switch strings.ToLower(scheme) {
+		case "sip", "sips":
+			_, err := ParseSIPURI(to)
+			return err
+		case "tel":
+			// RFC 3966 number, possibly with parameters.
+			num, _, _ := strings.Cut(rest, ";")
+			return ValidateSIPUser(num)
+		}
+	}
+	if strings.Contains(to, "@") {
+		_, err := ParseSIPURI("sip:" + to)
+		return err
+	}
+	return ValidateSIPUser(to)
+}
+
 func filterSlice[T any](arr []T, fnc func(v T) bool) []T {
 	var out []T
 	for _, v := range arr {
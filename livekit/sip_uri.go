@@ -0,0 +1,494 @@
+// Copyright 2025 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package livekit
+
+import (
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// SIPURIError describes which part of a SIP URI is invalid.
+type SIPURIError struct {
+	URI    string
+	Field  string // scheme, user, password, host, port, param or header
+	Value  string
+	Reason string
+}
+
+func (e *SIPURIError) Error() string {
+	if e.Value == "" {
+		return fmt.Sprintf("invalid SIP URI %q: %s: %s", e.URI, e.Field, e.Reason)
+	}
+	return fmt.Sprintf("invalid SIP URI %q: %s %q: %s", e.URI, e.Field, e.Value, e.Reason)
+}
+
+// SIPHeaderError describes an invalid SIP header name or value.
+type SIPHeaderError struct {
+	Header string
+	Value  string
+	Reason string
+}
+
+func (e *SIPHeaderError) Error() string {
+	if e.Value == "" {
+		return fmt.Sprintf("invalid SIP header %q: %s", e.Header, e.Reason)
+	}
+	return fmt.Sprintf("invalid SIP header %q value %q: %s", e.Header, e.Value, e.Reason)
+}
+
+// SIPURIParam is a single URI parameter. Value is empty for flag parameters, like "lr".
+type SIPURIParam struct {
+	Name  string
+	Value string
+}
+
+// ParsedSIPURI is a SIP or SIPS URI, as defined in RFC 3261, section 19.1.
+type ParsedSIPURI struct {
+	Secure   bool // sips scheme
+	User     string
+	Password string
+	Host     string // IPv6 addresses are stored without brackets
+	Port     int    // zero if not set
+	Params   []SIPURIParam
+	Headers  []SIPURIParam
+}
+
+// Param returns the value of the URI parameter with a given name.
+func (u *ParsedSIPURI) Param(name string) (string, bool) {
+	for _, p := range u.Params {
+		if strings.EqualFold(p.Name, name) {
+			return p.Value, true
+		}
+	}
+	return "", false
+}
+
+// Transport returns the transport requested by the URI.
+func (u *ParsedSIPURI) Transport() SIPTransport {
+	if u.Secure {
+		return SIPTransport_SIP_TRANSPORT_TLS
+	}
+	v, _ := u.Param("transport")
+	switch strings.ToLower(v) {
+	case "udp":
+		return SIPTransport_SIP_TRANSPORT_UDP
+	case "tcp":
+		return SIPTransport_SIP_TRANSPORT_TCP
+	case "tls":
+		return SIPTransport_SIP_TRANSPORT_TLS
+	}
+	return SIPTransport_SIP_TRANSPORT_AUTO
+}
+
+// HostPort returns host and port (if set) of the URI. IPv6 addresses are enclosed in brackets.
+func (u *ParsedSIPURI) HostPort() string {
+	host := u.Host
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if u.Port != 0 {
+		return host + ":" + strconv.Itoa(u.Port)
+	}
+	return host
+}
+
+// String returns the URI in the normalized form.
+func (u *ParsedSIPURI) String() string {
+	var b strings.Builder
+	if u.Secure {
+		b.WriteString("sips:")
+	} else {
+		b.WriteString("sip:")
+	}
+	if u.User != "" {
+		b.WriteString(escapeSIP(u.User, isSIPUserChar))
+		if u.Password != "" {
+			b.WriteByte(':')
+			b.WriteString(escapeSIP(u.Password, isSIPPasswordChar))
+		}
+		b.WriteByte('@')
+	}
+	b.WriteString(u.HostPort())
+	for _, p := range u.Params {
+		b.WriteByte(';')
+		b.WriteString(escapeSIP(p.Name, isSIPParamChar))
+		if p.Value != "" {
+			b.WriteByte('=')
+			b.WriteString(escapeSIP(p.Value, isSIPParamChar))
+		}
+	}
+	for i, h := range u.Headers {
+		if i == 0 {
+			b.WriteByte('?')
+		} else {
+			b.WriteByte('&')
+		}
+		b.WriteString(escapeSIP(h.Name, isSIPHeaderChar))
+		b.WriteByte('=')
+		b.WriteString(escapeSIP(h.Value, isSIPHeaderChar))
+	}
+	return b.String()
+}
+
+// ToProto converts the URI to SIPUri. Parameters other than transport, and headers are dropped.
+func (u *ParsedSIPURI) ToProto() *SIPUri {
+	p := &SIPUri{
+		User:      u.User,
+		Host:      u.Host,
+		Port:      uint32(u.Port),
+		Transport: u.Transport(),
+	}
+	if _, err := netip.ParseAddr(u.Host); err == nil {
+		p.Ip = u.Host
+	}
+	return p
+}
+
+// ParseSIPURI parses and normalizes a SIP or SIPS URI.
+//
+// Scheme, host and parameter names are lower-cased, escaped characters are decoded,
+// and visual separators are removed from telephone numbers in the user part.
+func ParseSIPURI(uri string) (*ParsedSIPURI, error) {
+	fail := func(field, value, reason string) error {
+		return &SIPURIError{URI: uri, Field: field, Value: value, Reason: reason}
+	}
+	var u ParsedSIPURI
+	scheme, rest, ok := strings.Cut(uri, ":")
+	if !ok {
+		return nil, fail("scheme", "", "missing sip: or sips: scheme")
+	}
+	switch strings.ToLower(scheme) {
+	case "sip":
+	case "sips":
+		u.Secure = true
+	default:
+		return nil, fail("scheme", scheme, "must be sip or sips")
+	}
+	if i := strings.IndexByte(rest, '@'); i >= 0 {
+		userinfo := rest[:i]
+		rest = rest[i+1:]
+		user, pass, hasPass := strings.Cut(userinfo, ":")
+		if user == "" {
+			return nil, fail("user", "", "empty user")
+		}
+		var err error
+		if u.User, err = unescapeSIP(user, isSIPUserChar); err != nil {
+			return nil, fail("user", user, err.Error())
+		}
+		if hasPass {
+			if u.Password, err = unescapeSIP(pass, isSIPPasswordChar); err != nil {
+				return nil, fail("password", "", err.Error())
+			}
+		}
+		u.User = NormalizeSIPUser(u.User)
+	}
+	var headers string
+	if i := strings.IndexByte(rest, '?'); i >= 0 {
+		rest, headers = rest[:i], rest[i+1:]
+	}
+	hostport, params, _ := strings.Cut(rest, ";")
+	host, port, err := parseSIPHostPort(hostport)
+	if err != nil {
+		e := err.(*SIPURIError)
+		e.URI = uri
+		return nil, e
+	}
+	u.Host, u.Port = host, port
+	if params != "" {
+		for _, p := range strings.Split(params, ";") {
+			name, val, _ := strings.Cut(p, "=")
+			if name == "" {
+				return nil, fail("param", p, "empty parameter name")
+			}
+			if name, err = unescapeSIP(name, isSIPParamChar); err != nil {
+				return nil, fail("param", p, err.Error())
+			}
+			if val, err = unescapeSIP(val, isSIPParamChar); err != nil {
+				return nil, fail("param", p, err.Error())
+			}
+			name = strings.ToLower(name)
+			switch name {
+			case "transport", "user", "method":
+				val = strings.ToLower(val)
+			}
+			if name == "transport" {
+				switch val {
+				case "udp", "tcp", "tls", "sctp", "ws", "wss":
+				default:
+					return nil, fail("param", p, "unsupported transport")
+				}
+			}
+			u.Params = append(u.Params, SIPURIParam{Name: name, Value: val})
+		}
+	}
+	if headers != "" {
+		for _, h := range strings.Split(headers, "&") {
+			name, val, ok := strings.Cut(h, "=")
+			if !ok || name == "" {
+				return nil, fail("header", h, "expected name=value")
+			}
+			if name, err = unescapeSIP(name, isSIPHeaderChar); err != nil {
+				return nil, fail("header", h, err.Error())
+			}
+			if val, err = unescapeSIP(val, isSIPHeaderChar); err != nil {
+				return nil, fail("header", h, err.Error())
+			}
+			u.Headers = append(u.Headers, SIPURIParam{Name: name, Value: val})
+		}
+	}
+	return &u, nil
+}
+
+// ValidateSIPHostPort checks that the address is a valid host or IP, with an optional port, as used in SIP URI.
+func ValidateSIPHostPort(addr string) error {
+	_, _, err := parseSIPHostPort(addr)
+	return err
+}
+
+func parseSIPHostPort(hostport string) (string, int, error) {
+	fail := func(field, value, reason string) (string, int, error) {
+		return "", 0, &SIPURIError{URI: hostport, Field: field, Value: value, Reason: reason}
+	}
+	if hostport == "" {
+		return fail("host", "", "empty host")
+	}
+	var host, port string
+	if strings.HasPrefix(hostport, "[") {
+		end := strings.IndexByte(hostport, ']')
+		if end < 0 {
+			return fail("host", hostport, "unterminated IPv6 reference")
+		}
+		host = hostport[1:end]
+		rest := hostport[end+1:]
+		if rest != "" {
+			if rest[0] != ':' {
+				return fail("host", hostport, "unexpected characters after IPv6 reference")
+			}
+			port = rest[1:]
+			if port == "" {
+				return fail("port", "", "empty port")
+			}
+		}
+		ip, err := netip.ParseAddr(host)
+		if err != nil || !ip.Is6() {
+			return fail("host", host, "invalid IPv6 address")
+		}
+	} else {
+		var hasPort bool
+		host, port, hasPort = strings.Cut(hostport, ":")
+		if hasPort && port == "" {
+			return fail("port", "", "empty port")
+		}
+		if strings.Contains(port, ":") {
+			return fail("host", hostport, "IPv6 address must be enclosed in brackets")
+		}
+		if !isSIPHostname(host) {
+			return fail("host", host, "invalid hostname or IPv4 address")
+		}
+	}
+	p := 0
+	if port != "" {
+		v, err := strconv.ParseUint(port, 10, 16)
+		if err != nil || v == 0 {
+			return fail("port", port, "must be a number in 1-65535 range")
+		}
+		p = int(v)
+	}
+	return strings.ToLower(host), p, nil
+}
+
+func isSIPHostname(host string) bool {
+	host = strings.TrimSuffix(host, ".")
+	if host == "" || len(host) > 253 {
+		return false
+	}
+	labels := strings.Split(host, ".")
+	allDigits := true
+	for _, l := range labels {
+		if l == "" || len(l) > 63 || l[0] == '-' || l[len(l)-1] == '-' {
+			return false
+		}
+		for i := 0; i < len(l); i++ {
+			c := l[i]
+			switch {
+			case c >= '0' && c <= '9':
+			case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '-', c == '_':
+				// RFC 3261 doesn't allow underscores, but they are common in provider hostnames and SRV names.
+				allDigits = false
+			default:
+				return false
+			}
+		}
+	}
+	if allDigits {
+		// Must be a valid IPv4 address then.
+		ip, err := netip.ParseAddr(host)
+		return err == nil && ip.Is4()
+	}
+	// Top label must start with a letter (RFC 3261: toplabel = ALPHA / ALPHA *( alphanum / "-" ) alphanum).
+	top := labels[len(labels)-1]
+	return (top[0] >= 'a' && top[0] <= 'z') || (top[0] >= 'A' && top[0] <= 'Z')
+}
+
+// ValidateSIPUser checks that the value can be used as a user part of SIP URI, for example a phone number or extension.
+// Phone numbers may contain visual separators, which are removed by NormalizeSIPUser.
+func ValidateSIPUser(user string) error {
+	if user == "" {
+		return &SIPURIError{URI: user, Field: "user", Reason: "empty user"}
+	}
+	if _, err := unescapeSIP(NormalizeSIPUser(user), isSIPUserChar); err != nil {
+		return &SIPURIError{URI: user, Field: "user", Value: user, Reason: err.Error()}
+	}
+	return nil
+}
+
+// ValidateSIPHeaderValue checks that the header value can be safely sent in a SIP message.
+func ValidateSIPHeaderValue(name, value string) error {
+	if !utf8.ValidString(value) {
+		return &SIPHeaderError{Header: name, Value: value, Reason: "invalid UTF-8"}
+	}
+	for _, r := range value {
+		if r == '\t' {
+			continue
+		}
+		if r < 0x20 || r == 0x7f {
+			return &SIPHeaderError{Header: name, Value: value, Reason: "control characters are not allowed"}
+		}
+	}
+	if strings.TrimSpace(value) != value {
+		return &SIPHeaderError{Header: name, Value: value, Reason: "leading or trailing whitespace"}
+	}
+	return nil
+}
+
+func validateHeaders(headers map[string]string) error {
+	for k, v := range headers {
+		if err := validateHeader(k); err != nil {
+			return err
+		}
+		if err := ValidateSIPHeaderValue(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NormalizeSIPUser removes visual separators (RFC 3966) and spaces from telephone numbers,
+// for example "+1 (415) 555-0100" becomes "+14155550100". Other users are returned as-is.
+func NormalizeSIPUser(user string) string {
+	num := strings.TrimPrefix(user, "+")
+	digits := false
+	for i := 0; i < len(num); i++ {
+		switch c := num[i]; {
+		case c >= '0' && c <= '9':
+			digits = true
+		case c == '-', c == '.', c == '(', c == ')', c == ' ':
+		default:
+			return user
+		}
+	}
+	if !digits {
+		return user
+	}
+	return strings.NewReplacer("-", "", ".", "", "(", "", ")", "", " ", "").Replace(user)
+}
+
+func isSIPUnreserved(c byte) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		return true
+	}
+	return strings.IndexByte("-_.!~*'()", c) >= 0
+}
+
+func isSIPUserChar(c byte) bool {
+	return isSIPUnreserved(c) || strings.IndexByte("&=+$,;?/", c) >= 0
+}
+
+func isSIPPasswordChar(c byte) bool {
+	return isSIPUnreserved(c) || strings.IndexByte("&=+$,", c) >= 0
+}
+
+func isSIPParamChar(c byte) bool {
+	return isSIPUnreserved(c) || strings.IndexByte("[]/:&+$", c) >= 0
+}
+
+func isSIPHeaderChar(c byte) bool {
+	return isSIPUnreserved(c) || strings.IndexByte("[]/?:+$", c) >= 0
+}
+
+func unhex(c byte) (byte, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10, true
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+func unescapeSIP(s string, allowed func(c byte) bool) (string, error) {
+	if !strings.ContainsRune(s, '%') {
+		for i := 0; i < len(s); i++ {
+			if !allowed(s[i]) {
+				return "", fmt.Errorf("character %q is not allowed", s[i])
+			}
+		}
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '%' {
+			if i+2 >= len(s) {
+				return "", fmt.Errorf("incomplete escape sequence")
+			}
+			h, ok1 := unhex(s[i+1])
+			l, ok2 := unhex(s[i+2])
+			if !ok1 || !ok2 {
+				return "", fmt.Errorf("invalid escape sequence %q", s[i:i+3])
+			}
+			b.WriteByte(h<<4 | l)
+			i += 2
+			continue
+		}
+		if !allowed(c) {
+			return "", fmt.Errorf("character %q is not allowed", c)
+		}
+		b.WriteByte(c)
+	}
+	return b.String(), nil
+}
+
+func escapeSIP(s string, allowed func(c byte) bool) string {
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if allowed(c) {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hex[c>>4])
+		b.WriteByte(hex[c&0xf])
+	}
+	return b.String()
+}
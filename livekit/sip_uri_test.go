@@ -0,0 +1,182 @@
+package livekit
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSIPURI(t *testing.T) {
+	cases := []struct {
+		uri   string
+		norm  string
+		field string // expected error field
+	}{
+		{uri: "sip:example.com", norm: "sip:example.com"},
+		{uri: "SIP:User@Example.COM:5060", norm: "sip:User@example.com:5060"},
+		{uri: "sips:+1-415-555-0100@sip.example.com;user=phone", norm: "sips:+14155550100@sip.example.com;user=phone"},
+		{uri: "sip:alice:secret@10.0.0.1;Transport=TCP;lr", norm: "sip:alice:secret@10.0.0.1;transport=tcp;lr"},
+		{uri: "sip:bob@[2001:db8::1]:5061", norm: "sip:bob@[2001:db8::1]:5061"},
+		{uri: "sip:b%6Fb@example.com?subject=hi&priority=urgent", norm: "sip:bob@example.com?subject=hi&priority=urgent"},
+		{uri: "sip:a%20b@example.com", norm: "sip:a%20b@example.com"},
+		{uri: "sip:user@sip_trunk.example.com", norm: "sip:user@sip_trunk.example.com"},
+		{uri: "example.com", field: "scheme"},
+		{uri: "http://example.com", field: "scheme"},
+		{uri: "sip:@example.com", field: "user"},
+		{uri: "sip:a b@example.com", field: "user"},
+		{uri: "sip:a%2@example.com", field: "user"},
+		{uri: "sip:user@", field: "host"},
+		{uri: "sip:user@-example.com", field: "host"},
+		{uri: "sip:user@1.2.3.256", field: "host"},
+		{uri: "sip:user@2001:db8::1", field: "host"},
+		{uri: "sip:user@[1.2.3.4]", field: "host"},
+		{uri: "sip:user@example.com:", field: "port"},
+		{uri: "sip:user@example.com:70000", field: "port"},
+		{uri: "sip:user@example.com:abc", field: "port"},
+		{uri: "sip:user@example.com;transport=foo", field: "param"},
+		{uri: "sip:user@example.com;=x", field: "param"},
+		{uri: "sip:user@example.com?subject", field: "header"},
+	}
+	for _, c := range cases {
+		t.Run(c.uri, func(t *testing.T) {
+			u, err := ParseSIPURI(c.uri)
+			if c.field != "" {
+				var e *SIPURIError
+				require.True(t, errors.As(err, &e), "error: %v", err)
+				require.Equal(t, c.field, e.Field)
+				require.Equal(t, c.uri, e.URI)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.norm, u.String())
+			u2, err := ParseSIPURI(u.String())
+			require.NoError(t, err)
+			require.Equal(t, u, u2)
+		})
+	}
+}
+
+func TestParsedSIPURIProto(t *testing.T) {
+	u, err := ParseSIPURI("sips:+15550100@10.0.0.1:5061")
+	require.NoError(t, err)
+	require.Equal(t, &SIPUri{
+		User:      "+15550100",
+		Host:      "10.0.0.1",
+		Ip:        "10.0.0.1",
+		Port:      5061,
+		Transport: SIPTransport_SIP_TRANSPORT_TLS,
+	}, u.ToProto())
+
+	u, err = ParseSIPURI("sip:alice@example.com;transport=udp")
+	require.NoError(t, err)
+	require.Equal(t, SIPTransport_SIP_TRANSPORT_UDP, u.Transport())
+	require.Equal(t, "example.com", u.HostPort())
+}
+
+func TestValidateSIPHeaderValue(t *testing.T) {
+	require.NoError(t, ValidateSIPHeaderValue("X-A", "some value\twith tab"))
+	require.NoError(t, ValidateSIPHeaderValue("X-A", ""))
+	var e *SIPHeaderError
+	require.ErrorAs(t, ValidateSIPHeaderValue("X-A", "a\r\nX-Injected: 1"), &e)
+	require.Equal(t, "X-A", e.Header)
+	require.Error(t, ValidateSIPHeaderValue("X-A", " a"))
+	require.Error(t, ValidateSIPHeaderValue("X-A", "\xff"))
+}
+
+func TestNormalizeSIPUser(t *testing.T) {
+	require.Equal(t, "+14155550100", NormalizeSIPUser("+1 (415) 555-0100"))
+	require.Equal(t, "4155550100", NormalizeSIPUser("415.555.0100"))
+	require.Equal(t, "alice-bob", NormalizeSIPUser("alice-bob"))
+	require.Equal(t, "---", NormalizeSIPUser("---"))
+}
+
+func TestSIPRequestValidateURI(t *testing.T) {
+	cases := []struct {
+		name string
+		req  interface {
+			Validate() error
+		}
+		exp bool
+	}{
+		{
+			name: "outbound address with port",
+			req:  &SIPOutboundTrunkInfo{Address: "sip.example.com:5080", Numbers: []string{"+2222"}},
+			exp:  true,
+		},
+		{
+			name: "outbound bad address",
+			req:  &SIPOutboundTrunkInfo{Address: "sip example.com", Numbers: []string{"+2222"}},
+			exp:  false,
+		},
+		{
+			name: "outbound bad header value",
+			req: &SIPOutboundTrunkInfo{Address: "sip.example.com", Numbers: []string{"+2222"}, Headers: map[string]string{
+				"X-A": "a\nb",
+			}},
+			exp: false,
+		},
+		{
+			name: "create participant",
+			req:  &CreateSIPParticipantRequest{SipTrunkId: "ST_1", SipCallTo: "+1 (415) 555-0100", RoomName: "room"},
+			exp:  true,
+		},
+		{
+			name: "create participant bad number",
+			req:  &CreateSIPParticipantRequest{SipTrunkId: "ST_1", SipCallTo: "+1 415 <555>", RoomName: "room"},
+			exp:  false,
+		},
+		{
+			name: "create participant number",
+			req:  &CreateSIPParticipantRequest{SipTrunkId: "ST_1", SipCallTo: "+14155550100", RoomName: "room"},
+			exp:  true,
+		},
+		{
+			name: "create participant bad hostname",
+			req: &CreateSIPParticipantRequest{SipCallTo: "+14155550100", RoomName: "room", Trunk: &SIPOutboundConfig{
+				Hostname: "bad host",
+			}},
+			exp: false,
+		},
+		{
+			name: "create participant hostname with underscore",
+			req: &CreateSIPParticipantRequest{SipCallTo: "+14155550100", RoomName: "room", Trunk: &SIPOutboundConfig{
+				Hostname: "sip_trunk.example.com",
+			}},
+			exp: true,
+		},
+		{
+			name: "transfer sip",
+			req:  &TransferSIPParticipantRequest{RoomName: "room", ParticipantIdentity: "p", TransferTo: "sip:+14155550100@example.com"},
+			exp:  true,
+		},
+		{
+			name: "transfer tel",
+			req:  &TransferSIPParticipantRequest{RoomName: "room", ParticipantIdentity: "p", TransferTo: "tel:+14155550100"},
+			exp:  true,
+		},
+		{
+			name: "transfer bracketed",
+			req:  &TransferSIPParticipantRequest{RoomName: "room", ParticipantIdentity: "p", TransferTo: "<sip:alice@example.com;transport=tcp>"},
+			exp:  true,
+		},
+		{
+			name: "transfer bad host",
+			req:  &TransferSIPParticipantRequest{RoomName: "room", ParticipantIdentity: "p", TransferTo: "sip:alice@exa mple.com"},
+			exp:  false,
+		},
+		{
+			name: "transfer bad header",
+			req: &TransferSIPParticipantRequest{RoomName: "room", ParticipantIdentity: "p", TransferTo: "tel:+14155550100", Headers: map[string]string{
+				"X-A": "\x00",
+			}},
+			exp: false,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.req.Validate()
+			require.Equal(t, c.exp, err == nil, "error: %v", err)
+		})
+	}
+}
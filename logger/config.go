@@ -14,7 +14,12 @@
 
 package logger
 
-import "sync"
+import (
+	"maps"
+	"sync"
+
+	"github.com/livekit/protocol/logger/zaputil"
+)
 
 type Config struct {
 	JSON  bool   `yaml:"json,omitempty"`
@@ -41,6 +46,10 @@ type Config struct {
 
 	lock               sync.Mutex       `yaml:"-"`
 	onUpdatedCallbacks []ConfigObserver `yaml:"-"`
+
+	loggerStateOnce sync.Once        `yaml:"-"`
+	sharedConfig    *sharedConfig    `yaml:"-"`
+	sampler         *zaputil.Sampler `yaml:"-"`
 }
 
 type ConfigObserver func(*Config) error
@@ -67,6 +76,24 @@ func (c *Config) Update(o *Config) error {
 	return nil
 }
 
+// Clone returns a copy of the config values taken under the config lock, so
+// it is safe to read while other goroutines call Update.
+func (c *Config) Clone() *Config {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return &Config{
+		JSON:               c.JSON,
+		Level:              c.Level,
+		Sample:             c.Sample,
+		ComponentLevels:    maps.Clone(c.ComponentLevels),
+		SampleInitial:      c.SampleInitial,
+		SampleInterval:     c.SampleInterval,
+		ItemSampleSeconds:  c.ItemSampleSeconds,
+		ItemSampleInitial:  c.ItemSampleInitial,
+		ItemSampleInterval: c.ItemSampleInterval,
+	}
+}
+
 func (c *Config) AddUpdateObserver(cb ConfigObserver) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
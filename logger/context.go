@@ -0,0 +1,238 @@
+// Copyright 2025 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logger
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+type loggerContextKey struct{}
+
+type contextLogger struct {
+	base   Logger
+	values []any
+
+	once   sync.Once
+	logger Logger
+}
+
+func (c *contextLogger) get() Logger {
+	c.once.Do(func() {
+		l := c.base
+		if l == nil {
+			l = GetLogger()
+		}
+		if len(c.values) != 0 {
+			l = l.WithValues(c.values...)
+		}
+		c.logger = l
+	})
+	return c.logger
+}
+
+func contextLoggerFrom(ctx context.Context) *contextLogger {
+	c, _ := ctx.Value(loggerContextKey{}).(*contextLogger)
+	return c
+}
+
+// NewContext returns a copy of ctx carrying l. l replaces the logger and
+// values previously added to ctx.
+func NewContext(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, &contextLogger{base: l})
+}
+
+// FromContext returns the logger carried by ctx with the values added by
+// ContextWithValues and the trace_id and span_id of the span in ctx. When ctx
+// has no logger the default logger is used.
+func FromContext(ctx context.Context) Logger {
+	if c := contextLoggerFrom(ctx); c != nil {
+		return withSpanContext(c.get(), ctx)
+	}
+	return withSpanContext(GetLogger(), ctx)
+}
+
+// HasContextLogger returns true if a logger was added to ctx with NewContext.
+func HasContextLogger(ctx context.Context) bool {
+	c := contextLoggerFrom(ctx)
+	return c != nil && c.base != nil
+}
+
+// ContextWithValues returns a copy of ctx whose logger includes keysAndValues.
+// Values replace earlier values added with the same key.
+func ContextWithValues(ctx context.Context, keysAndValues ...any) context.Context {
+	c := &contextLogger{}
+	if prev := contextLoggerFrom(ctx); prev != nil {
+		c.base = prev.base
+		c.values = prev.values
+	}
+	c.values = mergeValues(c.values, keysAndValues)
+	return context.WithValue(ctx, loggerContextKey{}, c)
+}
+
+// ContextValues returns the values added to ctx by ContextWithValues.
+func ContextValues(ctx context.Context) []any {
+	if c := contextLoggerFrom(ctx); c != nil {
+		return slices.Clip(c.values)
+	}
+	return nil
+}
+
+// Lazy returns a logger that calls build the first time it writes a record or derives another logger.
+// Levels are checked with enab until then, so build is never called for disabled records. enab is
+// usually the logger build starts from.
+func Lazy(enab Logger, build func() Logger) Logger {
+	return &lazyLogger{enab: enab, build: build}
+}
+
+type lazyLogger struct {
+	enab  Logger
+	build func() Logger
+
+	once   sync.Once
+	logger Logger
+	caller Logger
+}
+
+func (l *lazyLogger) get() Logger {
+	l.once.Do(func() {
+		l.logger = l.build()
+		l.caller = l.logger.WithCallDepth(1)
+	})
+	return l.logger
+}
+
+func (l *lazyLogger) enabled(lvl zapcore.Level) bool {
+	if e, ok := l.enab.(interface{ enabled(lvl zapcore.Level) bool }); ok {
+		return e.enabled(lvl)
+	}
+	return true
+}
+
+func (l *lazyLogger) Debugw(msg string, keysAndValues ...any) {
+	if l.enabled(zapcore.DebugLevel) {
+		l.get()
+		l.caller.Debugw(msg, keysAndValues...)
+	}
+}
+
+func (l *lazyLogger) Infow(msg string, keysAndValues ...any) {
+	if l.enabled(zapcore.InfoLevel) {
+		l.get()
+		l.caller.Infow(msg, keysAndValues...)
+	}
+}
+
+func (l *lazyLogger) Warnw(msg string, err error, keysAndValues ...any) {
+	if l.enabled(zapcore.WarnLevel) {
+		l.get()
+		l.caller.Warnw(msg, err, keysAndValues...)
+	}
+}
+
+func (l *lazyLogger) Errorw(msg string, err error, keysAndValues ...any) {
+	if l.enabled(zapcore.ErrorLevel) {
+		l.get()
+		l.caller.Errorw(msg, err, keysAndValues...)
+	}
+}
+
+func (l *lazyLogger) WithValues(keysAndValues ...any) Logger {
+	return l.get().WithValues(keysAndValues...)
+}
+
+func (l *lazyLogger) WithUnlikelyValues(keysAndValues ...any) UnlikelyLogger {
+	return l.get().WithUnlikelyValues(keysAndValues...)
+}
+
+func (l *lazyLogger) WithName(name string) Logger {
+	return l.get().WithName(name)
+}
+
+func (l *lazyLogger) WithComponent(component string) Logger {
+	return l.get().WithComponent(component)
+}
+
+func (l *lazyLogger) WithCallDepth(depth int) Logger {
+	return l.get().WithCallDepth(depth)
+}
+
+func (l *lazyLogger) WithItemSampler() Logger {
+	return l.get().WithItemSampler()
+}
+
+func (l *lazyLogger) WithoutSampler() Logger {
+	return l.get().WithoutSampler()
+}
+
+func (l *lazyLogger) WithDeferredValues() (Logger, DeferredFieldResolver) {
+	return l.get().WithDeferredValues()
+}
+
+func mergeValues(values, keysAndValues []any) []any {
+	values = slices.Clone(values)
+next:
+	for i := 1; i < len(keysAndValues); i += 2 {
+		key, ok := keysAndValues[i-1].(string)
+		for j := 1; ok && j < len(values); j += 2 {
+			if values[j-1] == key {
+				values[j] = keysAndValues[i]
+				continue next
+			}
+		}
+		values = append(values, keysAndValues[i-1], keysAndValues[i])
+	}
+	return values
+}
+
+// ContextWithRoom adds the room name to the context logger.
+func ContextWithRoom(ctx context.Context, name livekit.RoomName) context.Context {
+	return ContextWithValues(ctx, "room", string(name))
+}
+
+// ContextWithRoomID adds the room sid to the context logger.
+func ContextWithRoomID(ctx context.Context, id livekit.RoomID) context.Context {
+	return ContextWithValues(ctx, "roomID", string(id))
+}
+
+// ContextWithParticipant adds the participant identity to the context logger.
+func ContextWithParticipant(ctx context.Context, identity livekit.ParticipantIdentity) context.Context {
+	return ContextWithValues(ctx, "participant", string(identity))
+}
+
+// ContextWithParticipantID adds the participant sid to the context logger.
+func ContextWithParticipantID(ctx context.Context, id livekit.ParticipantID) context.Context {
+	return ContextWithValues(ctx, "pID", string(id))
+}
+
+// ContextWithTrackID adds the track sid to the context logger.
+func ContextWithTrackID(ctx context.Context, id livekit.TrackID) context.Context {
+	return ContextWithValues(ctx, "trackID", string(id))
+}
+
+// ContextWithEgressID adds the egress id to the context logger.
+func ContextWithEgressID(ctx context.Context, id string) context.Context {
+	return ContextWithValues(ctx, "egressID", id)
+}
+
+// ContextWithSIPCallID adds the sip call id to the context logger.
+func ContextWithSIPCallID(ctx context.Context, id string) context.Context {
+	return ContextWithValues(ctx, "sipCallID", id)
+}
@@ -0,0 +1,140 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger/zaputil"
+	"github.com/livekit/protocol/utils/must"
+)
+
+func newContextTestLogger() (Logger, *testBufferedWriteSyncer) {
+	ws := &testBufferedWriteSyncer{}
+	return must.Get(NewZapLogger(&Config{}, WithTap(zaputil.NewWriteEnabler(ws, zapcore.DebugLevel)))), ws
+}
+
+func readContextTestLogs(t *testing.T, ws *testBufferedWriteSyncer) []map[string]any {
+	var logs []map[string]any
+	for _, line := range bytes.Split(bytes.TrimSpace(ws.Bytes()), []byte("\n")) {
+		log := map[string]any{}
+		require.NoError(t, json.Unmarshal(line, &log))
+		logs = append(logs, log)
+	}
+	ws.Reset()
+	return logs
+}
+
+func TestContextLogger(t *testing.T) {
+	t.Run("carries logger and fields", func(t *testing.T) {
+		l, ws := newContextTestLogger()
+
+		ctx := NewContext(context.Background(), l.WithValues("service", "test"))
+		ctx = ContextWithRoom(ctx, livekit.RoomName("room1"))
+		ctx = ContextWithRoomID(ctx, livekit.RoomID("RM_1"))
+		ctx = ContextWithParticipant(ctx, livekit.ParticipantIdentity("alice"))
+		ctx = ContextWithParticipantID(ctx, livekit.ParticipantID("PA_1"))
+		ctx = ContextWithTrackID(ctx, livekit.TrackID("TR_1"))
+		ctx = ContextWithEgressID(ctx, "EG_1")
+		ctx = ContextWithSIPCallID(ctx, "SCL_1")
+
+		FromContext(ctx).Infow("test")
+
+		logs := readContextTestLogs(t, ws)
+		require.Len(t, logs, 1)
+		require.Equal(t, "test", logs[0]["service"])
+		require.Equal(t, "room1", logs[0]["room"])
+		require.Equal(t, "RM_1", logs[0]["roomID"])
+		require.Equal(t, "alice", logs[0]["participant"])
+		require.Equal(t, "PA_1", logs[0]["pID"])
+		require.Equal(t, "TR_1", logs[0]["trackID"])
+		require.Equal(t, "EG_1", logs[0]["egressID"])
+		require.Equal(t, "SCL_1", logs[0]["sipCallID"])
+	})
+
+	t.Run("replaces fields with the same key", func(t *testing.T) {
+		l, ws := newContextTestLogger()
+
+		ctx := NewContext(context.Background(), l)
+		parent := ContextWithRoom(ctx, "room1")
+		child := ContextWithRoom(parent, "room2")
+
+		FromContext(parent).Infow("parent")
+		FromContext(child).Infow("child")
+
+		logs := readContextTestLogs(t, ws)
+		require.Len(t, logs, 2)
+		require.Equal(t, "room1", logs[0]["room"])
+		require.Equal(t, "room2", logs[1]["room"])
+		require.Equal(t, []any{"room", "room2"}, ContextValues(child))
+	})
+
+	t.Run("new logger resets fields", func(t *testing.T) {
+		l, ws := newContextTestLogger()
+
+		ctx := ContextWithRoom(NewContext(context.Background(), l), "room1")
+		ctx = NewContext(ctx, l)
+		FromContext(ctx).Infow("test")
+
+		logs := readContextTestLogs(t, ws)
+		require.Len(t, logs, 1)
+		require.NotContains(t, logs[0], "room")
+		require.Empty(t, ContextValues(ctx))
+	})
+
+	t.Run("adds context to component loggers", func(t *testing.T) {
+		l, ws := newContextTestLogger()
+
+		sc := trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID: trace.TraceID{1},
+			SpanID:  trace.SpanID{2},
+		})
+		ctx := trace.ContextWithSpanContext(context.Background(), sc)
+		ctx = ContextWithParticipant(ctx, "alice")
+
+		WithContext(l.WithComponent("psrpc"), ctx).Infow("test")
+
+		logs := readContextTestLogs(t, ws)
+		require.Len(t, logs, 1)
+		require.Equal(t, "alice", logs[0]["participant"])
+		require.Equal(t, sc.TraceID().String(), logs[0]["trace_id"])
+		require.Equal(t, sc.SpanID().String(), logs[0]["span_id"])
+	})
+
+	t.Run("reports context logger", func(t *testing.T) {
+		l, _ := newContextTestLogger()
+
+		require.False(t, HasContextLogger(context.Background()))
+		require.False(t, HasContextLogger(ContextWithRoom(context.Background(), "room1")))
+		require.True(t, HasContextLogger(ContextWithRoom(NewContext(context.Background(), l), "room1")))
+	})
+}
+
+func TestLazyLogger(t *testing.T) {
+	ws := &testBufferedWriteSyncer{}
+	l := must.Get(NewZapLogger(&Config{Level: "info"}, WithTap(zaputil.NewWriteEnabler(ws, zapcore.InfoLevel))))
+
+	var builds int
+	lazy := Lazy(l, func() Logger {
+		builds++
+		return l.WithValues("room", "room1")
+	})
+
+	lazy.Debugw("skipped")
+	require.Zero(t, builds)
+
+	lazy.Infow("first")
+	lazy.Warnw("second", nil)
+	require.Equal(t, 1, builds)
+
+	logs := readContextTestLogs(t, ws)
+	require.Len(t, logs, 2)
+	require.Equal(t, "room1", logs[0]["room"])
+	require.Equal(t, "room1", logs[1]["room"])
+}
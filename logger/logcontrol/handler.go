@@ -0,0 +1,340 @@
+// Copyright 2025 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package logcontrol exposes logger.Config over http so levels and sampling
+// can be changed without restarting. It lives outside of logger because the
+// auth package depends on logger.
+package logcontrol
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/logger"
+)
+
+const authHeader = "Authorization"
+
+var (
+	ErrNoAuthHeader     = errors.New("authorization header could not be found")
+	ErrNoKeyProvider    = errors.New("no key provider configured")
+	ErrSecretNotFound   = errors.New("API secret could not be found")
+	ErrPermissionDenied = errors.New("token does not grant log control")
+)
+
+// Settings is the logger configuration reported by the handler.
+type Settings struct {
+	Level              string            `json:"level"`
+	ComponentLevels    map[string]string `json:"component_levels"`
+	Sample             bool              `json:"sample"`
+	SampleInitial      int               `json:"sample_initial"`
+	SampleInterval     int               `json:"sample_interval"`
+	ItemSampleSeconds  int               `json:"item_sample_seconds"`
+	ItemSampleInitial  int               `json:"item_sample_initial"`
+	ItemSampleInterval int               `json:"item_sample_interval"`
+	// RevertAt is set while a temporary update is in effect.
+	RevertAt *time.Time `json:"revert_at,omitempty"`
+}
+
+// Update is the body of a PUT request. Omitted fields are left unchanged and
+// component levels set to "" are removed.
+type Update struct {
+	Level              *string           `json:"level,omitempty"`
+	ComponentLevels    map[string]string `json:"component_levels,omitempty"`
+	Sample             *bool             `json:"sample,omitempty"`
+	SampleInitial      *int              `json:"sample_initial,omitempty"`
+	SampleInterval     *int              `json:"sample_interval,omitempty"`
+	ItemSampleSeconds  *int              `json:"item_sample_seconds,omitempty"`
+	ItemSampleInitial  *int              `json:"item_sample_initial,omitempty"`
+	ItemSampleInterval *int              `json:"item_sample_interval,omitempty"`
+	// TTL restores the settings in effect before the first of a series of
+	// temporary updates once it expires, e.g. "10m". An update without a TTL
+	// cancels any pending revert.
+	TTL string `json:"ttl,omitempty"`
+}
+
+type HandlerOption func(*Handler)
+
+// WithoutAuth disables authentication, e.g. for handlers only reachable on a
+// local debug port.
+func WithoutAuth() HandlerOption {
+	return func(h *Handler) {
+		h.noAuth = true
+	}
+}
+
+// Handler serves GET and PUT requests for the settings of a logger.Config.
+// Updates are applied with Config.Update so loggers created from the config
+// pick them up immediately. Item sampler settings apply to samplers created
+// after the update.
+type Handler struct {
+	conf   *logger.Config
+	kp     auth.KeyProvider
+	noAuth bool
+
+	mu       sync.Mutex
+	revert   *Settings
+	revertAt time.Time
+	timer    *time.Timer
+	gen      int
+}
+
+// NewHandler creates a handler which requires requests to carry an API token
+// signed with a key from kp in the Authorization header. The token must grant
+// roomAdmin and roomList without roomJoin, so tokens handed to clients are
+// rejected. If kp is nil, all requests are rejected unless WithoutAuth is set.
+func NewHandler(conf *logger.Config, kp auth.KeyProvider, opts ...HandlerOption) *Handler {
+	h := &Handler{conf: conf, kp: kp}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := h.authenticate(r); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.mu.Lock()
+		s := h.settings()
+		h.mu.Unlock()
+		writeSettings(w, s)
+
+	case http.MethodPut:
+		var u Update
+		if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s, err := h.update(u)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeSettings(w, s)
+
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) authenticate(r *http.Request) error {
+	if h.noAuth {
+		return nil
+	}
+	if h.kp == nil {
+		return ErrNoKeyProvider
+	}
+
+	authToken := strings.TrimPrefix(r.Header.Get(authHeader), "Bearer ")
+	if authToken == "" {
+		return ErrNoAuthHeader
+	}
+
+	v, err := auth.ParseAPIToken(authToken)
+	if err != nil {
+		return err
+	}
+
+	secret := h.kp.GetSecret(v.APIKey())
+	if secret == "" {
+		return ErrSecretNotFound
+	}
+
+	claims, err := v.Verify(secret)
+	if err != nil {
+		return err
+	}
+
+	video := claims.Video
+	if video == nil || !video.RoomAdmin || !video.RoomList || video.RoomJoin {
+		return ErrPermissionDenied
+	}
+	return nil
+}
+
+func (h *Handler) update(u Update) (Settings, error) {
+	var ttl time.Duration
+	if u.TTL != "" {
+		var err error
+		if ttl, err = time.ParseDuration(u.TTL); err != nil {
+			return Settings{}, fmt.Errorf("invalid ttl: %w", err)
+		}
+		if ttl <= 0 {
+			return Settings{}, errors.New("ttl must be positive")
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	prev := h.settings()
+	next, err := applyUpdate(prev, u)
+	if err != nil {
+		return Settings{}, err
+	}
+
+	if h.timer != nil {
+		h.timer.Stop()
+		h.timer = nil
+	}
+	h.gen++
+	if ttl != 0 {
+		if h.revert == nil {
+			h.revert = &prev
+		}
+		h.revertAt = time.Now().Add(ttl)
+		gen := h.gen
+		h.timer = time.AfterFunc(ttl, func() { h.revertSettings(gen) })
+	} else {
+		h.revert = nil
+	}
+
+	if err := h.apply(next); err != nil {
+		return Settings{}, err
+	}
+	logger.Infow("log settings updated", "settings", next, "ttl", ttl)
+	return h.settings(), nil
+}
+
+func (h *Handler) revertSettings(gen int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// another update may have replaced the timer while this call waited for the lock
+	if h.gen != gen {
+		return
+	}
+	s := *h.revert
+	h.timer = nil
+	h.revert = nil
+
+	if err := h.apply(s); err != nil {
+		logger.Warnw("failed to revert log settings", err)
+		return
+	}
+	logger.Infow("log settings reverted", "settings", s)
+}
+
+func (h *Handler) settings() Settings {
+	conf := h.conf.Clone()
+	s := Settings{
+		Level:              conf.Level,
+		ComponentLevels:    conf.ComponentLevels,
+		Sample:             conf.Sample,
+		SampleInitial:      conf.SampleInitial,
+		SampleInterval:     conf.SampleInterval,
+		ItemSampleSeconds:  conf.ItemSampleSeconds,
+		ItemSampleInitial:  conf.ItemSampleInitial,
+		ItemSampleInterval: conf.ItemSampleInterval,
+	}
+	if s.ComponentLevels == nil {
+		s.ComponentLevels = map[string]string{}
+	}
+	if h.timer != nil {
+		revertAt := h.revertAt
+		s.RevertAt = &revertAt
+	}
+	return s
+}
+
+func (h *Handler) apply(s Settings) error {
+	return h.conf.Update(&logger.Config{
+		JSON:               h.conf.Clone().JSON,
+		Level:              s.Level,
+		ComponentLevels:    s.ComponentLevels,
+		Sample:             s.Sample,
+		SampleInitial:      s.SampleInitial,
+		SampleInterval:     s.SampleInterval,
+		ItemSampleSeconds:  s.ItemSampleSeconds,
+		ItemSampleInitial:  s.ItemSampleInitial,
+		ItemSampleInterval: s.ItemSampleInterval,
+	})
+}
+
+func applyUpdate(s Settings, u Update) (Settings, error) {
+	s.RevertAt = nil
+	if u.Level != nil {
+		if err := validateLevel(*u.Level); err != nil {
+			return s, err
+		}
+		s.Level = *u.Level
+	}
+	if len(u.ComponentLevels) != 0 {
+		// config updates replace the map, so loggers never observe a partial update
+		levels := maps.Clone(s.ComponentLevels)
+		for component, level := range u.ComponentLevels {
+			if level == "" {
+				delete(levels, component)
+				continue
+			}
+			if err := validateLevel(level); err != nil {
+				return s, fmt.Errorf("component %s: %w", component, err)
+			}
+			levels[component] = level
+		}
+		s.ComponentLevels = levels
+	}
+	if u.Sample != nil {
+		s.Sample = *u.Sample
+	}
+
+	ints := []struct {
+		name string
+		dst  *int
+		src  *int
+	}{
+		{"sample_initial", &s.SampleInitial, u.SampleInitial},
+		{"sample_interval", &s.SampleInterval, u.SampleInterval},
+		{"item_sample_seconds", &s.ItemSampleSeconds, u.ItemSampleSeconds},
+		{"item_sample_initial", &s.ItemSampleInitial, u.ItemSampleInitial},
+		{"item_sample_interval", &s.ItemSampleInterval, u.ItemSampleInterval},
+	}
+	for _, v := range ints {
+		if v.src == nil {
+			continue
+		}
+		if *v.src < 0 {
+			return s, fmt.Errorf("%s must not be negative", v.name)
+		}
+		*v.dst = *v.src
+	}
+	return s, nil
+}
+
+func validateLevel(level string) error {
+	if _, err := zapcore.ParseLevel(level); err != nil {
+		return err
+	}
+	return nil
+}
+
+func writeSettings(w http.ResponseWriter, s Settings) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(s)
+}
@@ -0,0 +1,171 @@
+package logcontrol
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/logger"
+)
+
+func doRequest(t *testing.T, h http.Handler, method, body, token string) (*httptest.ResponseRecorder, Settings) {
+	r := httptest.NewRequest(method, "/debug/log", strings.NewReader(body))
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	var s Settings
+	if w.Code == http.StatusOK {
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &s))
+	}
+	return w, s
+}
+
+func TestHandler(t *testing.T) {
+	t.Run("gets and updates settings", func(t *testing.T) {
+		conf := &logger.Config{
+			Level:           "info",
+			ComponentLevels: map[string]string{"rtc": "warn"},
+		}
+		l, err := logger.NewZapLogger(conf)
+		require.NoError(t, err)
+		webhook := l.WithComponent("webhook").(logger.ZapLogger).ToZap().Desugar().Core()
+		require.False(t, webhook.Enabled(zapcore.DebugLevel))
+
+		h := NewHandler(conf, nil, WithoutAuth())
+
+		w, s := doRequest(t, h, http.MethodGet, "", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "info", s.Level)
+		require.Equal(t, map[string]string{"rtc": "warn"}, s.ComponentLevels)
+		require.Nil(t, s.RevertAt)
+
+		w, s = doRequest(t, h, http.MethodPut, `{"component_levels":{"webhook":"debug","rtc":""},"sample":true,"item_sample_seconds":5}`, "")
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, map[string]string{"webhook": "debug"}, s.ComponentLevels)
+		require.True(t, s.Sample)
+		require.Equal(t, 5, s.ItemSampleSeconds)
+		require.Equal(t, "info", s.Level)
+
+		require.True(t, webhook.Enabled(zapcore.DebugLevel))
+		require.Equal(t, "debug", conf.ComponentLevels["webhook"])
+		require.True(t, conf.Sample)
+	})
+
+	t.Run("rejects invalid settings", func(t *testing.T) {
+		conf := &logger.Config{Level: "info"}
+		h := NewHandler(conf, nil, WithoutAuth())
+
+		for _, body := range []string{
+			`{"level":"verbose"}`,
+			`{"component_levels":{"webhook":"loud"}}`,
+			`{"sample_initial":-1}`,
+			`{"level":"debug","ttl":"soon"}`,
+			`not json`,
+		} {
+			w, _ := doRequest(t, h, http.MethodPut, body, "")
+			require.Equal(t, http.StatusBadRequest, w.Code, body)
+		}
+		require.Equal(t, "info", conf.Level)
+
+		w, _ := doRequest(t, h, http.MethodPost, "", "")
+		require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+
+	t.Run("reverts after ttl", func(t *testing.T) {
+		conf := &logger.Config{Level: "info"}
+		h := NewHandler(conf, nil, WithoutAuth())
+
+		w, s := doRequest(t, h, http.MethodPut, `{"component_levels":{"webhook":"debug"},"ttl":"100ms"}`, "")
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NotNil(t, s.RevertAt)
+
+		// a second temporary update extends the revert and keeps the original settings
+		w, s = doRequest(t, h, http.MethodPut, `{"level":"debug","ttl":"100ms"}`, "")
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "debug", s.Level)
+		require.Equal(t, map[string]string{"webhook": "debug"}, s.ComponentLevels)
+
+		require.Eventually(t, func() bool {
+			_, s := doRequest(t, h, http.MethodGet, "", "")
+			return s.RevertAt == nil
+		}, time.Second, 10*time.Millisecond)
+
+		_, s = doRequest(t, h, http.MethodGet, "", "")
+		require.Equal(t, "info", s.Level)
+		require.Empty(t, s.ComponentLevels)
+	})
+
+	t.Run("permanent update cancels revert", func(t *testing.T) {
+		conf := &logger.Config{Level: "info"}
+		h := NewHandler(conf, nil, WithoutAuth())
+
+		doRequest(t, h, http.MethodPut, `{"level":"debug","ttl":"50ms"}`, "")
+		w, s := doRequest(t, h, http.MethodPut, `{"level":"warn"}`, "")
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Nil(t, s.RevertAt)
+
+		time.Sleep(100 * time.Millisecond)
+		require.Equal(t, "warn", conf.Level)
+	})
+
+	t.Run("authenticates requests", func(t *testing.T) {
+		conf := &logger.Config{Level: "info"}
+		h := NewHandler(conf, auth.NewSimpleKeyProvider("key", "secret"))
+
+		w, _ := doRequest(t, h, http.MethodGet, "", "")
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+
+		adminGrant := &auth.VideoGrant{RoomAdmin: true, RoomList: true}
+
+		// Without a key provider, nothing is accepted.
+		adminToken, err := auth.NewAccessToken("key", "secret").SetVideoGrant(adminGrant).ToJWT()
+		require.NoError(t, err)
+		w, _ = doRequest(t, NewHandler(conf, nil), http.MethodPut, `{"level":"debug"}`, adminToken)
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+		require.Equal(t, "info", conf.Level)
+
+		badToken, err := auth.NewAccessToken("key", "wrong").SetVideoGrant(adminGrant).ToJWT()
+		require.NoError(t, err)
+		w, _ = doRequest(t, h, http.MethodPut, `{"level":"debug"}`, badToken)
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+		require.Equal(t, "info", conf.Level)
+
+		unknownKey, err := auth.NewAccessToken("other", "secret").SetVideoGrant(adminGrant).ToJWT()
+		require.NoError(t, err)
+		w, _ = doRequest(t, h, http.MethodGet, "", unknownKey)
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+
+		joinToken, err := auth.NewAccessToken("key", "secret").
+			SetIdentity("alice").
+			SetVideoGrant(&auth.VideoGrant{RoomJoin: true, Room: "room1"}).
+			ToJWT()
+		require.NoError(t, err)
+		w, _ = doRequest(t, h, http.MethodPut, `{"level":"debug"}`, joinToken)
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+		require.Equal(t, "info", conf.Level)
+
+		moderatorToken, err := auth.NewAccessToken("key", "secret").
+			SetIdentity("bob").
+			SetVideoGrant(&auth.VideoGrant{RoomJoin: true, RoomAdmin: true, RoomList: true, Room: "room1"}).
+			ToJWT()
+		require.NoError(t, err)
+		w, _ = doRequest(t, h, http.MethodGet, "", moderatorToken)
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+
+		token, err := auth.NewAccessToken("key", "secret").SetVideoGrant(adminGrant).ToJWT()
+		require.NoError(t, err)
+		w, s := doRequest(t, h, http.MethodPut, `{"level":"debug"}`, token)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "debug", s.Level)
+	})
+}
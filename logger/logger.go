@@ -15,6 +15,7 @@
 package logger
 
 import (
+	"context"
 	"log/slog"
 	"os"
 	"slices"
@@ -24,6 +25,7 @@ import (
 
 	"github.com/go-logr/logr"
 	"github.com/puzpuzpuz/xsync/v3"
+	otellog "go.opentelemetry.io/otel/log"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 
@@ -202,6 +204,7 @@ type zapConfig struct {
 	writeEnablers *xsync.MapOf[string, *zaputil.WriteEnabler]
 	levelEnablers *xsync.MapOf[string, *zaputil.OrLevelEnabler]
 	tap           *zaputil.WriteEnabler
+	otel          otellog.LoggerProvider
 }
 
 type ZapLoggerOption func(*zapConfig)
@@ -231,6 +234,9 @@ type zapLogger[T zaputil.Encoder[T]] struct {
 	deferred  []*zaputil.Deferrer
 	sampler   *zaputil.Sampler
 	minLevel  zapcore.LevelEnabler
+
+	otelFields []zapcore.Field
+	otelCtx    context.Context
 }
 
 func FromZapLogger(log *zap.Logger, conf *Config, opts ...ZapLoggerOption) (ZapLogger, error) {
@@ -241,7 +247,7 @@ func FromZapLogger(log *zap.Logger, conf *Config, opts ...ZapLoggerOption) (ZapL
 
 	zc := &zapConfig{
 		conf:          conf,
-		sc:            newSharedConfig(conf),
+		sc:            conf.loggerState().sharedConfig,
 		writeEnablers: xsync.NewMapOf[string, *zaputil.WriteEnabler](),
 		levelEnablers: xsync.NewMapOf[string, *zaputil.OrLevelEnabler](),
 		tap:           zaputil.NewDiscardWriteEnabler(),
@@ -250,18 +256,8 @@ func FromZapLogger(log *zap.Logger, conf *Config, opts ...ZapLoggerOption) (ZapL
 		opt(zc)
 	}
 
-	var sampler *zaputil.Sampler
-	if conf.Sample {
-		var initial = 20
-		var interval = 100
-		if conf.ItemSampleInitial != 0 {
-			initial = conf.ItemSampleInitial
-		}
-		if conf.ItemSampleInterval != 0 {
-			interval = conf.ItemSampleInterval
-		}
-		sampler = zaputil.NewSampler(time.Second, initial, interval)
-	}
+	// the global sampler is always installed so sampling can be enabled by config updates
+	sampler := conf.loggerState().sampler
 
 	if conf.JSON {
 		return newZapLogger(zap, zc, zaputil.NewProductionEncoder(), sampler), nil
@@ -270,6 +266,35 @@ func FromZapLogger(log *zap.Logger, conf *Config, opts ...ZapLoggerOption) (ZapL
 	}
 }
 
+// loggerState initializes the levels and sampler shared by every logger built
+// from the config, registering their update observers only once.
+func (c *Config) loggerState() *Config {
+	c.loggerStateOnce.Do(func() {
+		c.sharedConfig = newSharedConfig(c)
+		c.sampler = zaputil.NewSampler(globalSamplerParams(c))
+		c.AddUpdateObserver(func(conf *Config) error {
+			c.sampler.Update(globalSamplerParams(conf))
+			return nil
+		})
+	})
+	return c
+}
+
+func globalSamplerParams(conf *Config) (tick time.Duration, initial, interval int) {
+	if !conf.Sample {
+		return 0, 0, 0
+	}
+	initial = 20
+	interval = 100
+	if conf.ItemSampleInitial != 0 {
+		initial = conf.ItemSampleInitial
+	}
+	if conf.ItemSampleInterval != 0 {
+		interval = conf.ItemSampleInterval
+	}
+	return time.Second, initial, interval
+}
+
 func NewZapLogger(conf *Config, opts ...ZapLoggerOption) (ZapLogger, error) {
 	return FromZapLogger(nil, conf, opts...)
 }
@@ -297,6 +322,9 @@ func (l *zapLogger[T]) makeZap() *zap.SugaredLogger {
 	}
 
 	c := l.enc.Core(console, l.tap)
+	if l.otel != nil {
+		c = zapcore.NewTee(c, l.makeOTelCore(console.LevelEnabler))
+	}
 	for i := range l.deferred {
 		c = zaputil.NewDeferredValueCore(c, l.deferred[i])
 	}
@@ -362,6 +390,9 @@ func (l *zapLogger[T]) Errorw(msg string, err error, keysAndValues ...any) {
 func (l *zapLogger[T]) WithValues(keysAndValues ...any) Logger {
 	dup := *l
 	dup.enc = dup.enc.WithValues(keysAndValues...)
+	if dup.otel != nil {
+		dup.otelFields = appendOTelFields(dup.otelFields, keysAndValues...)
+	}
 	dup.zap = dup.makeZap()
 	return &dup
 }
@@ -388,6 +419,10 @@ func (l *zapLogger[T]) WithComponent(component string) Logger {
 	return &dup
 }
 
+func (l *zapLogger[T]) enabled(lvl zapcore.Level) bool {
+	return l.zap.Level().Enabled(lvl)
+}
+
 func (l *zapLogger[T]) WithCallDepth(depth int) Logger {
 	dup := *l
 	dup.zap = dup.zap.WithOptions(zap.AddCallerSkip(depth))
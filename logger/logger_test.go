@@ -83,6 +83,32 @@ func TestLoggerComponent(t *testing.T) {
 		require.True(t, sub2.Enabled(zapcore.InfoLevel))
 	})
 
+	t.Run("updates sampling dynamically", func(t *testing.T) {
+		config := &Config{Level: "info"}
+		ws := &testBufferedWriteSyncer{}
+		l, err := NewZapLogger(config, WithTap(zaputil.NewWriteEnabler(ws, zapcore.InfoLevel)))
+		require.NoError(t, err)
+
+		for range 10 {
+			l.Infow("sampled")
+		}
+		require.Equal(t, 10, bytes.Count(ws.Bytes(), []byte("\n")))
+
+		ws.Reset()
+		err = config.Update(&Config{
+			Level:              "info",
+			Sample:             true,
+			ItemSampleInitial:  2,
+			ItemSampleInterval: 1000,
+		})
+		require.NoError(t, err)
+
+		for range 10 {
+			l.Infow("sampled")
+		}
+		require.Equal(t, 2, bytes.Count(ws.Bytes(), []byte("\n")))
+	})
+
 	t.Run("log output matches expected values", func(t *testing.T) {
 		ws := &testBufferedWriteSyncer{}
 		l, err := NewZapLogger(&Config{}, WithTap(zaputil.NewWriteEnabler(ws, zapcore.DebugLevel)))
@@ -171,6 +197,9 @@ func TestLoggerCallDepth(t *testing.T) {
 		"WithUnlikelyValues": func(l Logger) logFunc {
 			return l.WithUnlikelyValues().Debugw
 		},
+		"Lazy": func(l Logger) logFunc {
+			return Lazy(l, func() Logger { return l.WithValues("lazy", true) }).Debugw
+		},
 	}
 	for label, getLogFunc := range cases {
 		t.Run(label, func(t *testing.T) {
@@ -184,3 +213,30 @@ func TestLoggerCallDepth(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigClone(t *testing.T) {
+	conf := &Config{
+		Level:           "info",
+		Sample:          true,
+		ComponentLevels: map[string]string{"sub": "debug"},
+	}
+	clone := conf.Clone()
+	require.Equal(t, "info", clone.Level)
+	require.True(t, clone.Sample)
+
+	clone.ComponentLevels["sub"] = "error"
+	require.Equal(t, "debug", conf.ComponentLevels["sub"])
+}
+
+func TestConfigObserversRegisteredOnce(t *testing.T) {
+	conf := &Config{Level: "info"}
+	_, err := NewZapLogger(conf)
+	require.NoError(t, err)
+	observers := len(conf.onUpdatedCallbacks)
+
+	for range 10 {
+		_, err := NewZapLogger(conf)
+		require.NoError(t, err)
+	}
+	require.Equal(t, observers, len(conf.onUpdatedCallbacks))
+}
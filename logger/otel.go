@@ -0,0 +1,89 @@
+// Copyright 2025 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logger
+
+import (
+	"context"
+	"slices"
+
+	otellog "go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/livekit/protocol/logger/zaputil"
+)
+
+// OTelScopeName is the instrumentation scope of records logged outside of a component.
+const OTelScopeName = "github.com/livekit/protocol/logger"
+
+// WithOTelLoggerProvider exports records to provider as OpenTelemetry logs in
+// addition to the console output. Components are reported as instrumentation
+// scopes and keep the levels set in Config.ComponentLevels.
+func WithOTelLoggerProvider(provider otellog.LoggerProvider) ZapLoggerOption {
+	return func(zc *zapConfig) {
+		zc.otel = provider
+	}
+}
+
+// WithContext returns a logger that includes the values added to ctx with
+// ContextWithValues and attaches the trace_id and span_id of the span in ctx
+// to every record.
+func WithContext(l Logger, ctx context.Context) Logger {
+	if values := ContextValues(ctx); len(values) != 0 {
+		l = l.WithValues(values...)
+	}
+	return withSpanContext(l, ctx)
+}
+
+func withSpanContext(l Logger, ctx context.Context) Logger {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return l
+	}
+	if sl, ok := l.(interface {
+		withSpanContext(sc trace.SpanContext) Logger
+	}); ok {
+		return sl.withSpanContext(sc)
+	}
+	return l.WithValues("trace_id", sc.TraceID().String(), "span_id", sc.SpanID().String())
+}
+
+func (l *zapLogger[T]) withSpanContext(sc trace.SpanContext) Logger {
+	dup := *l
+	// the otel sdk reads the ids from the record context, so only the encoders need the values
+	dup.enc = dup.enc.WithValues("trace_id", sc.TraceID().String(), "span_id", sc.SpanID().String())
+	dup.otelCtx = trace.ContextWithSpanContext(context.Background(), sc)
+	dup.zap = dup.makeZap()
+	return &dup
+}
+
+func (l *zapLogger[T]) makeOTelCore(enab zapcore.LevelEnabler) zapcore.Core {
+	scope := l.component
+	if scope == "" {
+		scope = OTelScopeName
+	}
+	return zaputil.NewOTelCore(l.otel.Logger(scope), enab, l.otelCtx).With(l.otelFields)
+}
+
+func appendOTelFields(fields []zapcore.Field, keysAndValues ...any) []zapcore.Field {
+	fields = slices.Clip(fields)
+	for i := 1; i < len(keysAndValues); i += 2 {
+		if key, ok := keysAndValues[i-1].(string); ok {
+			fields = append(fields, zap.Any(key, keysAndValues[i]))
+		}
+	}
+	return fields
+}
@@ -0,0 +1,121 @@
+package logger
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	otellog "go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type testLogProcessor struct {
+	mu      sync.Mutex
+	records []sdklog.Record
+}
+
+func (p *testLogProcessor) OnEmit(ctx context.Context, r *sdklog.Record) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.records = append(p.records, r.Clone())
+	return nil
+}
+
+func (p *testLogProcessor) Shutdown(ctx context.Context) error   { return nil }
+func (p *testLogProcessor) ForceFlush(ctx context.Context) error { return nil }
+
+func (p *testLogProcessor) Records() []sdklog.Record {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Clone(p.records)
+}
+
+func recordAttributes(r sdklog.Record) map[string]otellog.Value {
+	attrs := map[string]otellog.Value{}
+	r.WalkAttributes(func(kv otellog.KeyValue) bool {
+		attrs[kv.Key] = kv.Value
+		return true
+	})
+	return attrs
+}
+
+func newOTelTestLogger(t *testing.T, conf *Config) (Logger, *testLogProcessor) {
+	p := &testLogProcessor{}
+	provider := sdklog.NewLoggerProvider(sdklog.WithProcessor(p))
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+
+	l, err := NewZapLogger(conf, WithOTelLoggerProvider(provider))
+	require.NoError(t, err)
+	return l, p
+}
+
+func TestOTelBridge(t *testing.T) {
+	t.Run("exports records", func(t *testing.T) {
+		l, p := newOTelTestLogger(t, &Config{Level: "info"})
+
+		l.WithValues("room", "test-room").Warnw("something happened", nil, "count", 3)
+		l.Debugw("filtered")
+
+		records := p.Records()
+		require.Len(t, records, 1)
+		r := records[0]
+		require.Equal(t, "something happened", r.Body().AsString())
+		require.Equal(t, otellog.SeverityWarn, r.Severity())
+		require.Equal(t, "warn", r.SeverityText())
+		require.Equal(t, OTelScopeName, r.InstrumentationScope().Name)
+
+		attrs := recordAttributes(r)
+		require.Equal(t, "test-room", attrs["room"].AsString())
+		require.Equal(t, int64(3), attrs["count"].AsInt64())
+	})
+
+	t.Run("maps components to scopes", func(t *testing.T) {
+		conf := &Config{
+			Level: "info",
+			ComponentLevels: map[string]string{
+				"sub":   "debug",
+				"quiet": "error",
+			},
+		}
+		l, p := newOTelTestLogger(t, conf)
+
+		l.WithComponent("sub").WithComponent("level2").Debugw("debug message")
+		l.WithComponent("quiet").Warnw("dropped", nil)
+
+		records := p.Records()
+		require.Len(t, records, 1)
+		require.Equal(t, "sub.level2", records[0].InstrumentationScope().Name)
+		require.Equal(t, "debug message", records[0].Body().AsString())
+
+		require.NoError(t, conf.Update(&Config{Level: "info"}))
+		l.WithComponent("quiet").Warnw("emitted", nil)
+
+		records = p.Records()
+		require.Len(t, records, 2)
+		require.Equal(t, "quiet", records[1].InstrumentationScope().Name)
+	})
+
+	t.Run("attaches span context", func(t *testing.T) {
+		l, p := newOTelTestLogger(t, &Config{Level: "info"})
+
+		sc := trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    trace.TraceID{1, 2, 3},
+			SpanID:     trace.SpanID{4, 5, 6},
+			TraceFlags: trace.FlagsSampled,
+		})
+		ctx := trace.ContextWithSpanContext(context.Background(), sc)
+
+		WithContext(l, ctx).WithComponent("sub").Infow("traced")
+		WithContext(l, context.Background()).Infow("untraced")
+
+		records := p.Records()
+		require.Len(t, records, 2)
+		require.Equal(t, sc.TraceID(), records[0].TraceID())
+		require.Equal(t, sc.SpanID(), records[0].SpanID())
+		require.NotContains(t, recordAttributes(records[0]), "trace_id")
+		require.False(t, records[1].TraceID().IsValid())
+	})
+}
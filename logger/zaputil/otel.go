@@ -0,0 +1,198 @@
+// Copyright 2025 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zaputil
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	otellog "go.opentelemetry.io/otel/log"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/exp/maps"
+)
+
+// NewOTelCore returns a core that emits entries as OpenTelemetry log records.
+// Records are emitted with ctx so the sdk can attach the span context it carries.
+func NewOTelCore(logger otellog.Logger, enab zapcore.LevelEnabler, ctx context.Context) zapcore.Core {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return &otelCore{
+		LevelEnabler: enab,
+		logger:       logger,
+		ctx:          ctx,
+	}
+}
+
+type otelCore struct {
+	zapcore.LevelEnabler
+	logger otellog.Logger
+	ctx    context.Context
+	attrs  []otellog.KeyValue
+}
+
+func (c *otelCore) Level() zapcore.Level {
+	return zapcore.LevelOf(c.LevelEnabler)
+}
+
+func (c *otelCore) With(fields []zapcore.Field) zapcore.Core {
+	if len(fields) == 0 {
+		return c
+	}
+	dup := *c
+	dup.attrs = append(slices.Clip(dup.attrs), OTelAttributes(fields)...)
+	return &dup
+}
+
+func (c *otelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *otelCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	var r otellog.Record
+	r.SetTimestamp(ent.Time)
+	r.SetObservedTimestamp(time.Now())
+	r.SetBody(otellog.StringValue(ent.Message))
+	r.SetSeverity(OTelSeverity(ent.Level))
+	r.SetSeverityText(ent.Level.String())
+
+	r.AddAttributes(c.attrs...)
+	r.AddAttributes(OTelAttributes(fields)...)
+	if ent.LoggerName != "" {
+		r.AddAttributes(otellog.String("logger", ent.LoggerName))
+	}
+	if ent.Caller.Defined {
+		r.AddAttributes(otellog.String("caller", ent.Caller.TrimmedPath()))
+	}
+	if ent.Stack != "" {
+		r.AddAttributes(otellog.String("stacktrace", ent.Stack))
+	}
+
+	c.logger.Emit(c.ctx, r)
+	return nil
+}
+
+func (c *otelCore) Sync() error {
+	return nil
+}
+
+// OTelSeverity maps a zap level to the matching OpenTelemetry severity.
+func OTelSeverity(lvl zapcore.Level) otellog.Severity {
+	switch lvl {
+	case zapcore.DebugLevel:
+		return otellog.SeverityDebug
+	case zapcore.InfoLevel:
+		return otellog.SeverityInfo
+	case zapcore.WarnLevel:
+		return otellog.SeverityWarn
+	case zapcore.ErrorLevel:
+		return otellog.SeverityError
+	case zapcore.DPanicLevel:
+		return otellog.SeverityFatal1
+	case zapcore.PanicLevel:
+		return otellog.SeverityFatal2
+	case zapcore.FatalLevel:
+		return otellog.SeverityFatal3
+	default:
+		if lvl < zapcore.DebugLevel {
+			return otellog.SeverityTrace
+		}
+		return otellog.SeverityUndefined
+	}
+}
+
+// OTelAttributes converts zap fields to OpenTelemetry attributes using the
+// values zap would encode, so marshalers and stringers are respected.
+func OTelAttributes(fields []zapcore.Field) []otellog.KeyValue {
+	if len(fields) == 0 {
+		return nil
+	}
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	keys := maps.Keys(enc.Fields)
+	slices.Sort(keys)
+
+	attrs := make([]otellog.KeyValue, 0, len(keys))
+	for _, k := range keys {
+		attrs = append(attrs, otellog.KeyValue{Key: k, Value: otelValue(enc.Fields[k])})
+	}
+	return attrs
+}
+
+func otelValue(v any) otellog.Value {
+	switch v := v.(type) {
+	case nil:
+		return otellog.Value{}
+	case string:
+		return otellog.StringValue(v)
+	case bool:
+		return otellog.BoolValue(v)
+	case int:
+		return otellog.IntValue(v)
+	case int8:
+		return otellog.Int64Value(int64(v))
+	case int16:
+		return otellog.Int64Value(int64(v))
+	case int32:
+		return otellog.Int64Value(int64(v))
+	case int64:
+		return otellog.Int64Value(v)
+	case uint:
+		return otellog.Int64Value(int64(v))
+	case uint8:
+		return otellog.Int64Value(int64(v))
+	case uint16:
+		return otellog.Int64Value(int64(v))
+	case uint32:
+		return otellog.Int64Value(int64(v))
+	case uint64:
+		return otellog.Int64Value(int64(v))
+	case uintptr:
+		return otellog.Int64Value(int64(v))
+	case float32:
+		return otellog.Float64Value(float64(v))
+	case float64:
+		return otellog.Float64Value(v)
+	case []byte:
+		return otellog.BytesValue(v)
+	case time.Duration:
+		return otellog.StringValue(v.String())
+	case time.Time:
+		return otellog.StringValue(v.Format(time.RFC3339Nano))
+	case []any:
+		vs := make([]otellog.Value, 0, len(v))
+		for _, e := range v {
+			vs = append(vs, otelValue(e))
+		}
+		return otellog.SliceValue(vs...)
+	case map[string]any:
+		keys := maps.Keys(v)
+		slices.Sort(keys)
+		kvs := make([]otellog.KeyValue, 0, len(v))
+		for _, k := range keys {
+			kvs = append(kvs, otellog.KeyValue{Key: k, Value: otelValue(v[k])})
+		}
+		return otellog.MapValue(kvs...)
+	default:
+		return otellog.StringValue(fmt.Sprint(v))
+	}
+}
@@ -76,19 +76,32 @@ func (c *counter) IncCheckReset(t time.Time, tick time.Duration) uint64 {
 	return 1
 }
 
-type Sampler struct {
-	counts            *counters
+type samplerConfig struct {
 	tick              time.Duration
 	first, thereafter uint64
 }
 
+type Sampler struct {
+	counts *counters
+	config atomic.Pointer[samplerConfig]
+}
+
 func NewSampler(tick time.Duration, first, thereafter int) *Sampler {
-	return &Sampler{
+	s := &Sampler{
+		counts: newCounters(),
+	}
+	s.Update(tick, first, thereafter)
+	return s
+}
+
+// Update replaces the sampling parameters without resetting the counts. A zero
+// tick disables sampling.
+func (s *Sampler) Update(tick time.Duration, first, thereafter int) {
+	s.config.Store(&samplerConfig{
 		tick:       tick,
-		counts:     newCounters(),
 		first:      uint64(first),
 		thereafter: uint64(thereafter),
-	}
+	})
 }
 
 func NewSamplerCore(core zapcore.Core, s *Sampler) zapcore.Core {
@@ -119,10 +132,10 @@ func (s *sampler) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.Ch
 		return ce
 	}
 
-	if ent.Level >= minLevel && ent.Level <= maxLevel {
+	if c := s.s.config.Load(); c.tick != 0 && ent.Level >= minLevel && ent.Level <= maxLevel {
 		counter := s.s.counts.get(ent.Level, ent.Message)
-		n := counter.IncCheckReset(ent.Time, s.s.tick)
-		if n > s.s.first && (s.s.thereafter == 0 || (n-s.s.first)%s.s.thereafter != 0) {
+		n := counter.IncCheckReset(ent.Time, c.tick)
+		if n > c.first && (c.thereafter == 0 || (n-c.first)%c.thereafter != 0) {
 			return ce
 		}
 	}
@@ -0,0 +1,242 @@
+// Copyright 2025 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/puzpuzpuz/xsync/v3"
+	"go.uber.org/atomic"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/livekit/protocol/utils"
+	"github.com/livekit/psrpc"
+	"github.com/livekit/psrpc/pkg/metadata"
+)
+
+// PriorityMetadataKey is the request metadata key carrying the RequestPriority.
+const PriorityMetadataKey = "lk-priority"
+
+type RequestPriority string
+
+const (
+	PriorityLow    RequestPriority = "low"
+	PriorityNormal RequestPriority = "normal"
+	PriorityHigh   RequestPriority = "high"
+)
+
+// WithRequestPriority sets the priority of requests sent with the context. Requests without a priority are normal.
+func WithRequestPriority(ctx context.Context, priority RequestPriority) context.Context {
+	return metadata.AppendMetadataToOutgoingContext(ctx, PriorityMetadataKey, string(priority))
+}
+
+// IncomingRequestPriority returns the priority of the request being handled.
+func IncomingRequestPriority(ctx context.Context) RequestPriority {
+	if head := metadata.IncomingHeader(ctx); head != nil {
+		switch p := RequestPriority(head.Metadata[PriorityMetadataKey]); p {
+		case PriorityLow, PriorityHigh:
+			return p
+		}
+	}
+	return PriorityNormal
+}
+
+// MethodLimits limit requests to a method.
+type MethodLimits struct {
+	// MaxConcurrent requests being handled. Zero means no limit.
+	MaxConcurrent int `yaml:"max_concurrent,omitempty"`
+	// RateLimit in requests per second. Zero means no limit.
+	RateLimit int `yaml:"rate_limit,omitempty"`
+	// Burst of requests allowed above the rate after a period of inactivity.
+	Burst int `yaml:"burst,omitempty"`
+	// HighRateLimit in requests per second applies to high priority requests instead of RateLimit,
+	// so they are not affected by the load from other requests. Since any client may set the priority,
+	// high priority requests share RateLimit with other requests if it is zero.
+	HighRateLimit int `yaml:"high_rate_limit,omitempty"`
+	// HighBurst is the Burst of high priority requests.
+	HighBurst int `yaml:"high_burst,omitempty"`
+}
+
+// CPUShedding sets CPU load thresholds, from 0 to 1, above which requests are rejected. Zero disables shedding
+// for low and normal priorities. Since any client may set the priority, high priority requests use the normal
+// threshold if High is zero. Set High to 1 to never shed them.
+type CPUShedding struct {
+	Low    float64 `yaml:"low,omitempty"`
+	Normal float64 `yaml:"normal,omitempty"`
+	High   float64 `yaml:"high,omitempty"`
+}
+
+// AdmissionConfig controls which requests a server accepts.
+type AdmissionConfig struct {
+	// Methods maps "Service.Method" or "Service" keys, like in PSRPCPolicies, to limits.
+	// Limits of a service key apply to each of its methods separately.
+	Methods map[string]MethodLimits `yaml:"methods,omitempty"`
+	// CPUShedding requires a CPU load source, see WithAdmissionCPULoad.
+	CPUShedding CPUShedding `yaml:"cpu_shedding,omitempty"`
+	// RetryAfter is suggested to clients rejected due to concurrency or CPU load. Defaults to one second.
+	RetryAfter time.Duration `yaml:"retry_after,omitempty"`
+}
+
+func (c *AdmissionConfig) lookup(service, method string) (MethodLimits, bool) {
+	if l, ok := c.Methods[service+"."+method]; ok {
+		return l, true
+	}
+	l, ok := c.Methods[service]
+	return l, ok
+}
+
+// CPULoad is implemented by hwstats.CPUStats.
+type CPULoad interface {
+	GetCPULoad() float64
+}
+
+type AdmissionOption func(*admissionController)
+
+// WithAdmissionCPULoad enables CPU shedding, using a source like hwstats.CPUStats.
+func WithAdmissionCPULoad(cpu CPULoad) AdmissionOption {
+	return func(c *admissionController) {
+		c.cpu = cpu
+	}
+}
+
+func WithAdmissionClock(clock utils.Clock) AdmissionOption {
+	return func(c *admissionController) {
+		c.clock = clock
+	}
+}
+
+// WithServerAdmission rejects requests over the configured limits with psrpc.ResourceExhausted errors.
+// The errors carry a RetryInfo detail, see RetryAfter.
+func WithServerAdmission(config AdmissionConfig, opts ...AdmissionOption) psrpc.ServerOption {
+	return psrpc.WithServerRPCInterceptors(newAdmissionInterceptor(config, opts...))
+}
+
+type admissionController struct {
+	config  AdmissionConfig
+	cpu     CPULoad
+	clock   utils.Clock
+	methods *xsync.MapOf[string, *methodAdmission]
+}
+
+type methodAdmission struct {
+	limits     MethodLimits
+	limited    bool
+	active     atomic.Int32
+	bucket     *utils.LeakyBucket
+	highBucket *utils.LeakyBucket
+}
+
+func newAdmissionInterceptor(config AdmissionConfig, opts ...AdmissionOption) psrpc.ServerRPCInterceptor {
+	if config.RetryAfter <= 0 {
+		config.RetryAfter = time.Second
+	}
+	c := &admissionController{
+		config:  config,
+		clock:   utils.SystemClock{},
+		methods: xsync.NewMapOf[string, *methodAdmission](),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c.intercept
+}
+
+func (c *admissionController) getMethod(info psrpc.RPCInfo) *methodAdmission {
+	m, _ := c.methods.LoadOrCompute(info.Service+"."+info.Method, func() *methodAdmission {
+		limits, ok := c.config.lookup(info.Service, info.Method)
+		m := &methodAdmission{limits: limits, limited: ok}
+		if limits.RateLimit > 0 {
+			m.bucket = utils.NewLeakyBucket(limits.RateLimit, limits.Burst, c.clock)
+		}
+		if limits.HighRateLimit > 0 {
+			m.highBucket = utils.NewLeakyBucket(limits.HighRateLimit, limits.HighBurst, c.clock)
+		}
+		return m
+	})
+	return m
+}
+
+func (c *admissionController) intercept(ctx context.Context, req proto.Message, info psrpc.RPCInfo, handler psrpc.ServerRPCHandler) (proto.Message, error) {
+	priority := IncomingRequestPriority(ctx)
+
+	if c.cpu != nil {
+		threshold := c.config.CPUShedding.Normal
+		switch priority {
+		case PriorityLow:
+			threshold = c.config.CPUShedding.Low
+		case PriorityHigh:
+			if c.config.CPUShedding.High > 0 {
+				threshold = c.config.CPUShedding.High
+			}
+		}
+		if load := c.cpu.GetCPULoad(); threshold > 0 && load > threshold {
+			return nil, c.reject(info, "cpu", c.config.RetryAfter, "cpu load %.2f is over %.2f", load, threshold)
+		}
+	}
+
+	m := c.getMethod(info)
+	if !m.limited {
+		return handler(ctx, req)
+	}
+
+	if m.limits.MaxConcurrent > 0 {
+		if n := m.active.Inc(); n > int32(m.limits.MaxConcurrent) {
+			m.active.Dec()
+			return nil, c.reject(info, "concurrency", c.config.RetryAfter, "too many concurrent requests")
+		}
+		defer m.active.Dec()
+	}
+
+	bucket := m.bucket
+	if priority == PriorityHigh && m.highBucket != nil {
+		bucket = m.highBucket
+	}
+	if bucket != nil {
+		if wait, ok := bucket.TryTake(); !ok {
+			return nil, c.reject(info, "rate", wait, "rate limit exceeded")
+		}
+	}
+
+	return handler(ctx, req)
+}
+
+func (c *admissionController) reject(info psrpc.RPCInfo, reason string, retryAfter time.Duration, msg string, args ...interface{}) error {
+	if m := metrics.Load(); m != nil {
+		m.admissionRejectedTotal.WithLabelValues(info.Service, info.Method, reason).Inc()
+	}
+	return psrpc.NewError(psrpc.ResourceExhausted, fmt.Errorf(msg, args...), &errdetails.RetryInfo{
+		RetryDelay: durationpb.New(retryAfter),
+	})
+}
+
+// RetryAfter returns the delay suggested by the server in a RetryInfo error detail.
+func RetryAfter(err error) (time.Duration, bool) {
+	var e psrpc.Error
+	if !errors.As(err, &e) {
+		return 0, false
+	}
+	for _, a := range e.DetailsProto() {
+		var info errdetails.RetryInfo
+		if a.MessageIs(&info) && a.UnmarshalTo(&info) == nil && info.RetryDelay != nil {
+			return info.RetryDelay.AsDuration(), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,188 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/utils"
+	"github.com/livekit/protocol/utils/hwstats"
+	"github.com/livekit/psrpc"
+	"github.com/livekit/psrpc/pkg/middleware"
+)
+
+var _ CPULoad = (*hwstats.CPUStats)(nil)
+
+type fixedCPULoad float64
+
+func (l fixedCPULoad) GetCPULoad() float64 {
+	return float64(l)
+}
+
+type blockingRoomManager struct {
+	started chan RequestPriority
+	release chan struct{}
+}
+
+func (s *blockingRoomManager) CreateRoom(ctx context.Context, req *livekit.CreateRoomRequest) (*livekit.Room, error) {
+	if s.started != nil {
+		s.started <- IncomingRequestPriority(ctx)
+	}
+	if s.release != nil {
+		<-s.release
+	}
+	return &livekit.Room{Name: req.Name}, nil
+}
+
+func newAdmissionTestClient(t *testing.T, svc RoomManagerServerImpl, config AdmissionConfig, opts ...AdmissionOption) RoomManagerClient[string] {
+	bus := psrpc.NewLocalMessageBus()
+	server, err := NewRoomManagerServer[string](svc, bus, WithServerAdmission(config, opts...))
+	require.NoError(t, err)
+	require.NoError(t, server.RegisterCreateRoomTopic("node"))
+	t.Cleanup(server.Kill)
+
+	client, err := NewRoomManagerClient[string](bus)
+	require.NoError(t, err)
+	t.Cleanup(client.Close)
+	return client
+}
+
+func requireRejected(t *testing.T, err error, retryAfter time.Duration) {
+	t.Helper()
+	var e psrpc.Error
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, psrpc.ResourceExhausted, e.Code())
+	d, ok := RetryAfter(err)
+	require.True(t, ok)
+	require.Equal(t, retryAfter, d)
+}
+
+func TestAdmissionConcurrency(t *testing.T) {
+	svc := &blockingRoomManager{started: make(chan RequestPriority, 1), release: make(chan struct{})}
+	client := newAdmissionTestClient(t, svc, AdmissionConfig{
+		Methods: map[string]MethodLimits{"RoomManager": {MaxConcurrent: 1}},
+	})
+	req := &livekit.CreateRoomRequest{Name: "room"}
+
+	done := make(chan error)
+	go func() {
+		_, err := client.CreateRoom(context.Background(), "node", req)
+		done <- err
+	}()
+	require.Equal(t, PriorityNormal, <-svc.started)
+
+	_, err := client.CreateRoom(context.Background(), "node", req)
+	requireRejected(t, err, time.Second)
+
+	close(svc.release)
+	require.NoError(t, <-done)
+	svc.started = nil
+	_, err = client.CreateRoom(context.Background(), "node", req)
+	require.NoError(t, err)
+}
+
+func TestAdmissionRateLimit(t *testing.T) {
+	clock := &utils.SimulatedClock{}
+	clock.Set(time.Now())
+	client := newAdmissionTestClient(t, &blockingRoomManager{}, AdmissionConfig{
+		Methods: map[string]MethodLimits{"RoomManager.CreateRoom": {RateLimit: 2}},
+	}, WithAdmissionClock(clock))
+	req := &livekit.CreateRoomRequest{Name: "room"}
+
+	_, err := client.CreateRoom(context.Background(), "node", req)
+	require.NoError(t, err)
+	_, err = client.CreateRoom(context.Background(), "node", req)
+	requireRejected(t, err, 500*time.Millisecond)
+
+	// High priority requests share the limit unless they have their own.
+	_, err = client.CreateRoom(WithRequestPriority(context.Background(), PriorityHigh), "node", req)
+	requireRejected(t, err, 500*time.Millisecond)
+
+	clock.Add(500 * time.Millisecond)
+	_, err = client.CreateRoom(context.Background(), "node", req)
+	require.NoError(t, err)
+}
+
+func TestAdmissionHighRateLimit(t *testing.T) {
+	clock := &utils.SimulatedClock{}
+	clock.Set(time.Now())
+	client := newAdmissionTestClient(t, &blockingRoomManager{}, AdmissionConfig{
+		Methods: map[string]MethodLimits{"RoomManager.CreateRoom": {RateLimit: 2, HighRateLimit: 4}},
+	}, WithAdmissionClock(clock))
+	req := &livekit.CreateRoomRequest{Name: "room"}
+	high := WithRequestPriority(context.Background(), PriorityHigh)
+
+	_, err := client.CreateRoom(context.Background(), "node", req)
+	require.NoError(t, err)
+	_, err = client.CreateRoom(context.Background(), "node", req)
+	requireRejected(t, err, 500*time.Millisecond)
+
+	// High priority requests are limited separately.
+	_, err = client.CreateRoom(high, "node", req)
+	require.NoError(t, err)
+	_, err = client.CreateRoom(high, "node", req)
+	requireRejected(t, err, 250*time.Millisecond)
+}
+
+func TestAdmissionCPUShedding(t *testing.T) {
+	svc := &blockingRoomManager{started: make(chan RequestPriority, 1)}
+	client := newAdmissionTestClient(t, svc, AdmissionConfig{
+		CPUShedding: CPUShedding{Low: 0.7, Normal: 0.9},
+		RetryAfter:  2 * time.Second,
+	}, WithAdmissionCPULoad(fixedCPULoad(0.8)))
+	req := &livekit.CreateRoomRequest{Name: "room"}
+
+	_, err := client.CreateRoom(WithRequestPriority(context.Background(), PriorityLow), "node", req)
+	requireRejected(t, err, 2*time.Second)
+
+	_, err = client.CreateRoom(context.Background(), "node", req)
+	require.NoError(t, err)
+	require.Equal(t, PriorityNormal, <-svc.started)
+
+	_, err = client.CreateRoom(WithRequestPriority(context.Background(), PriorityHigh), "node", req)
+	require.NoError(t, err)
+	require.Equal(t, PriorityHigh, <-svc.started)
+
+	// High priority requests are shed at the normal threshold by default.
+	client = newAdmissionTestClient(t, svc, AdmissionConfig{
+		CPUShedding: CPUShedding{Normal: 0.7},
+	}, WithAdmissionCPULoad(fixedCPULoad(0.8)))
+	_, err = client.CreateRoom(WithRequestPriority(context.Background(), PriorityHigh), "node", req)
+	requireRejected(t, err, time.Second)
+
+	client = newAdmissionTestClient(t, svc, AdmissionConfig{
+		CPUShedding: CPUShedding{Normal: 0.7, High: 0.9},
+	}, WithAdmissionCPULoad(fixedCPULoad(0.8)))
+	_, err = client.CreateRoom(WithRequestPriority(context.Background(), PriorityHigh), "node", req)
+	require.NoError(t, err)
+	require.Equal(t, PriorityHigh, <-svc.started)
+}
+
+func TestRPCPolicyRetryAfter(t *testing.T) {
+	_, ok := RetryAfter(psrpc.NewErrorf(psrpc.Unavailable, "down"))
+	require.False(t, ok)
+
+	c := &admissionController{}
+	err := c.reject(psrpc.RPCInfo{}, "rate", 3*time.Second, "busy")
+	p := RPCPolicy{MaxAttempts: 2, Backoff: time.Second}
+	retry, _, wait := p.retryOptions().GetRetryParameters(err, 1)
+	require.True(t, retry)
+	require.Equal(t, 3*time.Second, wait)
+
+	// Plain retry options also wait as asked.
+	o := withRetryAfter(middleware.RetryOptions{MaxAttempts: 3, Timeout: time.Second, Backoff: time.Second})
+	retry, timeout, wait := o.GetRetryParameters(err, 1)
+	require.True(t, retry)
+	require.Equal(t, 2*time.Second, timeout)
+	require.Equal(t, 3*time.Second, wait)
+	retry, _, _ = o.GetRetryParameters(err, 3)
+	require.False(t, retry)
+	retry, _, wait = o.GetRetryParameters(psrpc.NewErrorf(psrpc.Unavailable, "down"), 2)
+	require.True(t, retry)
+	require.Zero(t, wait)
+	retry, _, _ = o.GetRetryParameters(psrpc.NewErrorf(psrpc.NotFound, "missing"), 1)
+	require.False(t, retry)
+}
@@ -0,0 +1,308 @@
+// Copyright 2025 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/livekit/protocol/egress"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/utils"
+	"github.com/livekit/psrpc"
+	"github.com/livekit/psrpc/pkg/metadata"
+)
+
+// AuditSecretFields lists names of string fields which are replaced by a placeholder in audited requests,
+// in messages of any type, in addition to names matched by IsAuditSecretField.
+var AuditSecretFields = map[protoreflect.Name]struct{}{
+	"password":      {},
+	"token":         {},
+	"secret":        {},
+	"api_secret":    {},
+	"access_key":    {},
+	"secret_key":    {},
+	"session_token": {},
+	"credentials":   {},
+	"account_key":   {},
+	"signing_key":   {},
+	"private_key":   {},
+	"auth_token":    {},
+}
+
+// auditSecretSuffixes match secret field names like stream_key, auth_password or turn_password.
+var auditSecretSuffixes = []string{"_password", "_key", "_token"}
+
+// auditSecretHeaders match keys of string maps, like SIP headers, which carry credentials.
+var auditSecretHeaders = []string{"authorization", "cookie"}
+
+// isAuditSecretMapKey reports whether the value for a map key, such as a header or attribute name,
+// is redacted in audited requests.
+func isAuditSecretMapKey(key string) bool {
+	s := strings.NewReplacer("-", "_", ".", "_").Replace(strings.ToLower(key))
+	if IsAuditSecretField(protoreflect.Name(s)) {
+		return true
+	}
+	for _, h := range auditSecretHeaders {
+		if strings.HasSuffix(s, h) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAuditSecretField reports whether string fields with the name are redacted in audited requests.
+func IsAuditSecretField(name protoreflect.Name) bool {
+	if _, ok := AuditSecretFields[name]; ok {
+		return true
+	}
+	s := strings.ToLower(string(name))
+	if strings.Contains(s, "secret") || strings.Contains(s, "password") {
+		return true
+	}
+	for _, suffix := range auditSecretSuffixes {
+		if strings.HasSuffix(s, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// mutatingPrefixes are verbs of methods which change state.
+var mutatingPrefixes = []string{
+	"Create", "Update", "Delete", "Remove", "Start", "Stop", "Send", "Set", "Mute",
+	"Transfer", "Move", "Forward", "Kill", "Dispatch", "Close", "Add", "Register",
+}
+
+// IsMutatingMethod guesses whether a method changes state from its name.
+func IsMutatingMethod(method string) bool {
+	for _, p := range mutatingPrefixes {
+		if strings.HasPrefix(method, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// AuditConfig selects audited methods.
+type AuditConfig struct {
+	// Methods maps "Service.Method" or "Service" keys, like in PSRPCPolicies, to sample rates from 0 to 1.
+	// Listed methods are audited even if they do not look mutating.
+	Methods map[string]float64 `yaml:"methods,omitempty"`
+	// SampleRate applies to other mutating methods, see IsMutatingMethod. Zero disables them.
+	SampleRate float64 `yaml:"sample_rate,omitempty"`
+	// IncludeRequest adds requests, with secrets removed, to audit records.
+	IncludeRequest bool `yaml:"include_request,omitempty"`
+}
+
+func (c *AuditConfig) sampleRate(service, method string) float64 {
+	if r, ok := c.Methods[service+"."+method]; ok {
+		return r
+	}
+	if r, ok := c.Methods[service]; ok {
+		return r
+	}
+	if IsMutatingMethod(method) {
+		return c.SampleRate
+	}
+	return 0
+}
+
+// AuditRecord describes a handled request.
+type AuditRecord struct {
+	Time    time.Time
+	Service string
+	Method  string
+	Topic   []string
+	// Caller is the psrpc client ID of the caller.
+	Caller      string
+	Room        string
+	Participant string
+	// Outcome is "ok" or the psrpc error code.
+	Outcome  string
+	Error    string
+	Duration time.Duration
+	// Request is set when AuditConfig.IncludeRequest is enabled.
+	Request proto.Message
+}
+
+// AuditSink receives audit records. It must not retain the record after Write returns.
+type AuditSink interface {
+	Write(r *AuditRecord)
+}
+
+type loggerAuditSink struct {
+	logger logger.Logger
+}
+
+// NewLoggerAuditSink writes audit records as info messages. The logger should be dedicated to auditing.
+func NewLoggerAuditSink(l logger.Logger) AuditSink {
+	return &loggerAuditSink{logger: l}
+}
+
+func (s *loggerAuditSink) Write(r *AuditRecord) {
+	kv := []interface{}{
+		"service", r.Service,
+		"method", r.Method,
+		"topic", r.Topic,
+		"caller", r.Caller,
+		"room", r.Room,
+		"participant", r.Participant,
+		"outcome", r.Outcome,
+		"duration", r.Duration,
+	}
+	if r.Error != "" {
+		kv = append(kv, "error", r.Error)
+	}
+	if r.Request != nil {
+		kv = append(kv, "request", logger.Proto(r.Request))
+	}
+	s.logger.Infow("rpc audit", kv...)
+}
+
+type jsonAuditSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONAuditSink writes each audit record as a line of JSON.
+func NewJSONAuditSink(w io.Writer) AuditSink {
+	return &jsonAuditSink{w: w}
+}
+
+type jsonAuditRecord struct {
+	Time        time.Time       `json:"time"`
+	Service     string          `json:"service"`
+	Method      string          `json:"method"`
+	Topic       []string        `json:"topic,omitempty"`
+	Caller      string          `json:"caller,omitempty"`
+	Room        string          `json:"room,omitempty"`
+	Participant string          `json:"participant,omitempty"`
+	Outcome     string          `json:"outcome"`
+	Error       string          `json:"error,omitempty"`
+	DurationMs  int64           `json:"duration_ms"`
+	Request     json.RawMessage `json:"request,omitempty"`
+}
+
+func (s *jsonAuditSink) Write(r *AuditRecord) {
+	jr := jsonAuditRecord{
+		Time:        r.Time,
+		Service:     r.Service,
+		Method:      r.Method,
+		Topic:       r.Topic,
+		Caller:      r.Caller,
+		Room:        r.Room,
+		Participant: r.Participant,
+		Outcome:     r.Outcome,
+		Error:       r.Error,
+		DurationMs:  r.Duration.Milliseconds(),
+	}
+	if r.Request != nil {
+		if b, err := protojson.Marshal(r.Request); err == nil {
+			jr.Request = b
+		}
+	}
+	b, err := json.Marshal(jr)
+	if err != nil {
+		return
+	}
+	b = append(b, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, _ = s.w.Write(b)
+}
+
+// WithServerAuditLog writes audit records for handled requests to the sink.
+func WithServerAuditLog(config AuditConfig, sink AuditSink) psrpc.ServerOption {
+	return psrpc.WithServerRPCInterceptors(newServerAuditInterceptor(config, sink))
+}
+
+func newServerAuditInterceptor(config AuditConfig, sink AuditSink) psrpc.ServerRPCInterceptor {
+	return func(ctx context.Context, req proto.Message, rpcInfo psrpc.RPCInfo, handler psrpc.ServerRPCHandler) (res proto.Message, err error) {
+		rate := config.sampleRate(rpcInfo.Service, rpcInfo.Method)
+		if rate <= 0 || (rate < 1 && rand.Float64() >= rate) {
+			return handler(ctx, req)
+		}
+
+		start := time.Now()
+		defer func() {
+			r := &AuditRecord{
+				Time:     start,
+				Service:  rpcInfo.Service,
+				Method:   rpcInfo.Method,
+				Topic:    rpcInfo.Topic,
+				Outcome:  errorCode(err),
+				Duration: time.Since(start),
+			}
+			if head := metadata.IncomingHeader(ctx); head != nil {
+				r.Caller = head.RemoteID
+			}
+			if err != nil {
+				r.Error = err.Error()
+			}
+			if req != nil {
+				r.Room, r.Participant = auditSubject(req.ProtoReflect())
+				if config.IncludeRequest {
+					r.Request = RedactSecrets(req)
+				}
+			}
+			sink.Write(r)
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// auditSubject returns the room and participant a request refers to, by common field names.
+func auditSubject(m protoreflect.Message) (room, participant string) {
+	fields := m.Descriptor().Fields()
+	get := func(names ...protoreflect.Name) string {
+		for _, name := range names {
+			if f := fields.ByName(name); f != nil && f.Kind() == protoreflect.StringKind && !f.IsList() {
+				if v := m.Get(f).String(); v != "" {
+					return v
+				}
+			}
+		}
+		return ""
+	}
+	return get("room", "room_name"), get("identity", "participant_identity")
+}
+
+// auditRedactor redacts egress credentials and stream keys, as well as fields and map values matched by
+// IsAuditSecretField and isAuditSecretMapKey.
+var auditRedactor = egress.Redactor{
+	SecretField: func(fd protoreflect.FieldDescriptor) bool {
+		return IsAuditSecretField(fd.Name())
+	},
+	SecretMapKey: isAuditSecretMapKey,
+}
+
+// RedactSecrets returns a copy of the message with secret fields, see IsAuditSecretField, replaced by placeholders.
+// Stream keys in egress stream urls are removed as well.
+func RedactSecrets[T proto.Message](m T) T {
+	m = utils.CloneProto(m)
+	auditRedactor.Redact(m)
+	return m
+}
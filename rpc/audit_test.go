@@ -0,0 +1,174 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/psrpc"
+	"github.com/livekit/psrpc/pkg/metadata"
+)
+
+type auditRecords []*AuditRecord
+
+func (r *auditRecords) Write(rec *AuditRecord) {
+	*r = append(*r, rec)
+}
+
+func TestRedactSecrets(t *testing.T) {
+	req := &StartEgressRequest{
+		Request: &StartEgressRequest_RoomComposite{RoomComposite: &livekit.RoomCompositeEgressRequest{
+			RoomName: "room",
+			FileOutputs: []*livekit.EncodedFileOutput{{
+				Output: &livekit.EncodedFileOutput_S3{S3: &livekit.S3Upload{
+					AccessKey: "AKIA",
+					Secret:    "s3cr3t",
+					Bucket:    "bucket",
+				}},
+			}},
+		}},
+		Token: "jwt",
+	}
+	redacted := RedactSecrets(req)
+	require.Equal(t, "{token}", redacted.Token)
+	s3 := redacted.GetRoomComposite().FileOutputs[0].GetS3()
+	require.Equal(t, "{access_key}", s3.AccessKey)
+	require.Equal(t, "{secret}", s3.Secret)
+	require.Equal(t, "bucket", s3.Bucket)
+
+	// The original is not modified.
+	require.Equal(t, "jwt", req.Token)
+	require.Equal(t, "s3cr3t", req.GetRoomComposite().FileOutputs[0].GetS3().Secret)
+}
+
+func TestRedactSecretsMaps(t *testing.T) {
+	req := &InternalCreateSIPParticipantRequest{
+		SipCallId: "SCL_1",
+		Headers: map[string]string{
+			"Authorization": "Bearer abc",
+			"X-Auth-Token":  "t0k3n",
+			"X-Customer":    "acme",
+		},
+		ParticipantAttributes: map[string]string{
+			"sip.h.x-api-key": "k3y",
+			"customer":        "acme",
+		},
+	}
+	redacted := RedactSecrets(req)
+	require.Equal(t, map[string]string{
+		"Authorization": "{Authorization}",
+		"X-Auth-Token":  "{X-Auth-Token}",
+		"X-Customer":    "acme",
+	}, redacted.Headers)
+	require.Equal(t, map[string]string{
+		"sip.h.x-api-key": "{sip.h.x-api-key}",
+		"customer":        "acme",
+	}, redacted.ParticipantAttributes)
+	require.Equal(t, "Bearer abc", req.Headers["Authorization"])
+}
+
+func TestRedactSecretsIOInfo(t *testing.T) {
+	t.Run("CreateIngress", func(t *testing.T) {
+		info := &livekit.IngressInfo{
+			IngressId: "IN_1",
+			StreamKey: "abcdefghijkl",
+			Url:       "rtmp://ingress.example.com/x",
+			RoomName:  "room",
+		}
+		redacted := RedactSecrets(info)
+		require.Equal(t, "{stream_key}", redacted.StreamKey)
+		require.Equal(t, "IN_1", redacted.IngressId)
+		require.Equal(t, "abcdefghijkl", info.StreamKey)
+	})
+
+	t.Run("CreateEgress", func(t *testing.T) {
+		info := &livekit.EgressInfo{
+			EgressId: "EG_1",
+			Request: &livekit.EgressInfo_RoomComposite{RoomComposite: &livekit.RoomCompositeEgressRequest{
+				RoomName: "room",
+				StreamOutputs: []*livekit.StreamOutput{{
+					Urls: []string{"rtmp://live.example.com/app/abcdefghijkl"},
+				}},
+			}},
+			StreamResults: []*livekit.StreamInfo{{
+				Url: "rtmp://live.example.com/app/abcdefghijkl",
+			}},
+		}
+		redacted := RedactSecrets(info)
+		url := redacted.GetRoomComposite().StreamOutputs[0].Urls[0]
+		require.NotContains(t, url, "abcdefghijkl")
+		require.NotContains(t, redacted.StreamResults[0].Url, "abcdefghijkl")
+		require.Equal(t, "rtmp://live.example.com/app/abcdefghijkl", info.StreamResults[0].Url)
+	})
+
+	t.Run("suffix matches", func(t *testing.T) {
+		for _, name := range []string{"stream_key", "auth_password", "inbound_password", "outbound_password", "turn_password", "client_secret"} {
+			require.True(t, IsAuditSecretField(protoreflect.Name(name)), name)
+		}
+		for _, name := range []string{"room_name", "identity", "url"} {
+			require.False(t, IsAuditSecretField(protoreflect.Name(name)), name)
+		}
+
+		trunk := RedactSecrets(&livekit.SIPOutboundTrunkInfo{
+			SipTrunkId:   "ST_1",
+			AuthPassword: "pass",
+		})
+		require.Equal(t, "{auth_password}", trunk.AuthPassword)
+	})
+}
+
+func TestAuditInterceptor(t *testing.T) {
+	var records auditRecords
+	intercept := newServerAuditInterceptor(AuditConfig{
+		Methods:        map[string]float64{"SIPInternal.TransferSIPParticipant": 0},
+		SampleRate:     1,
+		IncludeRequest: true,
+	}, &records)
+
+	ctx := metadata.NewContextWithIncomingHeader(context.Background(), &metadata.Header{RemoteID: "CLI_caller"})
+	call := func(method string, req proto.Message, err error) {
+		info := psrpc.RPCInfo{Service: "SIPInternal", Method: method, Topic: []string{"project"}}
+		_, _ = intercept(ctx, req, info, func(ctx context.Context, req proto.Message) (proto.Message, error) {
+			return nil, err
+		})
+	}
+
+	call("CreateSIPParticipant", &InternalCreateSIPParticipantRequest{
+		RoomName:            "room",
+		ParticipantIdentity: "caller",
+		Username:            "user",
+		Password:            "pass",
+		Token:               "jwt",
+	}, psrpc.NewErrorf(psrpc.Unavailable, "busy"))
+	call("TransferSIPParticipant", &InternalTransferSIPParticipantRequest{}, nil)
+	call("ListSIPParticipants", &InternalCreateSIPParticipantRequest{}, nil)
+
+	require.Len(t, records, 1)
+	r := records[0]
+	require.Equal(t, "CreateSIPParticipant", r.Method)
+	require.Equal(t, []string{"project"}, r.Topic)
+	require.Equal(t, "CLI_caller", r.Caller)
+	require.Equal(t, "room", r.Room)
+	require.Equal(t, "caller", r.Participant)
+	require.Equal(t, "unavailable", r.Outcome)
+	require.Contains(t, r.Error, "busy")
+	req := r.Request.(*InternalCreateSIPParticipantRequest)
+	require.Equal(t, "user", req.Username)
+	require.Equal(t, "{password}", req.Password)
+	require.Equal(t, "{token}", req.Token)
+
+	var buf bytes.Buffer
+	NewJSONAuditSink(&buf).Write(r)
+	var out map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+	require.Equal(t, "room", out["room"])
+	require.Equal(t, "unavailable", out["outcome"])
+	require.NotContains(t, buf.String(), "pass\"")
+	require.NotContains(t, buf.String(), "jwt")
+}
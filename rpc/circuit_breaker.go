@@ -0,0 +1,387 @@
+// Copyright 2025 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/utils"
+	"github.com/livekit/psrpc"
+)
+
+type CircuitState int
+
+const (
+	CircuitClosed CircuitState = iota
+	CircuitHalfOpen
+	CircuitOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitHalfOpen:
+		return "half_open"
+	case CircuitOpen:
+		return "open"
+	default:
+		return fmt.Sprintf("%d", int(s))
+	}
+}
+
+// CircuitBreakerConfig controls when a circuit opens. Zero values are replaced by DefaultCircuitBreakerConfig values.
+type CircuitBreakerConfig struct {
+	// Window over which request outcomes are counted.
+	Window time.Duration `yaml:"window,omitempty"`
+	// MinRequests in the window before the circuit may open.
+	MinRequests int `yaml:"min_requests,omitempty"`
+	// FailureRatio in the window which opens the circuit, from 0 to 1.
+	FailureRatio float64 `yaml:"failure_ratio,omitempty"`
+	// OpenTimeout after which an open circuit lets probe requests through.
+	OpenTimeout time.Duration `yaml:"open_timeout,omitempty"`
+	// HalfOpenProbes is the number of concurrent probes, all of which must succeed to close the circuit.
+	HalfOpenProbes int `yaml:"half_open_probes,omitempty"`
+	// KeyByTopic tracks a separate circuit for each topic of a method, instead of one circuit per method.
+	// Only useful for methods with a small set of topics, e.g. node or region IDs.
+	KeyByTopic bool `yaml:"key_by_topic,omitempty"`
+	// IdleTimeout after which a per-topic circuit without requests is forgotten.
+	IdleTimeout time.Duration `yaml:"idle_timeout,omitempty"`
+	// MaxTopicLabels limits the number of distinct topic label values in circuit metrics of each method.
+	// Other topics are labeled "other".
+	MaxTopicLabels int `yaml:"max_topic_labels,omitempty"`
+}
+
+var DefaultCircuitBreakerConfig = CircuitBreakerConfig{
+	Window:         10 * time.Second,
+	MinRequests:    20,
+	FailureRatio:   0.5,
+	OpenTimeout:    5 * time.Second,
+	HalfOpenProbes: 3,
+	IdleTimeout:    5 * time.Minute,
+	MaxTopicLabels: 20,
+}
+
+func (c CircuitBreakerConfig) withDefaults() CircuitBreakerConfig {
+	if c.Window <= 0 {
+		c.Window = DefaultCircuitBreakerConfig.Window
+	}
+	if c.Window < circuitBuckets {
+		// the window is split into buckets, each at least a nanosecond wide
+		c.Window = circuitBuckets
+	}
+	if c.MinRequests <= 0 {
+		c.MinRequests = DefaultCircuitBreakerConfig.MinRequests
+	}
+	if c.FailureRatio <= 0 || c.FailureRatio > 1 {
+		c.FailureRatio = DefaultCircuitBreakerConfig.FailureRatio
+	}
+	if c.OpenTimeout <= 0 {
+		c.OpenTimeout = DefaultCircuitBreakerConfig.OpenTimeout
+	}
+	if c.HalfOpenProbes <= 0 {
+		c.HalfOpenProbes = DefaultCircuitBreakerConfig.HalfOpenProbes
+	}
+	if c.IdleTimeout <= 0 {
+		c.IdleTimeout = DefaultCircuitBreakerConfig.IdleTimeout
+	}
+	if c.MaxTopicLabels <= 0 {
+		c.MaxTopicLabels = DefaultCircuitBreakerConfig.MaxTopicLabels
+	}
+	return c
+}
+
+// CircuitOpenError is returned, wrapped into a psrpc.Unavailable error, for requests rejected by an open circuit.
+type CircuitOpenError struct {
+	Service    string
+	Method     string
+	Topic      string
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	if e.Topic == "" {
+		return fmt.Sprintf("circuit breaker open for %s.%s, retry after %s", e.Service, e.Method, e.RetryAfter)
+	}
+	return fmt.Sprintf("circuit breaker open for %s.%s topic %s, retry after %s", e.Service, e.Method, e.Topic, e.RetryAfter)
+}
+
+// IsCircuitOpen checks if the request was rejected by a circuit breaker without being sent.
+func IsCircuitOpen(err error) bool {
+	var e *CircuitOpenError
+	return errors.As(err, &e)
+}
+
+// isCircuitFailure checks if an error indicates that the service is overloaded or unreachable.
+func isCircuitFailure(err error) bool {
+	var e psrpc.Error
+	if !errors.As(err, &e) {
+		return false
+	}
+	switch e.Code() {
+	case psrpc.Unavailable, psrpc.ResourceExhausted, psrpc.DeadlineExceeded:
+		return true
+	default:
+		return false
+	}
+}
+
+type CircuitBreakerOption func(*CircuitBreaker)
+
+func WithCircuitBreakerClock(clock utils.Clock) CircuitBreakerOption {
+	return func(b *CircuitBreaker) {
+		b.clock = clock
+	}
+}
+
+// CircuitBreaker tracks failures of unary RPCs per method, or per method and topic if KeyByTopic is set.
+// When the share of Unavailable, ResourceExhausted and DeadlineExceeded errors stays high, it rejects requests
+// without sending them until the service recovers.
+type CircuitBreaker struct {
+	config CircuitBreakerConfig
+	clock  utils.Clock
+	topics *topicLabeler
+
+	mu        sync.Mutex
+	circuits  map[circuitKey]*circuit
+	lastSweep time.Time
+}
+
+type circuitKey struct {
+	service string
+	method  string
+	topic   string
+}
+
+func NewCircuitBreaker(config CircuitBreakerConfig, opts ...CircuitBreakerOption) *CircuitBreaker {
+	config = config.withDefaults()
+	b := &CircuitBreaker{
+		config:   config,
+		clock:    utils.SystemClock{},
+		circuits: make(map[circuitKey]*circuit),
+	}
+	if config.KeyByTopic {
+		b.topics = newTopicLabeler(config.MaxTopicLabels, nil)
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// WithCircuitBreaker adds a circuit breaker to the client. It should come before retry options,
+// so that rejected requests are not retried.
+func WithCircuitBreaker(config CircuitBreakerConfig, opts ...CircuitBreakerOption) psrpc.ClientOption {
+	return psrpc.WithClientRPCInterceptors(NewCircuitBreaker(config, opts...).Interceptor())
+}
+
+// State returns the state of the circuit used for a request.
+func (b *CircuitBreaker) State(info psrpc.RPCInfo) CircuitState {
+	b.mu.Lock()
+	c, ok := b.circuits[b.key(info)]
+	b.mu.Unlock()
+	if !ok {
+		return CircuitClosed
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state
+}
+
+func (b *CircuitBreaker) key(info psrpc.RPCInfo) circuitKey {
+	key := circuitKey{service: info.Service, method: info.Method}
+	if b.config.KeyByTopic {
+		key.topic = strings.Join(info.Topic, ".")
+	}
+	return key
+}
+
+func (b *CircuitBreaker) getCircuit(info psrpc.RPCInfo, now time.Time) *circuit {
+	key := b.key(info)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.config.KeyByTopic && now.Sub(b.lastSweep) >= b.config.IdleTimeout {
+		b.lastSweep = now
+		b.sweep(now)
+	}
+	c, ok := b.circuits[key]
+	if !ok {
+		c = &circuit{key: key, config: &b.config, lastUsed: now}
+		if key.topic != "" {
+			c.topicLabel = b.topics.labelTopic(key.service+"."+key.method, key.topic)
+		}
+		b.circuits[key] = c
+	}
+	return c
+}
+
+// sweep forgets per-topic circuits which had no requests for IdleTimeout.
+func (b *CircuitBreaker) sweep(now time.Time) {
+	for key, c := range b.circuits {
+		c.mu.Lock()
+		if c.probes == 0 && now.Sub(c.lastUsed) >= b.config.IdleTimeout {
+			if c.state != CircuitClosed {
+				c.setState(CircuitClosed)
+			}
+			delete(b.circuits, key)
+		}
+		c.mu.Unlock()
+	}
+}
+
+func (b *CircuitBreaker) Interceptor() psrpc.ClientRPCInterceptor {
+	return func(rpcInfo psrpc.RPCInfo, next psrpc.ClientRPCHandler) psrpc.ClientRPCHandler {
+		return func(ctx context.Context, req proto.Message, opts ...psrpc.RequestOption) (proto.Message, error) {
+			now := b.clock.Now()
+			c := b.getCircuit(rpcInfo, now)
+			probe, err := c.allow(now)
+			if err != nil {
+				return nil, err
+			}
+			res, err := next(ctx, req, opts...)
+			c.record(b.clock.Now(), probe, isCircuitFailure(err))
+			return res, err
+		}
+	}
+}
+
+const circuitBuckets = 10
+
+type circuitBucket struct {
+	start    time.Time
+	requests int
+	failures int
+}
+
+type circuit struct {
+	key        circuitKey
+	topicLabel string
+	config     *CircuitBreakerConfig
+
+	mu       sync.Mutex
+	lastUsed time.Time
+	state    CircuitState
+	buckets  [circuitBuckets]circuitBucket
+	openedAt time.Time
+	probes   int
+	passed   int
+}
+
+// allow checks if a request may be sent and whether it is a half-open probe.
+func (c *circuit) allow(now time.Time) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lastUsed = now
+	if c.state == CircuitOpen {
+		if wait := c.config.OpenTimeout - now.Sub(c.openedAt); wait > 0 {
+			c.reject()
+			return false, psrpc.NewError(psrpc.Unavailable, &CircuitOpenError{
+				Service:    c.key.service,
+				Method:     c.key.method,
+				Topic:      c.key.topic,
+				RetryAfter: wait,
+			})
+		}
+		c.setState(CircuitHalfOpen)
+		c.probes, c.passed = 0, 0
+	}
+
+	if c.state == CircuitHalfOpen {
+		if c.probes+c.passed >= c.config.HalfOpenProbes {
+			c.reject()
+			return false, psrpc.NewError(psrpc.Unavailable, &CircuitOpenError{
+				Service: c.key.service,
+				Method:  c.key.method,
+				Topic:   c.key.topic,
+			})
+		}
+		c.probes++
+		return true, nil
+	}
+	return false, nil
+}
+
+func (c *circuit) record(now time.Time, probe, failure bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if probe {
+		c.probes = max(c.probes-1, 0)
+		if c.state != CircuitHalfOpen {
+			return
+		}
+		if failure {
+			c.open(now)
+			return
+		}
+		if c.passed++; c.passed >= c.config.HalfOpenProbes {
+			c.buckets = [circuitBuckets]circuitBucket{}
+			c.setState(CircuitClosed)
+		}
+		return
+	}
+	if c.state != CircuitClosed {
+		return
+	}
+
+	width := c.config.Window / circuitBuckets
+	start := now.Truncate(width)
+	b := &c.buckets[int(start.UnixNano()/int64(width))%circuitBuckets]
+	if !b.start.Equal(start) {
+		*b = circuitBucket{start: start}
+	}
+	b.requests++
+	if failure {
+		b.failures++
+	}
+
+	var requests, failures int
+	for i := range c.buckets {
+		if now.Sub(c.buckets[i].start) < c.config.Window {
+			requests += c.buckets[i].requests
+			failures += c.buckets[i].failures
+		}
+	}
+	if requests >= c.config.MinRequests && float64(failures) >= c.config.FailureRatio*float64(requests) {
+		c.open(now)
+	}
+}
+
+func (c *circuit) open(now time.Time) {
+	c.openedAt = now
+	c.setState(CircuitOpen)
+}
+
+func (c *circuit) setState(state CircuitState) {
+	c.state = state
+	if m := metrics.Load(); m != nil {
+		m.circuitState.WithLabelValues(c.key.service, c.key.method, c.topicLabel).Set(float64(state))
+	}
+}
+
+func (c *circuit) reject() {
+	if m := metrics.Load(); m != nil {
+		m.circuitRejectedTotal.WithLabelValues(c.key.service, c.key.method, c.topicLabel).Inc()
+	}
+}
@@ -0,0 +1,190 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/utils"
+	"github.com/livekit/psrpc"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	clock := &utils.SimulatedClock{}
+	clock.Set(time.Now())
+	b := NewCircuitBreaker(CircuitBreakerConfig{
+		Window:         time.Second,
+		MinRequests:    4,
+		FailureRatio:   0.5,
+		OpenTimeout:    time.Second,
+		HalfOpenProbes: 2,
+	}, WithCircuitBreakerClock(clock))
+
+	info := psrpc.RPCInfo{Service: "SIPInternal", Method: "CreateSIPParticipant", Topic: []string{"project"}}
+	var calls int
+	var err error
+	call := b.Interceptor()(info, func(ctx context.Context, req proto.Message, opts ...psrpc.RequestOption) (proto.Message, error) {
+		calls++
+		return nil, err
+	})
+
+	// Other errors do not open the circuit.
+	err = psrpc.NewErrorf(psrpc.NotFound, "not found")
+	for i := 0; i < 4; i++ {
+		_, _ = call(context.Background(), nil)
+	}
+	require.Equal(t, CircuitClosed, b.State(info))
+
+	// Failures outside of the window are not counted.
+	clock.Add(2 * time.Second)
+	err = psrpc.NewErrorf(psrpc.ResourceExhausted, "busy")
+	for i := 0; i < 3; i++ {
+		_, _ = call(context.Background(), nil)
+	}
+	require.Equal(t, CircuitClosed, b.State(info))
+	_, _ = call(context.Background(), nil)
+	require.Equal(t, CircuitOpen, b.State(info))
+	require.Equal(t, CircuitClosed, b.State(psrpc.RPCInfo{Service: "SIPInternal", Method: "TransferSIPParticipant", Topic: []string{"project"}}))
+
+	calls = 0
+	clock.Add(500 * time.Millisecond)
+	_, rerr := call(context.Background(), nil)
+	require.True(t, IsCircuitOpen(rerr))
+	var e psrpc.Error
+	require.ErrorAs(t, rerr, &e)
+	require.Equal(t, psrpc.Unavailable, e.Code())
+	var oe *CircuitOpenError
+	require.ErrorAs(t, rerr, &oe)
+	require.Equal(t, 500*time.Millisecond, oe.RetryAfter)
+	require.Equal(t, 0, calls)
+
+	// A failed probe opens the circuit again.
+	clock.Add(500 * time.Millisecond)
+	err = psrpc.NewErrorf(psrpc.DeadlineExceeded, "timeout")
+	_, _ = call(context.Background(), nil)
+	require.Equal(t, 1, calls)
+	require.Equal(t, CircuitOpen, b.State(info))
+
+	// All probes must pass to close the circuit.
+	clock.Add(time.Second)
+	err = nil
+	_, rerr = call(context.Background(), nil)
+	require.NoError(t, rerr)
+	require.Equal(t, CircuitHalfOpen, b.State(info))
+	_, rerr = call(context.Background(), nil)
+	require.NoError(t, rerr)
+	require.Equal(t, CircuitClosed, b.State(info))
+}
+
+func TestCircuitBreakerByTopic(t *testing.T) {
+	clock := &utils.SimulatedClock{}
+	clock.Set(time.Now())
+	b := NewCircuitBreaker(CircuitBreakerConfig{
+		MinRequests:    1,
+		KeyByTopic:     true,
+		IdleTimeout:    time.Minute,
+		MaxTopicLabels: 1,
+	}, WithCircuitBreakerClock(clock))
+
+	fail := func(ctx context.Context, req proto.Message, opts ...psrpc.RequestOption) (proto.Message, error) {
+		return nil, psrpc.NewErrorf(psrpc.Unavailable, "down")
+	}
+	room1 := psrpc.RPCInfo{Service: "RoomManager", Method: "CreateRoom", Topic: []string{"room1"}}
+	room2 := psrpc.RPCInfo{Service: "RoomManager", Method: "CreateRoom", Topic: []string{"room2"}}
+	_, _ = b.Interceptor()(room1, fail)(context.Background(), nil)
+	require.Equal(t, CircuitOpen, b.State(room1))
+	require.Equal(t, CircuitClosed, b.State(room2))
+	_, _ = b.Interceptor()(room2, fail)(context.Background(), nil)
+	require.Len(t, b.circuits, 2)
+
+	// Topic labels are bounded.
+	require.Equal(t, "room1", b.circuits[b.key(room1)].topicLabel)
+	require.Equal(t, topicOther, b.circuits[b.key(room2)].topicLabel)
+
+	// Idle circuits are forgotten.
+	clock.Add(2 * time.Minute)
+	room3 := psrpc.RPCInfo{Service: "RoomManager", Method: "CreateRoom", Topic: []string{"room3"}}
+	_, _ = b.Interceptor()(room3, fail)(context.Background(), nil)
+	require.Len(t, b.circuits, 1)
+	require.Equal(t, CircuitClosed, b.State(room1))
+
+	// By default, all topics share a circuit.
+	b = NewCircuitBreaker(CircuitBreakerConfig{MinRequests: 2}, WithCircuitBreakerClock(clock))
+	_, _ = b.Interceptor()(room1, fail)(context.Background(), nil)
+	_, _ = b.Interceptor()(room2, fail)(context.Background(), nil)
+	require.Equal(t, CircuitOpen, b.State(room3))
+	require.Len(t, b.circuits, 1)
+}
+
+func TestCircuitBreakerHalfOpenLimit(t *testing.T) {
+	clock := &utils.SimulatedClock{}
+	clock.Set(time.Now())
+	b := NewCircuitBreaker(CircuitBreakerConfig{MinRequests: 1, HalfOpenProbes: 1}, WithCircuitBreakerClock(clock))
+	info := psrpc.RPCInfo{Service: "EgressInternal", Method: "StartEgress"}
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var probe bool
+	call := b.Interceptor()(info, func(ctx context.Context, req proto.Message, opts ...psrpc.RequestOption) (proto.Message, error) {
+		if probe {
+			close(started)
+			<-release
+			return nil, nil
+		}
+		return nil, psrpc.NewErrorf(psrpc.Unavailable, "down")
+	})
+	_, _ = call(context.Background(), nil)
+	require.Equal(t, CircuitOpen, b.State(info))
+
+	clock.Add(DefaultCircuitBreakerConfig.OpenTimeout)
+	probe = true
+	done := make(chan error)
+	go func() {
+		_, err := call(context.Background(), nil)
+		done <- err
+	}()
+	<-started
+	// Only one probe is allowed at a time.
+	_, err := call(context.Background(), nil)
+	require.True(t, IsCircuitOpen(err))
+
+	close(release)
+	require.NoError(t, <-done)
+	require.Equal(t, CircuitClosed, b.State(info))
+}
+
+func TestCircuitBreakerSkipsRetries(t *testing.T) {
+	svc := &flakyRoomManager{failures: 100, code: psrpc.Unavailable}
+	client := newPolicyTestClient(t, svc, ClientParams{PSRPCConfig: PSRPCConfig{
+		MaxAttempts:    3,
+		Timeout:        time.Second,
+		CircuitBreaker: &CircuitBreakerConfig{MinRequests: 2, OpenTimeout: time.Minute},
+	}})
+	req := &livekit.CreateRoomRequest{Name: "room"}
+
+	for i := 0; i < 2; i++ {
+		_, err := client.CreateRoom(context.Background(), "node", req)
+		require.Error(t, err)
+		require.False(t, IsCircuitOpen(err))
+	}
+	require.EqualValues(t, 6, svc.calls.Load())
+
+	_, err := client.CreateRoom(context.Background(), "node", req)
+	require.True(t, IsCircuitOpen(err))
+	require.EqualValues(t, 6, svc.calls.Load())
+}
+
+func TestCircuitBreakerTinyWindow(t *testing.T) {
+	b := NewCircuitBreaker(CircuitBreakerConfig{Window: time.Nanosecond})
+	info := psrpc.RPCInfo{Service: "SIPInternal", Method: "CreateSIPParticipant"}
+	call := b.Interceptor()(info, func(ctx context.Context, req proto.Message, opts ...psrpc.RequestOption) (proto.Message, error) {
+		return nil, psrpc.NewErrorf(psrpc.Unavailable, "unavailable")
+	})
+	require.NotPanics(t, func() {
+		_, _ = call(context.Background(), nil)
+	})
+}
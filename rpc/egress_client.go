@@ -62,9 +62,10 @@ func NewEgressClient(params ClientParams) (EgressClient, error) {
 		timeout = 10 * time.Second
 	}
 
-	internalOpts := append(opts,
-		psrpc.WithClientChannelSize(1000),
-		middleware.WithRPCRetries(middleware.RetryOptions{
+	internalOpts := append(opts, psrpc.WithClientChannelSize(1000))
+	// Policies configured for EgressInternal replace the default exponential backoff.
+	if !params.Policies.HasService("EgressInternal") {
+		internalOpts = append(internalOpts, middleware.WithRPCRetries(middleware.RetryOptions{
 			Timeout: timeout,
 			GetRetryParameters: func(err error, attempt int) (retry bool, timeout time.Duration, waitTime time.Duration) {
 				if !isErrRecoverable(err) {
@@ -82,6 +83,7 @@ func NewEgressClient(params ClientParams) (EgressClient, error) {
 				return true, timeout, backoff
 			},
 		}))
+	}
 
 	internalClient, err := NewEgressInternalClient(params.Bus, internalOpts...)
 	if err != nil {
@@ -35,11 +35,34 @@ func newLoggerCache() loggerCache {
 
 func (c loggerCache) Get(info psrpc.RPCInfo, l logger.Logger) logger.Logger {
 	wl, _ := c.m.LoadOrCompute(info.Method, func() logger.Logger {
-		return l.WithComponent("psrpc").WithComponent(info.Service).WithComponent(info.Method)
+		return withRPCComponents(l, info)
 	})
 	return wl
 }
 
+// ForContext returns a logger for a call made with ctx. A logger added to ctx with logger.NewContext
+// is used in place of l. The logger is built the first time a message is logged, so calls that don't
+// log don't pay for adding values from ctx.
+func (c loggerCache) ForContext(ctx context.Context, info psrpc.RPCInfo, l logger.Logger, keysAndValues ...any) logger.Logger {
+	base := c.Get(info, l)
+	return logger.Lazy(base, func() logger.Logger {
+		var cl logger.Logger
+		if logger.HasContextLogger(ctx) {
+			cl = withRPCComponents(logger.FromContext(ctx), info)
+		} else {
+			cl = logger.WithContext(base, ctx)
+		}
+		if len(keysAndValues) != 0 {
+			cl = cl.WithValues(keysAndValues...)
+		}
+		return cl
+	})
+}
+
+func withRPCComponents(l logger.Logger, info psrpc.RPCInfo) logger.Logger {
+	return l.WithComponent("psrpc").WithComponent(info.Service).WithComponent(info.Method)
+}
+
 func WithClientLogger(logger logger.Logger) psrpc.ClientOption {
 	return psrpc.WithClientOptions(
 		psrpc.WithClientRPCInterceptors(newClientRPCLoggerInterceptor(logger)),
@@ -58,8 +81,8 @@ func WithServerLogger(logger logger.Logger) psrpc.ServerOption {
 func newClientRPCLoggerInterceptor(l logger.Logger) psrpc.ClientRPCInterceptor {
 	loggers := newLoggerCache()
 	return func(rpcInfo psrpc.RPCInfo, next psrpc.ClientRPCHandler) psrpc.ClientRPCHandler {
-		l := loggers.Get(rpcInfo, l)
 		return func(ctx context.Context, req proto.Message, opts ...psrpc.RequestOption) (res proto.Message, err error) {
+			l := loggers.ForContext(ctx, rpcInfo, l)
 			start := time.Now()
 			defer func() {
 				if err != nil {
@@ -76,7 +99,7 @@ func newClientRPCLoggerInterceptor(l logger.Logger) psrpc.ClientRPCInterceptor {
 func newServerRPCLoggerInterceptor(l logger.Logger) psrpc.ServerRPCInterceptor {
 	loggers := newLoggerCache()
 	return func(ctx context.Context, req proto.Message, rpcInfo psrpc.RPCInfo, handler psrpc.ServerRPCHandler) (res proto.Message, err error) {
-		l := loggers.Get(rpcInfo, l)
+		l := loggers.ForContext(ctx, rpcInfo, l)
 		start := time.Now()
 		defer func() {
 			if err != nil {
@@ -133,7 +156,10 @@ func newMultiRPCLoggerInterceptor(l logger.Logger) psrpc.ClientMultiRPCIntercept
 	return func(rpcInfo psrpc.RPCInfo, next psrpc.ClientMultiRPCHandler) psrpc.ClientMultiRPCHandler {
 		return &multiRPCLoggerInterceptor{
 			ClientMultiRPCHandler: next,
-			logger:                loggers.Get(rpcInfo, l).WithValues("topic", rpcInfo.Topic),
+			loggers:               loggers,
+			rpcInfo:               rpcInfo,
+			baseLogger:            l,
+			logger:                loggers.ForContext(context.Background(), rpcInfo, l, "topic", rpcInfo.Topic),
 			start:                 time.Now(),
 		}
 	}
@@ -141,6 +167,9 @@ func newMultiRPCLoggerInterceptor(l logger.Logger) psrpc.ClientMultiRPCIntercept
 
 type multiRPCLoggerInterceptor struct {
 	psrpc.ClientMultiRPCHandler
+	loggers       loggerCache
+	rpcInfo       psrpc.RPCInfo
+	baseLogger    logger.Logger
 	logger        logger.Logger
 	start         time.Time
 	responseCount int
@@ -149,6 +178,7 @@ type multiRPCLoggerInterceptor struct {
 
 func (r *multiRPCLoggerInterceptor) Send(ctx context.Context, req proto.Message, opts ...psrpc.RequestOption) error {
 	r.start = time.Now()
+	r.logger = r.loggers.ForContext(ctx, r.rpcInfo, r.baseLogger, "topic", r.rpcInfo.Topic)
 	r.logger.Debugw("multirpc opened", "request", logger.Proto(req))
 	return r.ClientMultiRPCHandler.Send(ctx, req, opts...)
 }
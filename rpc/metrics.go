@@ -15,13 +15,19 @@
 package rpc
 
 import (
+	"context"
+	"errors"
+	"slices"
 	"sort"
+	"strings"
 	sync "sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/atomic"
 	"golang.org/x/exp/maps"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/livekit/psrpc"
 	"github.com/livekit/psrpc/pkg/middleware"
@@ -29,8 +35,17 @@ import (
 
 const (
 	livekitNamespace = "livekit"
+
+	// codeOK labels successful requests.
+	codeOK = "ok"
+	// codeNoResponse labels multi-RPCs which got no responses.
+	codeNoResponse = "no_response"
+	// topicOther labels topics over the limit set by WithTopicLabels.
+	topicOther = "other"
 )
 
+var defaultRequestTimeBuckets = []float64{10, 50, 100, 300, 500, 1000, 1500, 2000, 5000, 10000}
+
 type psrpcMetrics struct {
 	requestTime        prometheus.ObserverVec
 	streamSendTime     prometheus.ObserverVec
@@ -38,6 +53,14 @@ type psrpcMetrics struct {
 	streamCurrent      *prometheus.GaugeVec
 	errorTotal         *prometheus.CounterVec
 	bytesTotal         *prometheus.CounterVec
+
+	circuitState         *prometheus.GaugeVec
+	circuitRejectedTotal *prometheus.CounterVec
+
+	admissionRejectedTotal *prometheus.CounterVec
+
+	exemplars bool
+	topics    *topicLabeler
 }
 
 var (
@@ -51,7 +74,13 @@ var (
 )
 
 type psrpcMetricsOptions struct {
-	curryLabels prometheus.Labels
+	curryLabels       prometheus.Labels
+	buckets           []float64
+	nativeFactor      float64
+	nativeMaxBuckets  uint32
+	exemplars         bool
+	topicMethods      []string
+	maxTopicsByMethod int
 }
 
 type PSRPCMetricsOption func(*psrpcMetricsOptions)
@@ -62,6 +91,39 @@ func WithCurryLabels(labels prometheus.Labels) PSRPCMetricsOption {
 	}
 }
 
+// WithHistogramBuckets sets buckets of latency histograms, in milliseconds.
+func WithHistogramBuckets(buckets []float64) PSRPCMetricsOption {
+	return func(o *psrpcMetricsOptions) {
+		o.buckets = buckets
+	}
+}
+
+// WithNativeHistograms enables native histograms for latency, in addition to classic buckets.
+// The factor sets the growth of bucket bounds, e.g. 1.1, and maxBuckets limits their number.
+func WithNativeHistograms(factor float64, maxBuckets uint32) PSRPCMetricsOption {
+	return func(o *psrpcMetricsOptions) {
+		o.nativeFactor = factor
+		o.nativeMaxBuckets = maxBuckets
+	}
+}
+
+// WithExemplars attaches trace IDs of traced requests to latency observations.
+func WithExemplars() PSRPCMetricsOption {
+	return func(o *psrpcMetricsOptions) {
+		o.exemplars = true
+	}
+}
+
+// WithTopicLabels sets the topic label of requests and errors for the methods, given as "Service.Method"
+// or "Service". Other methods have an empty topic. To bound cardinality, only the first maxTopics topics
+// of each method get their own label value, the rest are labeled "other".
+func WithTopicLabels(maxTopics int, methods ...string) PSRPCMetricsOption {
+	return func(o *psrpcMetricsOptions) {
+		o.maxTopicsByMethod = maxTopics
+		o.topicMethods = append(o.topicMethods, methods...)
+	}
+}
+
 func InitPSRPCStats(constLabels prometheus.Labels, opts ...PSRPCMetricsOption) {
 	metricsBase.mu.Lock()
 	if metricsBase.initialized {
@@ -72,33 +134,48 @@ func InitPSRPCStats(constLabels prometheus.Labels, opts ...PSRPCMetricsOption) {
 
 	o := psrpcMetricsOptions{
 		curryLabels: prometheus.Labels{},
+		buckets:     defaultRequestTimeBuckets,
 	}
 	for _, opt := range opts {
 		opt(&o)
 	}
 
 	metricsBase.curryLabels = o.curryLabels
+	metricsBase.exemplars = o.exemplars
+	if len(o.topicMethods) != 0 {
+		metricsBase.topics = newTopicLabeler(o.maxTopicsByMethod, o.topicMethods)
+	}
 	curryLabelNames := maps.Keys(o.curryLabels)
 	sort.Strings(curryLabelNames)
 
-	labels := append(curryLabelNames, "role", "kind", "service", "method")
-	streamLabels := append(curryLabelNames, "role", "service", "method")
-	bytesLabels := append(labels, "direction")
+	labels := append(slices.Clip(curryLabelNames), "role", "kind", "service", "method")
+	requestLabels := append(slices.Clip(labels), "topic", "code")
+	streamLabels := append(slices.Clip(curryLabelNames), "role", "service", "method")
+	streamSendLabels := append(slices.Clip(streamLabels), "code")
+	bytesLabels := append(slices.Clip(labels), "direction")
+	circuitLabels := append(slices.Clip(curryLabelNames), "service", "method", "topic")
+	admissionLabels := append(slices.Clip(curryLabelNames), "service", "method", "reason")
 
 	metricsBase.requestTime = prometheus.NewHistogramVec(prometheus.HistogramOpts{
-		Namespace:   livekitNamespace,
-		Subsystem:   "psrpc",
-		Name:        "request_time_ms",
-		ConstLabels: constLabels,
-		Buckets:     []float64{10, 50, 100, 300, 500, 1000, 1500, 2000, 5000, 10000},
-	}, labels)
+		Namespace:                       livekitNamespace,
+		Subsystem:                       "psrpc",
+		Name:                            "request_time_ms",
+		ConstLabels:                     constLabels,
+		Buckets:                         o.buckets,
+		NativeHistogramBucketFactor:     o.nativeFactor,
+		NativeHistogramMaxBucketNumber:  o.nativeMaxBuckets,
+		NativeHistogramMinResetDuration: time.Hour,
+	}, requestLabels)
 	metricsBase.streamSendTime = prometheus.NewHistogramVec(prometheus.HistogramOpts{
-		Namespace:   livekitNamespace,
-		Subsystem:   "psrpc",
-		Name:        "stream_send_time_ms",
-		ConstLabels: constLabels,
-		Buckets:     []float64{10, 50, 100, 300, 500, 1000, 1500, 2000, 5000, 10000},
-	}, streamLabels)
+		Namespace:                       livekitNamespace,
+		Subsystem:                       "psrpc",
+		Name:                            "stream_send_time_ms",
+		ConstLabels:                     constLabels,
+		Buckets:                         o.buckets,
+		NativeHistogramBucketFactor:     o.nativeFactor,
+		NativeHistogramMaxBucketNumber:  o.nativeMaxBuckets,
+		NativeHistogramMinResetDuration: time.Hour,
+	}, streamSendLabels)
 	metricsBase.streamReceiveTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
 		Namespace:   livekitNamespace,
 		Subsystem:   "psrpc",
@@ -116,13 +193,32 @@ func InitPSRPCStats(constLabels prometheus.Labels, opts ...PSRPCMetricsOption) {
 		Subsystem:   "psrpc",
 		Name:        "error_total",
 		ConstLabels: constLabels,
-	}, labels)
+	}, requestLabels)
 	metricsBase.bytesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
 		Namespace:   livekitNamespace,
 		Subsystem:   "psrpc",
 		Name:        "bytes_total",
 		ConstLabels: constLabels,
 	}, bytesLabels)
+	metricsBase.circuitState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "psrpc",
+		Name:        "circuit_state",
+		Help:        "Client circuit breaker state: 0 closed, 1 half-open, 2 open",
+		ConstLabels: constLabels,
+	}, circuitLabels)
+	metricsBase.circuitRejectedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "psrpc",
+		Name:        "circuit_rejected_total",
+		ConstLabels: constLabels,
+	}, circuitLabels)
+	metricsBase.admissionRejectedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "psrpc",
+		Name:        "admission_rejected_total",
+		ConstLabels: constLabels,
+	}, admissionLabels)
 
 	metricsBase.mu.Unlock()
 
@@ -132,6 +228,9 @@ func InitPSRPCStats(constLabels prometheus.Labels, opts ...PSRPCMetricsOption) {
 	prometheus.MustRegister(metricsBase.streamCurrent)
 	prometheus.MustRegister(metricsBase.errorTotal)
 	prometheus.MustRegister(metricsBase.bytesTotal)
+	prometheus.MustRegister(metricsBase.circuitState)
+	prometheus.MustRegister(metricsBase.circuitRejectedTotal)
+	prometheus.MustRegister(metricsBase.admissionRejectedTotal)
 
 	CurryMetricLabels(o.curryLabels)
 }
@@ -156,23 +255,42 @@ func CurryMetricLabels(labels prometheus.Labels) {
 		streamCurrent:      metricsBase.streamCurrent.MustCurryWith(metricsBase.curryLabels),
 		errorTotal:         metricsBase.errorTotal.MustCurryWith(metricsBase.curryLabels),
 		bytesTotal:         metricsBase.bytesTotal.MustCurryWith(metricsBase.curryLabels),
+
+		circuitState:         metricsBase.circuitState.MustCurryWith(metricsBase.curryLabels),
+		circuitRejectedTotal: metricsBase.circuitRejectedTotal.MustCurryWith(metricsBase.curryLabels),
+
+		admissionRejectedTotal: metricsBase.admissionRejectedTotal.MustCurryWith(metricsBase.curryLabels),
+
+		exemplars: metricsBase.exemplars,
+		topics:    metricsBase.topics,
 	})
 }
 
-var _ middleware.MetricsObserver = PSRPCMetricsObserver{}
+// ContextMetricsObserver is a MetricsObserver which also receives the context of unary requests.
+// WithClientMetrics and WithServerMetrics call OnUnaryRequestContext instead of OnUnaryRequest for it.
+type ContextMetricsObserver interface {
+	middleware.MetricsObserver
+	OnUnaryRequestContext(ctx context.Context, role middleware.MetricRole, rpcInfo psrpc.RPCInfo, duration time.Duration, err error, rxBytes, txBytes int)
+}
+
+var _ ContextMetricsObserver = PSRPCMetricsObserver{}
 
 type PSRPCMetricsObserver struct{}
 
 func (o PSRPCMetricsObserver) OnUnaryRequest(role middleware.MetricRole, info psrpc.RPCInfo, duration time.Duration, err error, rxBytes, txBytes int) {
+	o.OnUnaryRequestContext(context.Background(), role, info, duration, err, rxBytes, txBytes)
+}
+
+func (o PSRPCMetricsObserver) OnUnaryRequestContext(ctx context.Context, role middleware.MetricRole, info psrpc.RPCInfo, duration time.Duration, err error, rxBytes, txBytes int) {
 	m := metrics.Load()
 	m.bytesTotal.WithLabelValues(role.String(), "rpc", info.Service, info.Method, "rx").Add(float64(rxBytes))
 	m.bytesTotal.WithLabelValues(role.String(), "rpc", info.Service, info.Method, "tx").Add(float64(txBytes))
 
+	topic, code := m.topics.label(info), errorCode(err)
 	if err != nil {
-		m.errorTotal.WithLabelValues(role.String(), "rpc", info.Service, info.Method).Inc()
-	} else {
-		m.requestTime.WithLabelValues(role.String(), "rpc", info.Service, info.Method).Observe(float64(duration.Milliseconds()))
+		m.errorTotal.WithLabelValues(role.String(), "rpc", info.Service, info.Method, topic, code).Inc()
 	}
+	m.observe(ctx, m.requestTime.WithLabelValues(role.String(), "rpc", info.Service, info.Method, topic, code), duration)
 }
 
 func (o PSRPCMetricsObserver) OnMultiRequest(role middleware.MetricRole, info psrpc.RPCInfo, duration time.Duration, responseCount, errorCount, rxBytes, txBytes int) {
@@ -180,22 +298,23 @@ func (o PSRPCMetricsObserver) OnMultiRequest(role middleware.MetricRole, info ps
 	m.bytesTotal.WithLabelValues(role.String(), "multirpc", info.Service, info.Method, "rx").Add(float64(rxBytes))
 	m.bytesTotal.WithLabelValues(role.String(), "multirpc", info.Service, info.Method, "tx").Add(float64(txBytes))
 
+	topic, code := m.topics.label(info), codeOK
 	if responseCount == 0 {
-		m.errorTotal.WithLabelValues(role.String(), "multirpc", info.Service, info.Method).Inc()
-	} else {
-		m.requestTime.WithLabelValues(role.String(), "multirpc", info.Service, info.Method).Observe(float64(duration.Milliseconds()))
+		code = codeNoResponse
+		m.errorTotal.WithLabelValues(role.String(), "multirpc", info.Service, info.Method, topic, code).Inc()
 	}
+	m.requestTime.WithLabelValues(role.String(), "multirpc", info.Service, info.Method, topic, code).Observe(float64(duration.Milliseconds()))
 }
 
 func (o PSRPCMetricsObserver) OnStreamSend(role middleware.MetricRole, info psrpc.RPCInfo, duration time.Duration, err error, bytes int) {
 	m := metrics.Load()
 	m.bytesTotal.WithLabelValues(role.String(), "stream", info.Service, info.Method, "tx").Add(float64(bytes))
 
+	code := errorCode(err)
 	if err != nil {
-		m.errorTotal.WithLabelValues(role.String(), "stream", info.Service, info.Method).Inc()
-	} else {
-		m.streamSendTime.WithLabelValues(role.String(), info.Service, info.Method).Observe(float64(duration.Milliseconds()))
+		m.errorTotal.WithLabelValues(role.String(), "stream", info.Service, info.Method, m.topics.label(info), code).Inc()
 	}
+	m.streamSendTime.WithLabelValues(role.String(), info.Service, info.Method, code).Observe(float64(duration.Milliseconds()))
 }
 
 func (o PSRPCMetricsObserver) OnStreamRecv(role middleware.MetricRole, info psrpc.RPCInfo, err error, bytes int) {
@@ -203,7 +322,7 @@ func (o PSRPCMetricsObserver) OnStreamRecv(role middleware.MetricRole, info psrp
 	m.bytesTotal.WithLabelValues(role.String(), "stream", info.Service, info.Method, "rx").Add(float64(bytes))
 
 	if err != nil {
-		m.errorTotal.WithLabelValues(role.String(), "stream", info.Service, info.Method).Inc()
+		m.errorTotal.WithLabelValues(role.String(), "stream", info.Service, info.Method, m.topics.label(info), errorCode(err)).Inc()
 	} else {
 		m.streamReceiveTotal.WithLabelValues(role.String(), info.Service, info.Method).Inc()
 	}
@@ -219,6 +338,153 @@ func (o PSRPCMetricsObserver) OnStreamClose(role middleware.MetricRole, info psr
 	m.streamCurrent.WithLabelValues(role.String(), info.Service, info.Method).Dec()
 }
 
+// observe records a duration in milliseconds, with the trace ID of the request as an exemplar if enabled.
+func (m *psrpcMetrics) observe(ctx context.Context, o prometheus.Observer, duration time.Duration) {
+	value := float64(duration.Milliseconds())
+	if m.exemplars {
+		if eo, ok := o.(prometheus.ExemplarObserver); ok {
+			sc := trace.SpanContextFromContext(ctx)
+			if !sc.IsValid() {
+				sc = trace.SpanContextFromContext(extractTraceContext(ctx))
+			}
+			if sc.IsSampled() {
+				eo.ObserveWithExemplar(value, prometheus.Labels{"trace_id": sc.TraceID().String()})
+				return
+			}
+		}
+	}
+	o.Observe(value)
+}
+
+func errorCode(err error) string {
+	if err == nil {
+		return codeOK
+	}
+	var e psrpc.Error
+	if errors.As(err, &e) {
+		return string(e.Code())
+	}
+	return string(psrpc.Unknown)
+}
+
+type topicLabeler struct {
+	methods   map[string]struct{}
+	maxTopics int
+
+	mu     sync.Mutex
+	topics map[string]map[string]struct{}
+}
+
+func newTopicLabeler(maxTopics int, methods []string) *topicLabeler {
+	l := &topicLabeler{
+		methods:   make(map[string]struct{}, len(methods)),
+		maxTopics: maxTopics,
+		topics:    make(map[string]map[string]struct{}),
+	}
+	for _, m := range methods {
+		l.methods[m] = struct{}{}
+	}
+	return l
+}
+
+func (l *topicLabeler) label(info psrpc.RPCInfo) string {
+	if l == nil || len(info.Topic) == 0 {
+		return ""
+	}
+	key := info.Service + "." + info.Method
+	if _, ok := l.methods[key]; !ok {
+		if _, ok := l.methods[info.Service]; !ok {
+			return ""
+		}
+	}
+
+	return l.labelTopic(key, strings.Join(info.Topic, "."))
+}
+
+// labelTopic returns the topic itself for the first maxTopics topics of a method, and "other" for the rest.
+func (l *topicLabeler) labelTopic(key, topic string) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	topics, ok := l.topics[key]
+	if !ok {
+		topics = make(map[string]struct{})
+		l.topics[key] = topics
+	}
+	if _, ok := topics[topic]; ok {
+		return topic
+	}
+	if len(topics) >= l.maxTopics {
+		return topicOther
+	}
+	topics[topic] = struct{}{}
+	return topic
+}
+
+// WithClientMetrics is like middleware.WithClientMetrics, but passes the context of unary requests
+// to a ContextMetricsObserver.
+func WithClientMetrics(observer middleware.MetricsObserver) psrpc.ClientOption {
+	co, ok := observer.(ContextMetricsObserver)
+	if !ok {
+		return middleware.WithClientMetrics(observer)
+	}
+	return psrpc.WithClientOptions(
+		middleware.WithClientMetrics(contextMetricsObserver{co, false}),
+		psrpc.WithClientRPCInterceptors(func(rpcInfo psrpc.RPCInfo, next psrpc.ClientRPCHandler) psrpc.ClientRPCHandler {
+			return func(ctx context.Context, req proto.Message, opts ...psrpc.RequestOption) (res proto.Message, err error) {
+				start := time.Now()
+				defer func() {
+					co.OnUnaryRequestContext(ctx, middleware.ClientRole, rpcInfo, time.Since(start), err, proto.Size(req), proto.Size(res))
+				}()
+				return next(ctx, req, opts...)
+			}
+		}),
+	)
+}
+
+// WithServerMetrics is like middleware.WithServerMetrics, but passes the context of unary requests
+// to a ContextMetricsObserver.
+func WithServerMetrics(observer middleware.MetricsObserver) psrpc.ServerOption {
+	co, ok := observer.(ContextMetricsObserver)
+	if !ok {
+		return middleware.WithServerMetrics(observer)
+	}
+	return psrpc.WithServerOptions(
+		middleware.WithServerMetrics(contextMetricsObserver{co, true}),
+		psrpc.WithServerRPCInterceptors(func(ctx context.Context, req proto.Message, rpcInfo psrpc.RPCInfo, handler psrpc.ServerRPCHandler) (res proto.Message, err error) {
+			start := time.Now()
+			defer func() {
+				if rpcInfo.Multi {
+					var responseCount, errorCount int
+					if err == nil {
+						responseCount++
+					} else {
+						errorCount++
+					}
+					co.OnMultiRequest(middleware.ServerRole, rpcInfo, time.Since(start), responseCount, errorCount, proto.Size(req), proto.Size(res))
+				} else {
+					co.OnUnaryRequestContext(ctx, middleware.ServerRole, rpcInfo, time.Since(start), err, proto.Size(req), proto.Size(res))
+				}
+			}()
+			return handler(ctx, req)
+		}),
+	)
+}
+
+// contextMetricsObserver drops requests recorded by the interceptors of WithClientMetrics and WithServerMetrics.
+type contextMetricsObserver struct {
+	ContextMetricsObserver
+	server bool
+}
+
+func (o contextMetricsObserver) OnUnaryRequest(role middleware.MetricRole, rpcInfo psrpc.RPCInfo, duration time.Duration, err error, rxBytes, txBytes int) {
+}
+
+func (o contextMetricsObserver) OnMultiRequest(role middleware.MetricRole, rpcInfo psrpc.RPCInfo, duration time.Duration, responseCount, errorCount, rxBytes, txBytes int) {
+	if !o.server {
+		o.ContextMetricsObserver.OnMultiRequest(role, rpcInfo, duration, responseCount, errorCount, rxBytes, txBytes)
+	}
+}
+
 var _ middleware.MetricsObserver = UnimplementedMetricsObserver{}
 
 type UnimplementedMetricsObserver struct{}
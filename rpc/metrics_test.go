@@ -0,0 +1,103 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/tracer"
+	"github.com/livekit/protocol/tracer/tracertest"
+	"github.com/livekit/psrpc"
+)
+
+func TestPSRPCMetrics(t *testing.T) {
+	InitPSRPCStats(prometheus.Labels{"test": "metrics"},
+		WithHistogramBuckets([]float64{1, 10, 100}),
+		WithNativeHistograms(1.1, 100),
+		WithExemplars(),
+		WithTopicLabels(1, "RoomManager.CreateRoom"),
+	)
+
+	tr, _ := tracertest.NewInMemoryTracer()
+	tracer.SetTracer(tr)
+	t.Cleanup(func() {
+		tracer.SetTracer(&tracer.NoOpTracer{})
+	})
+
+	bus := psrpc.NewLocalMessageBus()
+	server, err := NewRoomManagerServer[string](&tracingRoomManager{rooms: map[string]bool{"room": true}}, bus, WithServerMetrics(PSRPCMetricsObserver{}))
+	require.NoError(t, err)
+	require.NoError(t, server.RegisterCreateRoomTopic("a"))
+	require.NoError(t, server.RegisterCreateRoomTopic("b"))
+	t.Cleanup(server.Kill)
+
+	client, err := NewRoomManagerClient[string](bus, WithClientTracer(), WithClientMetrics(PSRPCMetricsObserver{}))
+	require.NoError(t, err)
+	t.Cleanup(client.Close)
+
+	_, err = client.CreateRoom(context.Background(), "a", &livekit.CreateRoomRequest{Name: "room"})
+	require.NoError(t, err)
+	_, err = client.CreateRoom(context.Background(), "b", &livekit.CreateRoomRequest{Name: "missing"})
+	require.Error(t, err)
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	byName := make(map[string]*dto.MetricFamily)
+	for _, f := range families {
+		byName[f.GetName()] = f
+	}
+
+	requestTime := byName["livekit_psrpc_request_time_ms"]
+	require.NotNil(t, requestTime)
+	type key struct{ role, topic, code string }
+	histograms := make(map[key]*dto.Histogram)
+	for _, m := range requestTime.Metric {
+		labels := make(map[string]string)
+		for _, l := range m.Label {
+			labels[l.GetName()] = l.GetValue()
+		}
+		histograms[key{labels["role"], labels["topic"], labels["code"]}] = m.Histogram
+	}
+	require.Len(t, histograms, 4)
+
+	ok := histograms[key{"client", "a", "ok"}]
+	require.NotNil(t, ok)
+	require.Len(t, ok.Bucket, 3)
+	require.NotNil(t, ok.Schema)
+
+	// Failed requests are observed with their error code, topics over the limit are labeled "other".
+	require.NotNil(t, histograms[key{"client", "other", "not_found"}])
+	require.NotNil(t, histograms[key{"server", "other", "not_found"}])
+
+	// The client span is the parent of the server one, so both are observed with the same trace ID.
+	var traceIDs []string
+	for _, h := range []*dto.Histogram{ok, histograms[key{"server", "a", "ok"}]} {
+		var found string
+		for _, e := range h.Exemplars {
+			found = e.Label[0].GetValue()
+		}
+		for _, b := range h.Bucket {
+			if b.Exemplar != nil {
+				found = b.Exemplar.Label[0].GetValue()
+			}
+		}
+		require.NotEmpty(t, found)
+		traceIDs = append(traceIDs, found)
+	}
+	require.Equal(t, traceIDs[0], traceIDs[1])
+
+	errors := byName["livekit_psrpc_error_total"]
+	require.NotNil(t, errors)
+	require.Len(t, errors.Metric, 2)
+	for _, m := range errors.Metric {
+		for _, l := range m.Label {
+			if l.GetName() == "code" {
+				require.Equal(t, "not_found", l.GetValue())
+			}
+		}
+	}
+}
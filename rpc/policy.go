@@ -0,0 +1,297 @@
+// Copyright 2025 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"slices"
+	"strings"
+	"time"
+
+	"go.uber.org/multierr"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v3"
+
+	"github.com/livekit/protocol/utils"
+	"github.com/livekit/psrpc"
+	"github.com/livekit/psrpc/pkg/middleware"
+)
+
+// DefaultRetryableCodes are retried when a policy does not list its own codes.
+var DefaultRetryableCodes = []psrpc.ErrorCode{
+	psrpc.DeadlineExceeded,
+	psrpc.Unavailable,
+	psrpc.ResourceExhausted,
+}
+
+var knownErrorCodes = []psrpc.ErrorCode{
+	psrpc.Canceled,
+	psrpc.MalformedRequest,
+	psrpc.MalformedResponse,
+	psrpc.DeadlineExceeded,
+	psrpc.Unavailable,
+	psrpc.Unknown,
+	psrpc.InvalidArgument,
+	psrpc.NotFound,
+	psrpc.NotAcceptable,
+	psrpc.AlreadyExists,
+	psrpc.PermissionDenied,
+	psrpc.ResourceExhausted,
+	psrpc.FailedPrecondition,
+	psrpc.Aborted,
+	psrpc.OutOfRange,
+	psrpc.Unimplemented,
+	psrpc.Internal,
+	psrpc.DataLoss,
+	psrpc.Unauthenticated,
+}
+
+// RPCPolicy controls retries, timeouts and hedging of a PSRPC method.
+type RPCPolicy struct {
+	// MaxAttempts including the first one. Zero or one disables retries.
+	MaxAttempts int `yaml:"max_attempts,omitempty"`
+	// Timeout of each attempt. Zero uses the client default.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// Backoff before the first retry. It doubles for each next retry, up to MaxBackoff.
+	Backoff time.Duration `yaml:"backoff,omitempty"`
+	// MaxBackoff limits the backoff. Zero means no limit.
+	MaxBackoff time.Duration `yaml:"max_backoff,omitempty"`
+	// Jitter adds a random delay of up to a given fraction of the backoff, from 0 to 1. The result is still limited by MaxBackoff.
+	Jitter float64 `yaml:"jitter,omitempty"`
+	// RetryableCodes lists error codes that are retried. DefaultRetryableCodes are used if empty.
+	// Errors which are not PSRPC errors are treated as psrpc.Unknown, so they are not retried by default.
+	RetryableCodes []psrpc.ErrorCode `yaml:"retryable_codes,omitempty"`
+	// HedgeDelay enables hedging: a new attempt starts if the previous one did not complete within the delay,
+	// and the first successful response wins. Only use it for idempotent methods.
+	HedgeDelay time.Duration `yaml:"hedge_delay,omitempty"`
+}
+
+// PSRPCPolicies maps methods to their policies. Keys are either "Service.Method", like "EgressInternal.StartEgress",
+// or "Service" to apply a policy to all methods of the service. Method keys take precedence.
+type PSRPCPolicies map[string]RPCPolicy
+
+// ParsePSRPCPolicies reads a policy table in YAML format:
+//
+//	SIPInternal.CreateSIPParticipant:
+//	  max_attempts: 3
+//	  timeout: 10s
+//	  backoff: 500ms
+//	  jitter: 0.5
+//	  retryable_codes: [unavailable]
+//	IOInfo:
+//	  max_attempts: 2
+//	  timeout: 1s
+//	  hedge_delay: 200ms
+func ParsePSRPCPolicies(r io.Reader) (PSRPCPolicies, error) {
+	var p PSRPCPolicies
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	if err := dec.Decode(&p); err != nil && err != io.EOF {
+		return nil, err
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Validate checks policy keys and values.
+func (p PSRPCPolicies) Validate() error {
+	for key, policy := range p {
+		service, method, _ := strings.Cut(key, ".")
+		if service == "" || strings.Contains(method, ".") {
+			return fmt.Errorf("invalid psrpc policy key %q", key)
+		}
+		if err := policy.Validate(); err != nil {
+			return fmt.Errorf("psrpc policy %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks policy values.
+func (p *RPCPolicy) Validate() error {
+	if p.MaxAttempts < 0 || p.Timeout < 0 || p.Backoff < 0 || p.MaxBackoff < 0 || p.HedgeDelay < 0 {
+		return errors.New("negative values are not allowed")
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return errors.New("jitter must be between 0 and 1")
+	}
+	for _, code := range p.RetryableCodes {
+		if !slices.Contains(knownErrorCodes, code) {
+			return fmt.Errorf("unknown error code %q", code)
+		}
+	}
+	return nil
+}
+
+// Lookup returns the policy for a method.
+func (p PSRPCPolicies) Lookup(service, method string) (RPCPolicy, bool) {
+	if policy, ok := p[service+"."+method]; ok {
+		return policy, true
+	}
+	policy, ok := p[service]
+	return policy, ok
+}
+
+// HasService checks if any policy applies to methods of a service.
+func (p PSRPCPolicies) HasService(service string) bool {
+	for key := range p {
+		if s, _, _ := strings.Cut(key, "."); s == service {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRetryable checks if an error should be retried. Canceled and expired contexts are never retried,
+// and errors other than psrpc.Error are treated as psrpc.Unknown.
+func (p *RPCPolicy) IsRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	code := psrpc.Unknown
+	var e psrpc.Error
+	if errors.As(err, &e) {
+		code = e.Code()
+	}
+	codes := p.RetryableCodes
+	if len(codes) == 0 {
+		codes = DefaultRetryableCodes
+	}
+	return slices.Contains(codes, code)
+}
+
+// BackoffFor returns the delay before a given retry, starting from 1.
+func (p *RPCPolicy) BackoffFor(retry int) time.Duration {
+	if p.Backoff == 0 || retry < 1 {
+		return 0
+	}
+	backoff := float64(p.Backoff) * math.Pow(2, float64(retry-1))
+	backoff *= 1 + p.Jitter*rand.Float64()
+	if p.MaxBackoff != 0 && backoff > float64(p.MaxBackoff) {
+		backoff = float64(p.MaxBackoff)
+	}
+	return time.Duration(backoff)
+}
+
+func (p *RPCPolicy) retryOptions() middleware.RetryOptions {
+	return middleware.RetryOptions{
+		Timeout: p.Timeout,
+		GetRetryParameters: func(err error, attempt int) (bool, time.Duration, time.Duration) {
+			if attempt >= p.MaxAttempts || !p.IsRetryable(err) {
+				return false, 0, 0
+			}
+			// Wait at least as long as the server asked to.
+			backoff := p.BackoffFor(attempt)
+			if retryAfter, ok := RetryAfter(err); ok {
+				backoff = max(backoff, retryAfter)
+			}
+			return true, p.Timeout, backoff
+		},
+	}
+}
+
+// hedge runs attempts with utils.HedgeCall. The whole call is limited by the time the last attempt
+// would complete if all of them timed out.
+func (p *RPCPolicy) hedge(next psrpc.ClientRPCHandler) psrpc.ClientRPCHandler {
+	return func(ctx context.Context, req proto.Message, opts ...psrpc.RequestOption) (proto.Message, error) {
+		attemptOpts := opts
+		if p.Timeout > 0 {
+			attemptOpts = append(slices.Clip(opts), psrpc.WithRequestTimeout(p.Timeout))
+		}
+		timeout := p.Timeout
+		if timeout == 0 {
+			timeout = psrpc.DefaultClientTimeout
+		}
+		res, err := utils.HedgeCall(ctx, utils.HedgeParams[proto.Message]{
+			Timeout:     timeout + time.Duration(p.MaxAttempts-1)*p.HedgeDelay,
+			RetryDelay:  p.HedgeDelay,
+			MaxAttempts: max(p.MaxAttempts, 1),
+			IsRecoverable: func(err error) bool {
+				return ctx.Err() == nil && p.IsRetryable(err)
+			},
+			Func: func(ctx context.Context) (proto.Message, error) {
+				return next(ctx, req, attemptOpts...)
+			},
+		})
+		if err != nil {
+			// HedgeCall combines errors of all attempts, return the first one to keep the error code.
+			if errs := multierr.Errors(err); len(errs) != 0 {
+				err = errs[0]
+			}
+		}
+		return res, err
+	}
+}
+
+// withRetryAfter makes middleware.RetryOptions wait as long as the server asked to in a RetryInfo detail,
+// and also retry errors carrying it, like rejections by admission control. Otherwise, the options work
+// the same way as in middleware: timeout grows by Backoff with each attempt, and only DeadlineExceeded,
+// Unavailable and non-PSRPC errors are retried by default.
+func withRetryAfter(o middleware.RetryOptions) middleware.RetryOptions {
+	if o.GetRetryParameters != nil {
+		return o
+	}
+	isRecoverable := o.IsRecoverable
+	if isRecoverable == nil {
+		isRecoverable = func(err error) bool {
+			var e psrpc.Error
+			if !errors.As(err, &e) {
+				return true
+			}
+			return e.Code() == psrpc.DeadlineExceeded || e.Code() == psrpc.Unavailable
+		}
+	}
+	o.GetRetryParameters = func(err error, attempt int) (bool, time.Duration, time.Duration) {
+		retryAfter, ok := RetryAfter(err)
+		if attempt == o.MaxAttempts || !(ok || isRecoverable(err)) {
+			return false, 0, 0
+		}
+		return true, o.Timeout + time.Duration(attempt)*o.Backoff, retryAfter
+	}
+	return o
+}
+
+// WithRPCRetries is like middleware.WithRPCRetries, but respects RetryInfo details of errors, see RetryAfter.
+func WithRPCRetries(o middleware.RetryOptions) psrpc.ClientOption {
+	return psrpc.WithClientRPCInterceptors(middleware.NewRPCRetryInterceptor(withRetryAfter(o)))
+}
+
+// NewRPCPolicyInterceptor applies policies to matching methods. Other methods use the fallback retry options, if set.
+func NewRPCPolicyInterceptor(policies PSRPCPolicies, fallback *middleware.RetryOptions) psrpc.ClientRPCInterceptor {
+	var fallbackInterceptor psrpc.ClientRPCInterceptor
+	if fallback != nil {
+		fallbackInterceptor = middleware.NewRPCRetryInterceptor(withRetryAfter(*fallback))
+	}
+	return func(rpcInfo psrpc.RPCInfo, next psrpc.ClientRPCHandler) psrpc.ClientRPCHandler {
+		policy, ok := policies.Lookup(rpcInfo.Service, rpcInfo.Method)
+		switch {
+		case !ok && fallbackInterceptor != nil:
+			return fallbackInterceptor(rpcInfo, next)
+		case !ok:
+			return next
+		case policy.HedgeDelay > 0 && policy.MaxAttempts > 1:
+			return policy.hedge(next)
+		default:
+			return middleware.NewRPCRetryInterceptor(policy.retryOptions())(rpcInfo, next)
+		}
+	}
+}
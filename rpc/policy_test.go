@@ -0,0 +1,199 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/psrpc"
+)
+
+func TestParsePSRPCPolicies(t *testing.T) {
+	p, err := ParsePSRPCPolicies(strings.NewReader(`
+RoomManager.CreateRoom:
+  max_attempts: 3
+  timeout: 10s
+  backoff: 500ms
+  jitter: 0.5
+  retryable_codes: [unavailable]
+RoomManager:
+  max_attempts: 2
+  hedge_delay: 200ms
+`))
+	require.NoError(t, err)
+	require.Equal(t, PSRPCPolicies{
+		"RoomManager.CreateRoom": {
+			MaxAttempts:    3,
+			Timeout:        10 * time.Second,
+			Backoff:        500 * time.Millisecond,
+			Jitter:         0.5,
+			RetryableCodes: []psrpc.ErrorCode{psrpc.Unavailable},
+		},
+		"RoomManager": {
+			MaxAttempts: 2,
+			HedgeDelay:  200 * time.Millisecond,
+		},
+	}, p)
+
+	policy, ok := p.Lookup("RoomManager", "CreateRoom")
+	require.True(t, ok)
+	require.Equal(t, 3, policy.MaxAttempts)
+	policy, ok = p.Lookup("RoomManager", "Other")
+	require.True(t, ok)
+	require.Equal(t, 2, policy.MaxAttempts)
+	_, ok = p.Lookup("EgressInternal", "StartEgress")
+	require.False(t, ok)
+	require.True(t, p.HasService("RoomManager"))
+	require.False(t, p.HasService("Room"))
+
+	p, err = ParsePSRPCPolicies(strings.NewReader(""))
+	require.NoError(t, err)
+	require.Empty(t, p)
+
+	for _, s := range []string{
+		"A.B.C: {max_attempts: 1}",
+		"A: {unknown: 1}",
+		"A: {max_attempts: -1}",
+		"A: {jitter: 2}",
+		"A: {retryable_codes: [bogus]}",
+	} {
+		_, err = ParsePSRPCPolicies(strings.NewReader(s))
+		require.Error(t, err, s)
+	}
+}
+
+func TestRPCPolicyBackoff(t *testing.T) {
+	p := RPCPolicy{Backoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}
+	require.Equal(t, time.Duration(0), p.BackoffFor(0))
+	require.Equal(t, 100*time.Millisecond, p.BackoffFor(1))
+	require.Equal(t, 200*time.Millisecond, p.BackoffFor(2))
+	require.Equal(t, 300*time.Millisecond, p.BackoffFor(3))
+
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		d := p.BackoffFor(2)
+		require.GreaterOrEqual(t, d, 200*time.Millisecond)
+		require.LessOrEqual(t, d, 300*time.Millisecond)
+		// Jitter does not exceed MaxBackoff.
+		require.Equal(t, 300*time.Millisecond, p.BackoffFor(3))
+	}
+}
+
+func TestRPCPolicyRetryable(t *testing.T) {
+	p := RPCPolicy{}
+	require.True(t, p.IsRetryable(psrpc.NewErrorf(psrpc.Unavailable, "")))
+	require.False(t, p.IsRetryable(psrpc.NewErrorf(psrpc.NotFound, "")))
+	require.True(t, p.IsRetryable(psrpc.ErrRequestTimedOut))
+	require.False(t, p.IsRetryable(psrpc.ErrRequestCanceled))
+	require.False(t, p.IsRetryable(context.DeadlineExceeded))
+	require.False(t, p.IsRetryable(fmt.Errorf("call: %w", context.Canceled)))
+	require.False(t, p.IsRetryable(errors.New("unknown")))
+
+	p.RetryableCodes = []psrpc.ErrorCode{psrpc.NotFound}
+	require.False(t, p.IsRetryable(psrpc.NewErrorf(psrpc.Unavailable, "")))
+	require.True(t, p.IsRetryable(psrpc.NewErrorf(psrpc.NotFound, "")))
+
+	p.RetryableCodes = []psrpc.ErrorCode{psrpc.Unknown}
+	require.True(t, p.IsRetryable(errors.New("unknown")))
+}
+
+type flakyRoomManager struct {
+	calls    atomic.Int32
+	failures int32
+	code     psrpc.ErrorCode
+	delay    time.Duration
+}
+
+func (s *flakyRoomManager) CreateRoom(ctx context.Context, req *livekit.CreateRoomRequest) (*livekit.Room, error) {
+	if n := s.calls.Add(1); n <= s.failures {
+		if s.delay != 0 {
+			time.Sleep(s.delay)
+		}
+		return nil, psrpc.NewErrorf(s.code, "attempt %d failed", n)
+	}
+	return &livekit.Room{Name: req.Name}, nil
+}
+
+func newPolicyTestClient(t *testing.T, svc *flakyRoomManager, params ClientParams) RoomManagerClient[string] {
+	bus := psrpc.NewLocalMessageBus()
+	server, err := NewRoomManagerServer[string](svc, bus)
+	require.NoError(t, err)
+	require.NoError(t, server.RegisterCreateRoomTopic("node"))
+	t.Cleanup(server.Kill)
+
+	client, err := NewRoomManagerClient[string](bus, params.Options()...)
+	require.NoError(t, err)
+	t.Cleanup(client.Close)
+	return client
+}
+
+func TestRPCPolicyInterceptor(t *testing.T) {
+	ctx := context.Background()
+	req := &livekit.CreateRoomRequest{Name: "room"}
+
+	t.Run("retry", func(t *testing.T) {
+		svc := &flakyRoomManager{failures: 2, code: psrpc.Unavailable}
+		client := newPolicyTestClient(t, svc, ClientParams{PSRPCConfig: PSRPCConfig{Policies: PSRPCPolicies{
+			"RoomManager.CreateRoom": {MaxAttempts: 3, Timeout: time.Second, Backoff: time.Millisecond},
+		}}})
+		_, err := client.CreateRoom(ctx, "node", req)
+		require.NoError(t, err)
+		require.EqualValues(t, 3, svc.calls.Load())
+	})
+
+	t.Run("attempts exhausted", func(t *testing.T) {
+		svc := &flakyRoomManager{failures: 5, code: psrpc.Unavailable}
+		client := newPolicyTestClient(t, svc, ClientParams{PSRPCConfig: PSRPCConfig{Policies: PSRPCPolicies{
+			"RoomManager": {MaxAttempts: 2, Timeout: time.Second},
+		}}})
+		_, err := client.CreateRoom(ctx, "node", req)
+		require.Error(t, err)
+		require.EqualValues(t, 2, svc.calls.Load())
+	})
+
+	t.Run("not retryable", func(t *testing.T) {
+		svc := &flakyRoomManager{failures: 1, code: psrpc.NotFound}
+		client := newPolicyTestClient(t, svc, ClientParams{PSRPCConfig: PSRPCConfig{Policies: PSRPCPolicies{
+			"RoomManager.CreateRoom": {MaxAttempts: 3, Timeout: time.Second},
+		}}})
+		_, err := client.CreateRoom(ctx, "node", req)
+		var e psrpc.Error
+		require.ErrorAs(t, err, &e)
+		require.Equal(t, psrpc.NotFound, e.Code())
+		require.EqualValues(t, 1, svc.calls.Load())
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		svc := &flakyRoomManager{failures: 1, code: psrpc.Unavailable}
+		client := newPolicyTestClient(t, svc, ClientParams{PSRPCConfig: PSRPCConfig{
+			MaxAttempts: 2,
+			Timeout:     time.Second,
+			Policies: PSRPCPolicies{
+				"EgressInternal": {MaxAttempts: 5},
+			},
+		}})
+		_, err := client.CreateRoom(ctx, "node", req)
+		require.NoError(t, err)
+		require.EqualValues(t, 2, svc.calls.Load())
+	})
+
+	t.Run("hedge", func(t *testing.T) {
+		svc := &flakyRoomManager{failures: 1, code: psrpc.Unavailable, delay: 500 * time.Millisecond}
+		client := newPolicyTestClient(t, svc, ClientParams{PSRPCConfig: PSRPCConfig{Policies: PSRPCPolicies{
+			"RoomManager.CreateRoom": {MaxAttempts: 2, Timeout: time.Second, HedgeDelay: 50 * time.Millisecond},
+		}}})
+		start := time.Now()
+		_, err := client.CreateRoom(ctx, "node", req)
+		require.NoError(t, err)
+		// The second attempt succeeds while the first one is still running.
+		require.Less(t, time.Since(start), 400*time.Millisecond)
+		require.EqualValues(t, 2, svc.calls.Load())
+	})
+}
@@ -0,0 +1,304 @@
+// Copyright 2025 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package rpctest provides an in-memory PSRPC message bus for end-to-end tests of generated clients and servers,
+// with fault injection and recording of published messages.
+package rpctest
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/livekit/protocol/rpc"
+	"github.com/livekit/psrpc"
+	"github.com/livekit/psrpc/testutils"
+)
+
+// Message kinds, as used in PSRPC channel names.
+const (
+	KindRequest       = "REQ"
+	KindResponse      = "RES"
+	KindClaimRequest  = "CLAIM"
+	KindClaimResponse = "RCLAIM"
+	KindStream        = "STR"
+)
+
+// Record is a message published on the bus.
+type Record struct {
+	Time time.Time
+	// From is the node which published the message.
+	From    string
+	Channel string
+	Kind    string
+	Service string
+	// Method and Topic are set for requests and claim responses. Topic parts are joined with dots, and characters
+	// which are not allowed in channel names are escaped.
+	Method string
+	Topic  string
+	// ClientID is set for responses and claim requests.
+	ClientID  string
+	RequestID string
+	// Payload is the serialized request or response, if any.
+	Payload []byte
+}
+
+// Decode unmarshals the payload of a request or response.
+func (r *Record) Decode(msg proto.Message) error {
+	return proto.Unmarshal(r.Payload, msg)
+}
+
+// Bus is an in-memory message bus. Every client and server connects to it through a node, see Node.
+// Faults are injected on delivery to a node, based on the node which published the message.
+type Bus struct {
+	base psrpc.MessageBus
+
+	mu            sync.Mutex
+	rng           *rand.Rand
+	latency       func(from, to string) time.Duration
+	dropRate      float64
+	duplicateRate float64
+	partitions    map[[2]string]struct{}
+	records       []Record
+}
+
+func NewBus() *Bus {
+	return &Bus{
+		base:       psrpc.NewLocalMessageBus(),
+		rng:        rand.New(rand.NewSource(0)),
+		partitions: make(map[[2]string]struct{}),
+	}
+}
+
+// Node returns the bus as seen by a node. Use the same ID for psrpc.WithServerID or psrpc.WithClientID
+// to match nodes with servers and clients in recorded messages.
+func (b *Bus) Node(id string) psrpc.MessageBus {
+	return testutils.NewTestBus(b.base,
+		testutils.WithPublishInterceptor(func(next testutils.PublishHandler) testutils.PublishHandler {
+			return func(ctx context.Context, channel testutils.Channel, msg proto.Message) error {
+				a, err := anypb.New(msg)
+				if err != nil {
+					return err
+				}
+				body, err := proto.Marshal(a)
+				if err != nil {
+					return err
+				}
+				now := time.Now()
+				b.record(id, now, channel.Legacy, msg)
+				return next(ctx, channel, &testutils.LaggyMessage{
+					Origin: id,
+					SentAt: now.UnixNano(),
+					Body:   body,
+				})
+			}
+		}),
+		testutils.WithSubscribeInterceptor(func(ctx context.Context, channel testutils.Channel, next testutils.ReadHandler) testutils.ReadHandler {
+			return b.newReader(id, next)
+		}),
+	)
+}
+
+// ClientParams returns parameters for clients like rpc.NewEgressClient, connected through a node.
+func (b *Bus) ClientParams(id string, config rpc.PSRPCConfig) rpc.ClientParams {
+	return rpc.ClientParams{
+		PSRPCConfig: config,
+		Bus:         b.Node(id),
+	}
+}
+
+// SetLatency delays delivery of all messages.
+func (b *Bus) SetLatency(latency time.Duration) {
+	b.SetLatencyFunc(func(_, _ string) time.Duration {
+		return latency
+	})
+}
+
+// SetLatencyFunc delays delivery of messages from one node to another.
+func (b *Bus) SetLatencyFunc(latency func(from, to string) time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.latency = latency
+}
+
+// SetDropRate sets the probability of a message not being delivered to a node, from 0 to 1.
+func (b *Bus) SetDropRate(rate float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.dropRate = rate
+}
+
+// SetDuplicateRate sets the probability of a message being delivered to a node twice, from 0 to 1.
+func (b *Bus) SetDuplicateRate(rate float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.duplicateRate = rate
+}
+
+// Partition stops delivery of messages between two nodes, in both directions.
+func (b *Bus) Partition(a, c string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.partitions[partitionKey(a, c)] = struct{}{}
+}
+
+// Heal restores delivery of messages between two nodes.
+func (b *Bus) Heal(a, c string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.partitions, partitionKey(a, c))
+}
+
+// HealAll removes all partitions.
+func (b *Bus) HealAll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	clear(b.partitions)
+}
+
+func partitionKey(a, b string) [2]string {
+	if a > b {
+		a, b = b, a
+	}
+	return [2]string{a, b}
+}
+
+// Records returns all messages published since the bus was created or reset.
+func (b *Bus) Records() []Record {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]Record(nil), b.records...)
+}
+
+// Requests returns recorded requests to a method. An empty method matches all methods of the service.
+func (b *Bus) Requests(service, method string) []Record {
+	var out []Record
+	for _, r := range b.Records() {
+		if r.Kind == KindRequest && r.Service == service && (method == "" || r.Method == method) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// Reset clears recorded messages and removes all injected faults.
+func (b *Bus) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.records = nil
+	b.latency = nil
+	b.dropRate = 0
+	b.duplicateRate = 0
+	clear(b.partitions)
+}
+
+func (b *Bus) record(from string, now time.Time, channel string, msg proto.Message) {
+	r := Record{
+		Time:    now,
+		From:    from,
+		Channel: channel,
+	}
+	parts := strings.Split(channel, "|")
+	if len(parts) >= 3 {
+		r.Service, r.Kind = parts[0], parts[len(parts)-1]
+		switch r.Kind {
+		case KindRequest, KindClaimResponse:
+			r.Method = parts[1]
+			r.Topic = strings.Join(parts[2:len(parts)-1], ".")
+		case KindResponse, KindClaimRequest:
+			r.ClientID = parts[1]
+		}
+	}
+
+	m := msg.ProtoReflect()
+	fields := m.Descriptor().Fields()
+	if f := fields.ByName("request_id"); f != nil && f.Kind() == protoreflect.StringKind {
+		r.RequestID = m.Get(f).String()
+	}
+	for _, name := range []protoreflect.Name{"raw_request", "raw_response"} {
+		if f := fields.ByName(name); f != nil && f.Kind() == protoreflect.BytesKind {
+			r.Payload = m.Get(f).Bytes()
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.records = append(b.records, r)
+}
+
+type delivery struct {
+	drop      bool
+	duplicate bool
+	delay     time.Duration
+}
+
+func (b *Bus) deliver(from, to string) delivery {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.partitions[partitionKey(from, to)]; ok {
+		return delivery{drop: true}
+	}
+	if b.dropRate > 0 && b.rng.Float64() < b.dropRate {
+		return delivery{drop: true}
+	}
+	var d delivery
+	if b.duplicateRate > 0 && b.rng.Float64() < b.duplicateRate {
+		d.duplicate = true
+	}
+	if b.latency != nil {
+		d.delay = b.latency(from, to)
+	}
+	return d
+}
+
+func (b *Bus) newReader(id string, next testutils.ReadHandler) testutils.ReadHandler {
+	var pending []byte
+	return func() ([]byte, bool) {
+		if pending != nil {
+			msg := pending
+			pending = nil
+			return msg, true
+		}
+		for {
+			data, ok := next()
+			if !ok {
+				return nil, false
+			}
+
+			a := &anypb.Any{}
+			m := &testutils.LaggyMessage{}
+			if proto.Unmarshal(data, a) != nil || a.UnmarshalTo(m) != nil {
+				return nil, false
+			}
+
+			d := b.deliver(m.Origin, id)
+			if d.drop {
+				continue
+			}
+			if wait := time.Until(time.Unix(0, m.SentAt).Add(d.delay)); wait > 0 {
+				time.Sleep(wait)
+			}
+			if d.duplicate {
+				pending = m.Body
+			}
+			return m.Body, true
+		}
+	}
+}
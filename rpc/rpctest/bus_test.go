@@ -0,0 +1,161 @@
+package rpctest
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/rpc"
+	"github.com/livekit/psrpc"
+)
+
+type testEgressServer struct {
+	id       string
+	affinity float32
+	calls    atomic.Int32
+}
+
+func (s *testEgressServer) StartEgress(ctx context.Context, req *rpc.StartEgressRequest) (*livekit.EgressInfo, error) {
+	s.calls.Add(1)
+	return &livekit.EgressInfo{EgressId: req.EgressId, RoomId: s.id}, nil
+}
+
+func (s *testEgressServer) StartEgressAffinity(ctx context.Context, req *rpc.StartEgressRequest) float32 {
+	return s.affinity
+}
+
+func (s *testEgressServer) ListActiveEgress(ctx context.Context, req *rpc.ListActiveEgressRequest) (*rpc.ListActiveEgressResponse, error) {
+	return &rpc.ListActiveEgressResponse{}, nil
+}
+
+func startEgressServer(t *testing.T, bus *Bus, id string, affinity float32) *testEgressServer {
+	svc := &testEgressServer{id: id, affinity: affinity}
+	server, err := rpc.NewEgressInternalServer(svc, bus.Node(id), psrpc.WithServerID(id))
+	require.NoError(t, err)
+	require.NoError(t, server.RegisterStartEgressTopic(""))
+	t.Cleanup(server.Kill)
+	return svc
+}
+
+func TestBusAffinityPartition(t *testing.T) {
+	bus := NewBus()
+	a := startEgressServer(t, bus, "egress-a", 1)
+	b := startEgressServer(t, bus, "egress-b", 0.5)
+
+	client, err := rpc.NewEgressClient(bus.ClientParams("client", rpc.PSRPCConfig{}))
+	require.NoError(t, err)
+	t.Cleanup(client.Close)
+
+	info, err := client.StartEgress(context.Background(), "", &rpc.StartEgressRequest{EgressId: "EG_1"})
+	require.NoError(t, err)
+	require.Equal(t, "egress-a", info.RoomId)
+
+	bus.Partition("egress-a", "client")
+	info, err = client.StartEgress(context.Background(), "", &rpc.StartEgressRequest{EgressId: "EG_2"})
+	require.NoError(t, err)
+	require.Equal(t, "egress-b", info.RoomId)
+	require.EqualValues(t, 1, a.calls.Load())
+	require.EqualValues(t, 1, b.calls.Load())
+
+	reqs := bus.Requests("EgressInternal", "StartEgress")
+	require.Len(t, reqs, 2)
+	for i, r := range reqs {
+		require.Equal(t, "client", r.From)
+		var req rpc.StartEgressRequest
+		require.NoError(t, r.Decode(&req))
+		require.Equal(t, []string{"EG_1", "EG_2"}[i], req.EgressId)
+	}
+}
+
+type testRoomManager struct {
+	calls atomic.Int32
+}
+
+func (s *testRoomManager) CreateRoom(ctx context.Context, req *livekit.CreateRoomRequest) (*livekit.Room, error) {
+	s.calls.Add(1)
+	return &livekit.Room{Name: req.Name}, nil
+}
+
+func newRoomManager(t *testing.T, bus *Bus, config rpc.PSRPCConfig) (*testRoomManager, rpc.TypedRoomManagerClient) {
+	svc := &testRoomManager{}
+	server, err := rpc.NewTypedRoomManagerServer(svc, bus.Node("node"), psrpc.WithServerID("node"))
+	require.NoError(t, err)
+	require.NoError(t, server.RegisterCreateRoomTopic("node"))
+	t.Cleanup(server.Kill)
+
+	params := bus.ClientParams("client", config)
+	client, err := rpc.NewTypedRoomManagerClient(params.Args())
+	require.NoError(t, err)
+	t.Cleanup(client.Close)
+	return svc, client
+}
+
+func TestBusFaults(t *testing.T) {
+	ctx := context.Background()
+	req := &livekit.CreateRoomRequest{Name: "room"}
+
+	t.Run("drop", func(t *testing.T) {
+		bus := NewBus()
+		svc, client := newRoomManager(t, bus, rpc.PSRPCConfig{})
+		bus.SetDropRate(1)
+		_, err := client.CreateRoom(ctx, "node", req, psrpc.WithRequestTimeout(100*time.Millisecond))
+		require.ErrorIs(t, err, psrpc.ErrNoResponse)
+		require.EqualValues(t, 0, svc.calls.Load())
+		require.Len(t, bus.Requests("RoomManager", "CreateRoom"), 1)
+
+		bus.Reset()
+		_, err = client.CreateRoom(ctx, "node", req)
+		require.NoError(t, err)
+		require.Len(t, bus.Requests("RoomManager", ""), 1)
+	})
+
+	t.Run("retry", func(t *testing.T) {
+		bus := NewBus()
+		svc, client := newRoomManager(t, bus, rpc.PSRPCConfig{MaxAttempts: 3, Timeout: 100 * time.Millisecond})
+		bus.Partition("client", "node")
+		time.AfterFunc(50*time.Millisecond, bus.HealAll)
+		_, err := client.CreateRoom(ctx, "node", req)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, svc.calls.Load())
+		require.Len(t, bus.Requests("RoomManager", "CreateRoom"), 2)
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		bus := NewBus()
+		svc, client := newRoomManager(t, bus, rpc.PSRPCConfig{})
+		bus.SetDuplicateRate(1)
+		_, err := client.CreateRoom(ctx, "node", req)
+		require.NoError(t, err)
+
+		// The server claims both copies of the request, but the client only accepts one claim.
+		require.Eventually(t, func() bool {
+			var claims int
+			for _, r := range bus.Records() {
+				if r.Kind == KindClaimRequest {
+					claims++
+				}
+			}
+			return claims == 2
+		}, time.Second, 10*time.Millisecond)
+		require.EqualValues(t, 1, svc.calls.Load())
+	})
+
+	t.Run("latency", func(t *testing.T) {
+		bus := NewBus()
+		_, client := newRoomManager(t, bus, rpc.PSRPCConfig{})
+		bus.SetLatencyFunc(func(from, to string) time.Duration {
+			if from == "node" {
+				return 100 * time.Millisecond
+			}
+			return 0
+		})
+		start := time.Now()
+		_, err := client.CreateRoom(ctx, "node", req)
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+	})
+}
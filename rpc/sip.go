@@ -1,13 +1,17 @@
 package rpc
 
 import (
+	"context"
 	"errors"
 	"maps"
 	"math/rand/v2"
 	"net"
+	"slices"
 	"strings"
 
 	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/utils"
+	"github.com/livekit/protocol/utils/guid"
 )
 
 func (p *GetSIPTrunkAuthenticationRequest) SIPCall() *SIPCall {
@@ -189,6 +193,97 @@ func NewCreateSIPParticipantRequest(
 	}, nil
 }
 
+// DefaultSIPFailoverCodes are SIP status codes that trigger a call attempt via the next outbound trunk.
+// They only include trunk and carrier failures. Responses from the callee, such as 486 Busy Here or
+// 603 Decline, are not retried, since another trunk reaches the same callee. Callers that treat a busy
+// signal from a carrier as a trunk failure can pass their own list to NewCreateSIPParticipantFailover.
+var DefaultSIPFailoverCodes = []livekit.SIPStatusCode{
+	livekit.SIPStatusCode_SIP_STATUS_INTERNAL_SERVER_ERROR,
+	livekit.SIPStatusCode_SIP_STATUS_BAD_GATEWAY,
+	livekit.SIPStatusCode_SIP_STATUS_SERVICE_UNAVAILABLE,
+	livekit.SIPStatusCode_SIP_STATUS_GATEWAY_TIMEOUT,
+}
+
+// SIPFailover is an ordered list of outbound call attempts via different trunks.
+// Next attempt is only made if the previous one failed with one of RetryOn status codes.
+type SIPFailover struct {
+	Attempts []*InternalCreateSIPParticipantRequest
+	RetryOn  []livekit.SIPStatusCode
+}
+
+// NewCreateSIPParticipantFailover fills InternalCreateSIPParticipantRequest for each of the trunks, in order.
+// Each attempt is a separate SIP call, so it gets its own call ID from newCallID. If newCallID is nil,
+// a new SIP call ID is generated. If retryOn is empty, DefaultSIPFailoverCodes are used.
+func NewCreateSIPParticipantFailover(
+	projectID string,
+	newCallID func() string,
+	ownHostname, wsUrl, token string,
+	req *livekit.CreateSIPParticipantRequest,
+	trunks []*livekit.SIPOutboundTrunkInfo,
+	retryOn ...livekit.SIPStatusCode,
+) (*SIPFailover, error) {
+	if len(trunks) == 0 {
+		return nil, errors.New("no outbound trunks for failover")
+	}
+	if len(retryOn) == 0 {
+		retryOn = DefaultSIPFailoverCodes
+	}
+	if newCallID == nil {
+		newCallID = func() string {
+			return guid.New(utils.SIPCallPrefix)
+		}
+	}
+	f := &SIPFailover{
+		Attempts: make([]*InternalCreateSIPParticipantRequest, 0, len(trunks)),
+		RetryOn:  retryOn,
+	}
+	for _, trunk := range trunks {
+		r := utils.CloneProto(req)
+		r.SipTrunkId = trunk.SipTrunkId
+		r.Trunk = nil
+		ireq, err := NewCreateSIPParticipantRequest(projectID, newCallID(), ownHostname, wsUrl, token, r, trunk)
+		if err != nil {
+			return nil, err
+		}
+		f.Attempts = append(f.Attempts, ireq)
+	}
+	return f, nil
+}
+
+// ShouldRetry checks if the next trunk should be tried after the call failed with a given error.
+func (f *SIPFailover) ShouldRetry(err error) bool {
+	if err == nil {
+		return false
+	}
+	st := livekit.SIPStatusFrom(err)
+	if st == nil {
+		return false
+	}
+	return slices.Contains(f.RetryOn, st.Code)
+}
+
+// Run executes call attempts in order, until one succeeds or fails with a non-retryable error.
+func (f *SIPFailover) Run(
+	ctx context.Context,
+	call func(ctx context.Context, req *InternalCreateSIPParticipantRequest) (*InternalCreateSIPParticipantResponse, error),
+) (*InternalCreateSIPParticipantResponse, error) {
+	var lastErr error
+	for _, req := range f.Attempts {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		resp, err := call(ctx, req)
+		if err == nil {
+			return resp, nil
+		}
+		lastErr = err
+		if !f.ShouldRetry(err) {
+			return nil, err
+		}
+	}
+	return nil, lastErr
+}
+
 // NewTransferSIPParticipantRequest fills InternalTransferSIPParticipantRequest from
 // livekit.TransferSIPParticipantRequest.
 func NewTransferSIPParticipantRequest(
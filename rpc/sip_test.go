@@ -1,6 +1,9 @@
 package rpc
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -127,4 +130,65 @@ func TestNewCreateSIPParticipantRequest(t *testing.T) {
 	res, err = NewCreateSIPParticipantRequest("p_123", "call-id", "xyz.sip.livekit.cloud", "url", "token", r, nil)
 	require.NoError(t, err)
 	require.Equal(t, exp, res)
+	// Formatted numbers are passed as-is, so identities stay the same.
+	r.SipCallTo = "+1 (415) 555-0100"
+	res, err = NewCreateSIPParticipantRequest("p_123", "call-id", "xyz.sip.livekit.cloud", "url", "token", r, nil)
+	require.NoError(t, err)
+	require.Equal(t, "+1 (415) 555-0100", res.CallTo)
+	require.Equal(t, "sip_+1 (415) 555-0100", res.ParticipantIdentity)
+	require.Equal(t, "+1 (415) 555-0100", res.ParticipantAttributes[livekit.AttrSIPPhoneNumber])
+}
+
+func TestSIPFailover(t *testing.T) {
+	r := &livekit.CreateSIPParticipantRequest{
+		SipTrunkId: "a",
+		SipCallTo:  "+3333",
+		RoomName:   "room",
+	}
+	trunks := []*livekit.SIPOutboundTrunkInfo{
+		{SipTrunkId: "a", Address: "a.example.com", Numbers: []string{"+1111"}},
+		{SipTrunkId: "b", Address: "b.example.com", Numbers: []string{"+2222"}},
+	}
+	var ids int
+	newCallID := func() string {
+		ids++
+		return fmt.Sprintf("call-%d", ids)
+	}
+	f, err := NewCreateSIPParticipantFailover("p_123", newCallID, "xyz.sip.livekit.cloud", "url", "token", r, trunks)
+	require.NoError(t, err)
+	require.Len(t, f.Attempts, 2)
+	require.Equal(t, "call-1", f.Attempts[0].SipCallId)
+	require.Equal(t, "call-2", f.Attempts[1].SipCallId)
+	require.Equal(t, "call-2", f.Attempts[1].ParticipantAttributes[livekit.AttrSIPCallID])
+	require.Equal(t, "b", f.Attempts[1].SipTrunkId)
+	require.Equal(t, "b.example.com", f.Attempts[1].Address)
+	require.Equal(t, "+2222", f.Attempts[1].Number)
+	require.Equal(t, "b", f.Attempts[1].ParticipantAttributes[livekit.AttrSIPTrunkID])
+	require.Equal(t, "a", r.SipTrunkId, "request must not be modified")
+
+	unavailable := (&livekit.SIPStatus{Code: livekit.SIPStatusCode_SIP_STATUS_SERVICE_UNAVAILABLE}).GRPCStatus().Err()
+	busy := (&livekit.SIPStatus{Code: livekit.SIPStatusCode_SIP_STATUS_BUSY_HERE}).GRPCStatus().Err()
+	require.True(t, f.ShouldRetry(unavailable))
+	require.False(t, f.ShouldRetry(busy))
+	require.False(t, f.ShouldRetry(errors.New("other")))
+
+	var tried []string
+	resp, err := f.Run(context.Background(), func(ctx context.Context, req *InternalCreateSIPParticipantRequest) (*InternalCreateSIPParticipantResponse, error) {
+		tried = append(tried, req.SipTrunkId)
+		if req.SipTrunkId == "a" {
+			return nil, unavailable
+		}
+		return &InternalCreateSIPParticipantResponse{SipCallId: req.SipCallId}, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "call-2", resp.SipCallId)
+	require.Equal(t, []string{"a", "b"}, tried)
+
+	tried = nil
+	_, err = f.Run(context.Background(), func(ctx context.Context, req *InternalCreateSIPParticipantRequest) (*InternalCreateSIPParticipantResponse, error) {
+		tried = append(tried, req.SipTrunkId)
+		return nil, busy
+	})
+	require.Equal(t, busy, err)
+	require.Equal(t, []string{"a"}, tried)
 }
@@ -0,0 +1,165 @@
+// Copyright 2025 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/tracer"
+	"github.com/livekit/psrpc"
+	"github.com/livekit/psrpc/pkg/metadata"
+)
+
+// WithClientTracer starts a client span for each RPC and multi-RPC, using the tracer set by tracer.SetTracer,
+// and passes W3C trace context to the server in request metadata. Streams are not traced.
+func WithClientTracer() psrpc.ClientOption {
+	return psrpc.WithClientOptions(
+		psrpc.WithClientRPCInterceptors(newClientRPCTracerInterceptor()),
+		psrpc.WithClientMultiRPCInterceptors(newMultiRPCTracerInterceptor()),
+	)
+}
+
+// WithServerTracer starts a server span for each RPC, continuing the trace passed by WithClientTracer.
+func WithServerTracer() psrpc.ServerOption {
+	return psrpc.WithServerOptions(
+		psrpc.WithServerRPCInterceptors(newServerRPCTracerInterceptor()),
+	)
+}
+
+func spanName(info psrpc.RPCInfo) string {
+	return info.Service + "/" + info.Method
+}
+
+func spanAttributes(info psrpc.RPCInfo) []interface{} {
+	kv := []interface{}{
+		"rpc.system", "psrpc",
+		"rpc.service", info.Service,
+		"rpc.method", info.Method,
+	}
+	if len(info.Topic) != 0 {
+		kv = append(kv, "psrpc.topic", info.Topic)
+	}
+	return kv
+}
+
+func endSpan(span tracer.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(tracer.StatusError, err.Error())
+		var e psrpc.Error
+		if errors.As(err, &e) {
+			span.SetAttributes("psrpc.error_code", string(e.Code()))
+		}
+	}
+	span.End()
+}
+
+// injectTraceContext adds trace context of the current span to outgoing psrpc metadata.
+func injectTraceContext(ctx context.Context) context.Context {
+	carrier := propagation.MapCarrier{}
+	tracer.Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return ctx
+	}
+	kv := make([]string, 0, 2*len(carrier))
+	for k, v := range carrier {
+		kv = append(kv, k, v)
+	}
+	return metadata.AppendMetadataToOutgoingContext(ctx, kv...)
+}
+
+// extractTraceContext returns a context with the remote span passed in incoming psrpc metadata, if any.
+func extractTraceContext(ctx context.Context) context.Context {
+	head := metadata.IncomingHeader(ctx)
+	if head == nil || len(head.Metadata) == 0 {
+		return ctx
+	}
+	return tracer.Extract(ctx, propagation.MapCarrier(head.Metadata))
+}
+
+func newClientRPCTracerInterceptor() psrpc.ClientRPCInterceptor {
+	return func(rpcInfo psrpc.RPCInfo, next psrpc.ClientRPCHandler) psrpc.ClientRPCHandler {
+		name, attrs := spanName(rpcInfo), spanAttributes(rpcInfo)
+		return func(ctx context.Context, req proto.Message, opts ...psrpc.RequestOption) (res proto.Message, err error) {
+			ctx, span := tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient))
+			span.SetAttributes(attrs...)
+			defer func() {
+				endSpan(span, err)
+			}()
+			return next(injectTraceContext(ctx), req, opts...)
+		}
+	}
+}
+
+func newServerRPCTracerInterceptor() psrpc.ServerRPCInterceptor {
+	return func(ctx context.Context, req proto.Message, rpcInfo psrpc.RPCInfo, handler psrpc.ServerRPCHandler) (res proto.Message, err error) {
+		ctx, span := tracer.Start(extractTraceContext(ctx), spanName(rpcInfo), trace.WithSpanKind(trace.SpanKindServer))
+		span.SetAttributes(spanAttributes(rpcInfo)...)
+		defer func() {
+			endSpan(span, err)
+		}()
+		return handler(ctx, req)
+	}
+}
+
+func newMultiRPCTracerInterceptor() psrpc.ClientMultiRPCInterceptor {
+	return func(rpcInfo psrpc.RPCInfo, next psrpc.ClientMultiRPCHandler) psrpc.ClientMultiRPCHandler {
+		return &multiRPCTracerInterceptor{
+			ClientMultiRPCHandler: next,
+			info:                  rpcInfo,
+			span:                  &tracer.NoOpSpan{},
+		}
+	}
+}
+
+type multiRPCTracerInterceptor struct {
+	psrpc.ClientMultiRPCHandler
+	info          psrpc.RPCInfo
+	span          tracer.Span
+	responseCount int
+	errorCount    int
+}
+
+func (r *multiRPCTracerInterceptor) Send(ctx context.Context, req proto.Message, opts ...psrpc.RequestOption) error {
+	ctx, r.span = tracer.Start(ctx, spanName(r.info), trace.WithSpanKind(trace.SpanKindClient))
+	r.span.SetAttributes(spanAttributes(r.info)...)
+	err := r.ClientMultiRPCHandler.Send(injectTraceContext(ctx), req, opts...)
+	if err != nil {
+		endSpan(r.span, err)
+		r.span = &tracer.NoOpSpan{}
+	}
+	return err
+}
+
+func (r *multiRPCTracerInterceptor) Recv(msg proto.Message, err error) {
+	if err != nil {
+		r.span.AddEvent("error", "error", err)
+		r.errorCount++
+	} else {
+		r.responseCount++
+	}
+	r.ClientMultiRPCHandler.Recv(msg, err)
+}
+
+func (r *multiRPCTracerInterceptor) Close() {
+	r.span.SetAttributes("psrpc.response_count", r.responseCount, "psrpc.error_count", r.errorCount)
+	r.span.End()
+	r.ClientMultiRPCHandler.Close()
+}
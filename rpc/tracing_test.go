@@ -0,0 +1,103 @@
+package rpc
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/tracer"
+	"github.com/livekit/protocol/tracer/tracertest"
+	"github.com/livekit/protocol/utils/xtwirp"
+	"github.com/livekit/psrpc"
+)
+
+type tracingRoomManager struct {
+	rooms map[string]bool
+}
+
+func (s *tracingRoomManager) CreateRoom(ctx context.Context, req *livekit.CreateRoomRequest) (*livekit.Room, error) {
+	if !s.rooms[req.Name] {
+		return nil, psrpc.NewErrorf(psrpc.NotFound, "no room")
+	}
+	return &livekit.Room{Name: req.Name}, nil
+}
+
+type tracingRoomService struct {
+	livekit.RoomService
+	client RoomManagerClient[string]
+}
+
+func (s *tracingRoomService) CreateRoom(ctx context.Context, req *livekit.CreateRoomRequest) (*livekit.Room, error) {
+	return s.client.CreateRoom(ctx, "node", req)
+}
+
+func TestTracing(t *testing.T) {
+	tr, exporter := tracertest.NewInMemoryTracer()
+	tracer.SetTracer(tr)
+	t.Cleanup(func() {
+		tracer.SetTracer(&tracer.NoOpTracer{})
+	})
+
+	bus := psrpc.NewLocalMessageBus()
+	server, err := NewRoomManagerServer[string](&tracingRoomManager{rooms: map[string]bool{"room": true}}, bus, WithServerTracer())
+	require.NoError(t, err)
+	require.NoError(t, server.RegisterCreateRoomTopic("node"))
+	t.Cleanup(server.Kill)
+
+	client, err := NewRoomManagerClient[string](bus, WithClientTracer())
+	require.NoError(t, err)
+	t.Cleanup(client.Close)
+
+	opts := append(xtwirp.DefaultServerOptions(), xtwirp.ServerPassTrace())
+	ts := livekit.NewRoomServiceServer(&tracingRoomService{client: client}, toInterfaces(opts)...)
+	hs := httptest.NewServer(xtwirp.WrapHandler(ts))
+	t.Cleanup(hs.Close)
+	rs := livekit.NewRoomServiceProtobufClient(hs.URL, hs.Client(), append(xtwirp.DefaultClientOptions(), xtwirp.ClientPassTrace())...)
+
+	_, err = rs.CreateRoom(context.Background(), &livekit.CreateRoomRequest{Name: "room"})
+	require.NoError(t, err)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 4)
+	// Spans are exported when they end, so the innermost comes first.
+	psrpcServer, psrpcClient, twirpServer, twirpClient := spans[0], spans[1], spans[2], spans[3]
+	require.Equal(t, "RoomService/CreateRoom", twirpClient.Name)
+	require.Equal(t, trace.SpanKindClient, twirpClient.SpanKind)
+	require.Equal(t, "RoomService/CreateRoom", twirpServer.Name)
+	require.Equal(t, trace.SpanKindServer, twirpServer.SpanKind)
+	require.Equal(t, "RoomManager/CreateRoom", psrpcClient.Name)
+	require.Equal(t, trace.SpanKindClient, psrpcClient.SpanKind)
+	require.Equal(t, "RoomManager/CreateRoom", psrpcServer.Name)
+	require.Equal(t, trace.SpanKindServer, psrpcServer.SpanKind)
+
+	traceID := twirpClient.SpanContext.TraceID()
+	for _, s := range spans {
+		require.Equal(t, traceID, s.SpanContext.TraceID(), s.Name)
+	}
+	require.Equal(t, twirpClient.SpanContext.SpanID(), twirpServer.Parent.SpanID())
+	require.True(t, twirpServer.Parent.IsRemote())
+	require.Equal(t, twirpServer.SpanContext.SpanID(), psrpcClient.Parent.SpanID())
+	require.Equal(t, psrpcClient.SpanContext.SpanID(), psrpcServer.Parent.SpanID())
+	require.True(t, psrpcServer.Parent.IsRemote())
+
+	exporter.Reset()
+	_, err = rs.CreateRoom(context.Background(), &livekit.CreateRoomRequest{Name: "missing"})
+	require.Error(t, err)
+	spans = exporter.GetSpans()
+	require.Len(t, spans, 4)
+	for _, s := range spans {
+		require.Equal(t, "Error", s.Status.Code.String(), s.Name)
+	}
+}
+
+func toInterfaces[T any](s []T) []interface{} {
+	out := make([]interface{}, 0, len(s))
+	for _, v := range s {
+		out = append(out, v)
+	}
+	return out
+}
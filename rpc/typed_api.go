@@ -31,6 +31,12 @@ type PSRPCConfig struct {
 	Timeout     time.Duration `yaml:"timeout,omitempty"`
 	Backoff     time.Duration `yaml:"backoff,omitempty"`
 	BufferSize  int           `yaml:"buffer_size,omitempty"`
+	// Policies override MaxAttempts, Timeout and Backoff for matching methods.
+	Policies PSRPCPolicies `yaml:"policies,omitempty"`
+	// CircuitBreaker enables failing fast while a service is overloaded.
+	CircuitBreaker *CircuitBreakerConfig `yaml:"circuit_breaker,omitempty"`
+	// Admission limits requests accepted by servers created with WithDefaultServerOptions.
+	Admission *AdmissionConfig `yaml:"admission,omitempty"`
 }
 
 var DefaultPSRPCConfig = PSRPCConfig{
@@ -67,17 +73,26 @@ func (p *ClientParams) Options() []psrpc.ClientOption {
 		opts = append(opts, psrpc.WithClientChannelSize(p.BufferSize))
 	}
 	if p.Observer != nil {
-		opts = append(opts, middleware.WithClientMetrics(p.Observer))
+		opts = append(opts, WithClientMetrics(p.Observer))
 	}
 	if p.Logger != nil {
 		opts = append(opts, WithClientLogger(p.Logger))
 	}
+	if p.CircuitBreaker != nil {
+		opts = append(opts, WithCircuitBreaker(*p.CircuitBreaker))
+	}
+	var retries *middleware.RetryOptions
 	if p.MaxAttempts != 0 || p.Timeout != 0 || p.Backoff != 0 {
-		opts = append(opts, middleware.WithRPCRetries(middleware.RetryOptions{
+		retries = &middleware.RetryOptions{
 			MaxAttempts: p.MaxAttempts,
 			Timeout:     p.Timeout,
 			Backoff:     p.Backoff,
-		}))
+		}
+	}
+	if len(p.Policies) != 0 {
+		opts = append(opts, psrpc.WithClientRPCInterceptors(NewRPCPolicyInterceptor(p.Policies, retries)))
+	} else if retries != nil {
+		opts = append(opts, WithRPCRetries(*retries))
 	}
 	return opts
 }
@@ -88,21 +103,25 @@ func (p *ClientParams) Args() (psrpc.MessageBus, psrpc.ClientOption) {
 
 func WithServerObservability(logger logger.Logger) psrpc.ServerOption {
 	return psrpc.WithServerOptions(
-		middleware.WithServerMetrics(PSRPCMetricsObserver{}),
+		WithServerMetrics(PSRPCMetricsObserver{}),
 		WithServerLogger(logger),
 	)
 }
 
-func WithDefaultServerOptions(psrpcConfig PSRPCConfig, logger logger.Logger) psrpc.ServerOption {
-	return psrpc.WithServerOptions(
+func WithDefaultServerOptions(psrpcConfig PSRPCConfig, logger logger.Logger, opts ...AdmissionOption) psrpc.ServerOption {
+	serverOpts := []psrpc.ServerOption{
 		psrpc.WithServerChannelSize(psrpcConfig.BufferSize),
 		WithServerObservability(logger),
-	)
+	}
+	if psrpcConfig.Admission != nil {
+		serverOpts = append(serverOpts, WithServerAdmission(*psrpcConfig.Admission, opts...))
+	}
+	return psrpc.WithServerOptions(serverOpts...)
 }
 
 func WithClientObservability(logger logger.Logger) psrpc.ClientOption {
 	return psrpc.WithClientOptions(
-		middleware.WithClientMetrics(PSRPCMetricsObserver{}),
+		WithClientMetrics(PSRPCMetricsObserver{}),
 		WithClientLogger(logger),
 	)
 }
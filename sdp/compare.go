@@ -0,0 +1,370 @@
+// Copyright 2025 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdp
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/pion/sdp/v3"
+)
+
+type IssueSeverity string
+
+const (
+	// SeverityError means the answer is invalid for the offer and media is expected to fail.
+	SeverityError IssueSeverity = "error"
+	// SeverityWarning means the answer is valid, but declines or changes something that was offered.
+	SeverityWarning IssueSeverity = "warning"
+)
+
+type IssueKind string
+
+const (
+	IssueMediaCount IssueKind = "media_count"
+	IssueMid        IssueKind = "mid"
+	IssueMediaType  IssueKind = "media_type"
+	IssueRejected   IssueKind = "rejected"
+	IssueBundle     IssueKind = "bundle"
+	IssueCodec      IssueKind = "codec"
+	IssueDirection  IssueKind = "direction"
+	IssueSimulcast  IssueKind = "simulcast"
+	IssueICE        IssueKind = "ice"
+	IssueDTLS       IssueKind = "dtls"
+)
+
+// Issue is a problem found by CompareOfferAnswer.
+type Issue struct {
+	Severity IssueSeverity
+	Kind     IssueKind
+	// Mid of the media section, empty for session level issues.
+	Mid     string
+	Message string
+}
+
+func (i Issue) String() string {
+	if i.Mid == "" {
+		return fmt.Sprintf("%s: %s: %s", i.Severity, i.Kind, i.Message)
+	}
+	return fmt.Sprintf("%s: %s: mid %s: %s", i.Severity, i.Kind, i.Mid, i.Message)
+}
+
+type Issues []Issue
+
+// HasErrors returns true if any issue has SeverityError.
+func (is Issues) HasErrors() bool {
+	return slices.ContainsFunc(is, func(i Issue) bool { return i.Severity == SeverityError })
+}
+
+// Errors returns issues with SeverityError.
+func (is Issues) Errors() Issues {
+	var out Issues
+	for _, i := range is {
+		if i.Severity == SeverityError {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+func (is Issues) String() string {
+	s := make([]string, 0, len(is))
+	for _, i := range is {
+		s = append(s, i.String())
+	}
+	return strings.Join(s, "; ")
+}
+
+type issueCollector struct {
+	issues Issues
+}
+
+func (c *issueCollector) add(severity IssueSeverity, kind IssueKind, mid string, format string, args ...any) {
+	c.issues = append(c.issues, Issue{
+		Severity: severity,
+		Kind:     kind,
+		Mid:      mid,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// CompareOfferAnswer validates an answer against its offer and returns all issues found. An empty result means
+// the answer is compatible with the offer.
+//
+// It checks that media sections and mids match, BUNDLE groups are consistent, answered codecs were offered
+// with the same payload type and parameters, directions are compatible, simulcast rids were offered,
+// and the answer has ICE credentials and a DTLS fingerprint.
+func CompareOfferAnswer(offer, answer *sdp.SessionDescription) Issues {
+	c := &issueCollector{}
+
+	if _, _, err := ExtractICECredential(answer); err != nil {
+		c.add(SeverityError, IssueICE, "", "%v", err)
+	}
+	if _, _, err := ExtractFingerprint(answer); err != nil {
+		c.add(SeverityError, IssueDTLS, "", "%v", err)
+	}
+
+	if len(offer.MediaDescriptions) != len(answer.MediaDescriptions) {
+		c.add(SeverityError, IssueMediaCount, "", "offer has %d media sections, answer has %d",
+			len(offer.MediaDescriptions), len(answer.MediaDescriptions))
+	}
+
+	for i, am := range answer.MediaDescriptions {
+		mid := GetMidValue(am)
+		if i >= len(offer.MediaDescriptions) {
+			c.add(SeverityError, IssueMid, mid, "media section %d was not offered", i)
+			continue
+		}
+		om := offer.MediaDescriptions[i]
+		if offerMid := GetMidValue(om); offerMid != mid {
+			c.add(SeverityError, IssueMid, mid, "media section %d has mid %q in the offer", i, offerMid)
+		}
+		if om.MediaName.Media != am.MediaName.Media {
+			c.add(SeverityError, IssueMediaType, mid, "offered %s, answered %s", om.MediaName.Media, am.MediaName.Media)
+			continue
+		}
+		if isRejected(am) {
+			if !isRejected(om) {
+				c.add(SeverityWarning, IssueRejected, mid, "media section rejected")
+			}
+			continue
+		}
+		if isRejected(om) {
+			c.add(SeverityError, IssueRejected, mid, "media section rejected in the offer is accepted in the answer")
+			continue
+		}
+		if om.MediaName.Media == "application" {
+			continue
+		}
+		compareCodecs(c, mid, om, am)
+		compareDirection(c, mid, om, am)
+		compareSimulcast(c, mid, om, am)
+	}
+
+	compareBundle(c, offer, answer)
+	return c.issues
+}
+
+// isRejected checks if the media section is rejected with port 0. Sections with bundle-only also use port 0,
+// but are not rejected.
+func isRejected(m *sdp.MediaDescription) bool {
+	return m.MediaName.Port.Value == 0 && !hasBundleOnly(m)
+}
+
+func hasBundleOnly(m *sdp.MediaDescription) bool {
+	_, ok := m.Attribute("bundle-only")
+	return ok
+}
+
+func bundleGroups(desc *sdp.SessionDescription) [][]string {
+	var out [][]string
+	for _, a := range desc.Attributes {
+		if a.Key != sdp.AttrKeyGroup {
+			continue
+		}
+		fields := strings.Fields(a.Value)
+		if len(fields) > 0 && strings.EqualFold(fields[0], "BUNDLE") {
+			out = append(out, fields[1:])
+		}
+	}
+	return out
+}
+
+func compareBundle(c *issueCollector, offer, answer *sdp.SessionDescription) {
+	offered := bundleGroups(offer)
+	answered := bundleGroups(answer)
+	if len(offered) != 0 && len(answered) == 0 {
+		c.add(SeverityWarning, IssueBundle, "", "BUNDLE was not accepted")
+		return
+	}
+
+	mids := make(map[string]*sdp.MediaDescription)
+	for _, m := range answer.MediaDescriptions {
+		mids[GetMidValue(m)] = m
+	}
+	for _, group := range answered {
+		if len(group) == 0 {
+			c.add(SeverityError, IssueBundle, "", "empty BUNDLE group")
+			continue
+		}
+		var og []string
+		for _, g := range offered {
+			if slices.Contains(g, group[0]) {
+				og = g
+				break
+			}
+		}
+		if og == nil {
+			c.add(SeverityError, IssueBundle, group[0], "BUNDLE group was not offered")
+			continue
+		}
+		for _, mid := range group {
+			if !slices.Contains(og, mid) {
+				c.add(SeverityError, IssueBundle, mid, "mid was not offered in the BUNDLE group")
+			}
+			m, ok := mids[mid]
+			if !ok {
+				c.add(SeverityError, IssueBundle, mid, "BUNDLE group refers to unknown mid")
+			} else if isRejected(m) {
+				c.add(SeverityError, IssueBundle, mid, "BUNDLE group contains rejected media section")
+			}
+		}
+	}
+}
+
+func codecParams(c sdp.Codec) string {
+	s := fmt.Sprintf("%s/%d", strings.ToLower(c.Name), c.ClockRate)
+	if c.EncodingParameters != "" && c.EncodingParameters != "1" {
+		s += "/" + c.EncodingParameters
+	}
+	return s
+}
+
+// fmtpCompatible checks that both sides agree on format parameters that must match. For H264 these are
+// packetization mode and profile, for RTX and RED the payload types they carry. Other parameters are declarative
+// and may differ.
+func fmtpCompatible(c sdp.Codec, offer, answer string) bool {
+	op, ap := fmtpMap(offer), fmtpMap(answer)
+	switch {
+	case CodecMatches(c, "H264"):
+		// Packetization mode defaults to 0.
+		if strings.TrimPrefix(op["packetization-mode"], "0") != strings.TrimPrefix(ap["packetization-mode"], "0") {
+			return false
+		}
+		// Level may be downgraded by the answerer, profile must match.
+		oprof, aprof := op["profile-level-id"], ap["profile-level-id"]
+		return len(oprof) < 4 || len(aprof) < 4 || strings.EqualFold(oprof[:4], aprof[:4])
+	case CodecMatches(c, codecNameRTX):
+		return op["apt"] == ap["apt"]
+	case CodecMatches(c, codecNameRED):
+		return slices.Equal(associatedPayloadTypes(sdp.Codec{Name: c.Name, Fmtp: offer}), associatedPayloadTypes(sdp.Codec{Name: c.Name, Fmtp: answer}))
+	}
+	return true
+}
+
+func fmtpMap(fmtp string) map[string]string {
+	out := make(map[string]string)
+	for _, p := range parseFmtpParams(fmtp) {
+		out[strings.ToLower(p.key)] = p.value
+	}
+	return out
+}
+
+func compareCodecs(c *issueCollector, mid string, om, am *sdp.MediaDescription) {
+	offered := make(map[uint8]sdp.Codec)
+	for _, codec := range MediaCodecs(om) {
+		offered[codec.PayloadType] = codec
+	}
+
+	answered := MediaCodecs(am)
+	if len(answered) == 0 {
+		c.add(SeverityError, IssueCodec, mid, "no codecs in the answer")
+		return
+	}
+	primary := 0
+	for _, codec := range answered {
+		oc, ok := offered[codec.PayloadType]
+		if !ok {
+			c.add(SeverityError, IssueCodec, mid, "payload type %d (%s) was not offered", codec.PayloadType, codecParams(codec))
+			continue
+		}
+		if codecParams(oc) != codecParams(codec) {
+			c.add(SeverityError, IssueCodec, mid, "payload type %d offered as %s, answered as %s",
+				codec.PayloadType, codecParams(oc), codecParams(codec))
+			continue
+		}
+		if !fmtpCompatible(codec, oc.Fmtp, codec.Fmtp) {
+			c.add(SeverityError, IssueCodec, mid, "payload type %d (%s) format parameters %q do not match offered %q",
+				codec.PayloadType, codecParams(codec), codec.Fmtp, oc.Fmtp)
+			continue
+		}
+		if len(associatedPayloadTypes(codec)) == 0 {
+			primary++
+		}
+	}
+	if primary == 0 {
+		c.add(SeverityError, IssueCodec, mid, "no media codecs in common")
+	}
+}
+
+// compatibleDirections lists answer directions allowed for each offered direction, see RFC 3264 section 6.1.
+var compatibleDirections = map[sdp.Direction][]sdp.Direction{
+	sdp.DirectionSendRecv: {sdp.DirectionSendRecv, sdp.DirectionSendOnly, sdp.DirectionRecvOnly, sdp.DirectionInactive},
+	sdp.DirectionSendOnly: {sdp.DirectionRecvOnly, sdp.DirectionInactive},
+	sdp.DirectionRecvOnly: {sdp.DirectionSendOnly, sdp.DirectionInactive},
+	sdp.DirectionInactive: {sdp.DirectionInactive},
+}
+
+func compareDirection(c *issueCollector, mid string, om, am *sdp.MediaDescription) {
+	od, ad := GetDirection(om), GetDirection(am)
+	if !slices.Contains(compatibleDirections[od], ad) {
+		c.add(SeverityError, IssueDirection, mid, "offered %s, answered %s", od, ad)
+		return
+	}
+	if ad == sdp.DirectionInactive && od != sdp.DirectionInactive {
+		c.add(SeverityWarning, IssueDirection, mid, "offered %s, answered inactive", od)
+	}
+}
+
+// mediaRids returns rid ids with their direction, "send" or "recv".
+func mediaRids(m *sdp.MediaDescription) map[string]string {
+	out := make(map[string]string)
+	for _, a := range m.Attributes {
+		if a.Key != "rid" {
+			continue
+		}
+		fields := strings.Fields(a.Value)
+		if len(fields) >= 2 {
+			out[fields[0]] = fields[1]
+		}
+	}
+	return out
+}
+
+func compareSimulcast(c *issueCollector, mid string, om, am *sdp.MediaDescription) {
+	offerSimulcast, answerSimulcast := IsMediaDescriptionSimulcast(om), IsMediaDescriptionSimulcast(am)
+	switch {
+	case !offerSimulcast && answerSimulcast:
+		c.add(SeverityError, IssueSimulcast, mid, "simulcast was not offered")
+		return
+	case offerSimulcast && !answerSimulcast:
+		c.add(SeverityWarning, IssueSimulcast, mid, "simulcast was not accepted")
+		return
+	case !offerSimulcast:
+		return
+	}
+
+	offered, answered := mediaRids(om), mediaRids(am)
+	reversed := map[string]string{"send": "recv", "recv": "send"}
+	// iterate in a stable order, so issues are reported deterministically
+	rids := make([]string, 0, len(answered))
+	for rid := range answered {
+		rids = append(rids, rid)
+	}
+	slices.Sort(rids)
+	for _, rid := range rids {
+		dir := answered[rid]
+		odir, ok := offered[rid]
+		if !ok {
+			c.add(SeverityError, IssueSimulcast, mid, "rid %s was not offered", rid)
+		} else if reversed[odir] != dir {
+			c.add(SeverityError, IssueSimulcast, mid, "rid %s offered as %s, answered as %s", rid, odir, dir)
+		}
+	}
+	if len(answered) < len(offered) {
+		c.add(SeverityWarning, IssueSimulcast, mid, "%d of %d offered rids accepted", len(answered), len(offered))
+	}
+}
@@ -0,0 +1,244 @@
+package sdp
+
+import (
+	"strings"
+	"testing"
+
+	pionsdp "github.com/pion/sdp/v3"
+	"github.com/stretchr/testify/require"
+)
+
+const compareTestOffer = "v=0\r\n" +
+	"o=- 4648475892259889561 2 IN IP4 127.0.0.1\r\n" +
+	"s=-\r\n" +
+	"t=0 0\r\n" +
+	"a=group:BUNDLE 0 1\r\n" +
+	"a=fingerprint:sha-256 40:42:FB:47:87:52:BF:CB:EC:3A:DF:EB:06:DA:2D:B7:2F:59:42:10:23:7B:9D:4C:C9:58:DD:FF:A2:8F:17:67\r\n" +
+	"m=audio 9 UDP/TLS/RTP/SAVPF 63 111\r\n" +
+	"c=IN IP4 0.0.0.0\r\n" +
+	"a=ice-ufrag:ysXw\r\n" +
+	"a=ice-pwd:vw5LmwG4y/e6dPP/zAP9Gp5k\r\n" +
+	"a=mid:0\r\n" +
+	"a=sendrecv\r\n" +
+	"a=rtpmap:63 red/48000/2\r\n" +
+	"a=fmtp:63 111/111\r\n" +
+	"a=rtpmap:111 opus/48000/2\r\n" +
+	"a=fmtp:111 minptime=10;useinbandfec=1\r\n" +
+	"m=video 9 UDP/TLS/RTP/SAVPF 96 97 102\r\n" +
+	"c=IN IP4 0.0.0.0\r\n" +
+	"a=ice-ufrag:ysXw\r\n" +
+	"a=ice-pwd:vw5LmwG4y/e6dPP/zAP9Gp5k\r\n" +
+	"a=mid:1\r\n" +
+	"a=sendonly\r\n" +
+	"a=rtpmap:96 VP8/90000\r\n" +
+	"a=rtpmap:97 rtx/90000\r\n" +
+	"a=fmtp:97 apt=96\r\n" +
+	"a=rtpmap:102 H264/90000\r\n" +
+	"a=fmtp:102 level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42e01f\r\n" +
+	"a=rid:h send\r\n" +
+	"a=rid:q send\r\n" +
+	"a=simulcast:send h;q\r\n"
+
+const compareTestAnswer = "v=0\r\n" +
+	"o=- 7135427917323327734 2 IN IP4 127.0.0.1\r\n" +
+	"s=-\r\n" +
+	"t=0 0\r\n" +
+	"a=group:BUNDLE 0 1\r\n" +
+	"a=fingerprint:sha-256 0E:2F:8D:2D:1B:33:6D:44:3A:40:AE:85:4B:5E:73:B3:3B:AB:1F:9B:BD:1C:2E:4E:EF:94:2F:C5:A6:EE:E6:40\r\n" +
+	"m=audio 9 UDP/TLS/RTP/SAVPF 111\r\n" +
+	"c=IN IP4 0.0.0.0\r\n" +
+	"a=ice-ufrag:Pk6h\r\n" +
+	"a=ice-pwd:ttWq2R9PtzgfjmRk0nq8N9Ld\r\n" +
+	"a=mid:0\r\n" +
+	"a=sendrecv\r\n" +
+	"a=rtpmap:111 opus/48000/2\r\n" +
+	"a=fmtp:111 useinbandfec=1;minptime=10\r\n" +
+	"m=video 9 UDP/TLS/RTP/SAVPF 102\r\n" +
+	"c=IN IP4 0.0.0.0\r\n" +
+	"a=ice-ufrag:Pk6h\r\n" +
+	"a=ice-pwd:ttWq2R9PtzgfjmRk0nq8N9Ld\r\n" +
+	"a=mid:1\r\n" +
+	"a=recvonly\r\n" +
+	"a=rtpmap:102 H264/90000\r\n" +
+	"a=fmtp:102 packetization-mode=1;profile-level-id=42e01e\r\n" +
+	"a=rid:h recv\r\n" +
+	"a=rid:q recv\r\n" +
+	"a=simulcast:recv h;q\r\n"
+
+func compareWith(t *testing.T, replacements ...string) Issues {
+	offer := &pionsdp.SessionDescription{}
+	require.NoError(t, offer.UnmarshalString(compareTestOffer))
+	answer := &pionsdp.SessionDescription{}
+	require.NoError(t, answer.UnmarshalString(strings.NewReplacer(replacements...).Replace(compareTestAnswer)))
+	return CompareOfferAnswer(offer, answer)
+}
+
+func requireIssue(t *testing.T, issues Issues, severity IssueSeverity, kind IssueKind, mid string) {
+	t.Helper()
+	for _, i := range issues {
+		if i.Severity == severity && i.Kind == kind && i.Mid == mid {
+			return
+		}
+	}
+	require.Failf(t, "issue not found", "expected %s %s for mid %q, got: %s", severity, kind, mid, issues)
+}
+
+func TestCompareOfferAnswer(t *testing.T) {
+	t.Run("compatible", func(t *testing.T) {
+		issues := compareWith(t)
+		require.Empty(t, issues, issues.String())
+		require.False(t, issues.HasErrors())
+	})
+
+	cases := []struct {
+		name         string
+		replacements []string
+		severity     IssueSeverity
+		kind         IssueKind
+		mid          string
+	}{
+		{
+			name:         "missing fingerprint",
+			replacements: []string{"a=fingerprint", "a=x-fingerprint"},
+			severity:     SeverityError,
+			kind:         IssueDTLS,
+		},
+		{
+			name:         "missing ice credentials",
+			replacements: []string{"a=ice-ufrag:Pk6h\r\n", ""},
+			severity:     SeverityError,
+			kind:         IssueICE,
+		},
+		{
+			name:         "mid mismatch",
+			replacements: []string{"a=mid:1", "a=mid:v", "BUNDLE 0 1", "BUNDLE 0 v"},
+			severity:     SeverityError,
+			kind:         IssueMid,
+			mid:          "v",
+		},
+		{
+			name:         "bundle mid not offered",
+			replacements: []string{"BUNDLE 0 1", "BUNDLE 0 1 2"},
+			severity:     SeverityError,
+			kind:         IssueBundle,
+			mid:          "2",
+		},
+		{
+			name:         "bundle declined",
+			replacements: []string{"a=group:BUNDLE 0 1\r\n", ""},
+			severity:     SeverityWarning,
+			kind:         IssueBundle,
+		},
+		{
+			name:         "payload type not offered",
+			replacements: []string{"SAVPF 102", "SAVPF 98", "rtpmap:102", "rtpmap:98", "fmtp:102", "fmtp:98"},
+			severity:     SeverityError,
+			kind:         IssueCodec,
+			mid:          "1",
+		},
+		{
+			name:         "payload type codec changed",
+			replacements: []string{"102 H264/90000", "102 VP9/90000"},
+			severity:     SeverityError,
+			kind:         IssueCodec,
+			mid:          "1",
+		},
+		{
+			name:         "h264 packetization mode",
+			replacements: []string{"packetization-mode=1;profile-level-id=42e01e", "profile-level-id=42e01e"},
+			severity:     SeverityError,
+			kind:         IssueCodec,
+			mid:          "1",
+		},
+		{
+			name:         "h264 profile",
+			replacements: []string{"profile-level-id=42e01e", "profile-level-id=640c1f"},
+			severity:     SeverityError,
+			kind:         IssueCodec,
+			mid:          "1",
+		},
+		{
+			name:         "direction",
+			replacements: []string{"a=recvonly", "a=sendrecv"},
+			severity:     SeverityError,
+			kind:         IssueDirection,
+			mid:          "1",
+		},
+		{
+			name:         "inactive",
+			replacements: []string{"a=recvonly", "a=inactive"},
+			severity:     SeverityWarning,
+			kind:         IssueDirection,
+			mid:          "1",
+		},
+		{
+			name:         "rid not offered",
+			replacements: []string{"a=rid:q recv", "a=rid:f recv"},
+			severity:     SeverityError,
+			kind:         IssueSimulcast,
+			mid:          "1",
+		},
+		{
+			name:         "rid direction",
+			replacements: []string{"a=rid:q recv", "a=rid:q send"},
+			severity:     SeverityError,
+			kind:         IssueSimulcast,
+			mid:          "1",
+		},
+		{
+			name:         "rid declined",
+			replacements: []string{"a=rid:q recv\r\n", "", "recv h;q", "recv h"},
+			severity:     SeverityWarning,
+			kind:         IssueSimulcast,
+			mid:          "1",
+		},
+		{
+			name:         "simulcast declined",
+			replacements: []string{"a=rid:h recv\r\na=rid:q recv\r\na=simulcast:recv h;q\r\n", ""},
+			severity:     SeverityWarning,
+			kind:         IssueSimulcast,
+			mid:          "1",
+		},
+		{
+			name:         "rejected",
+			replacements: []string{"m=video 9", "m=video 0", "BUNDLE 0 1", "BUNDLE 0"},
+			severity:     SeverityWarning,
+			kind:         IssueRejected,
+			mid:          "1",
+		},
+		{
+			name:         "rejected in bundle",
+			replacements: []string{"m=video 9", "m=video 0"},
+			severity:     SeverityError,
+			kind:         IssueBundle,
+			mid:          "1",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			issues := compareWith(t, c.replacements...)
+			requireIssue(t, issues, c.severity, c.kind, c.mid)
+			require.Equal(t, c.severity == SeverityError, issues.HasErrors(), issues.String())
+		})
+	}
+
+	t.Run("rid order", func(t *testing.T) {
+		for range 10 {
+			issues := compareWith(t, "a=rid:h recv", "a=rid:y recv", "a=rid:q recv", "a=rid:x recv", "recv h;q", "recv y;x")
+			require.Equal(t, []string{"rid x was not offered", "rid y was not offered"}, []string{issues[0].Message, issues[1].Message}, issues.String())
+		}
+	})
+
+	t.Run("media count", func(t *testing.T) {
+		offer := &pionsdp.SessionDescription{}
+		require.NoError(t, offer.UnmarshalString(compareTestOffer))
+		answer := &pionsdp.SessionDescription{}
+		require.NoError(t, answer.UnmarshalString(compareTestAnswer))
+		answer.MediaDescriptions = answer.MediaDescriptions[:1]
+		answer.Attributes[0].Value = "BUNDLE 0"
+
+		issues := CompareOfferAnswer(offer, answer)
+		requireIssue(t, issues, SeverityError, IssueMediaCount, "")
+		require.Len(t, issues.Errors(), 1)
+	})
+}
@@ -0,0 +1,597 @@
+// Copyright 2025 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdp
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pion/sdp/v3"
+)
+
+var (
+	ErrCodecNotFound     = errors.New("codec not found")
+	ErrNoFreePayloadType = errors.New("no free payload type")
+	ErrNoFreeExtensionID = errors.New("no free header extension id")
+)
+
+const (
+	attrKeyRtpmap = "rtpmap"
+	attrKeyFmtp   = "fmtp"
+	attrKeyRtcpFb = "rtcp-fb"
+
+	codecNameOpus = "opus"
+	codecNameRED  = "red"
+	codecNameRTX  = "rtx"
+
+	// BandwidthAS is an application specific maximum bandwidth in kbps.
+	BandwidthAS = "AS"
+	// BandwidthTIAS is a transport independent maximum bandwidth in bps, see RFC 3890.
+	BandwidthTIAS = "TIAS"
+
+	maxOneByteExtensionID = 14
+	// Two-byte header allows IDs up to 255, but pion fails to parse values above 246.
+	maxTwoByteExtensionID = 246
+)
+
+// MediaCodecs returns codecs of a media section in the order of preference.
+// Payload types without rtpmap, other than static PCMU and PCMA, are returned without a name.
+func MediaCodecs(m *sdp.MediaDescription) []sdp.Codec {
+	s := &sdp.SessionDescription{
+		MediaDescriptions: []*sdp.MediaDescription{m},
+	}
+	var out []sdp.Codec
+	for _, f := range m.MediaName.Formats {
+		pt, err := strconv.ParseUint(f, 10, 8)
+		if err != nil {
+			// not an RTP media section
+			continue
+		}
+		codec, err := s.GetCodecForPayloadType(uint8(pt))
+		if err != nil {
+			codec = sdp.Codec{PayloadType: uint8(pt)}
+		}
+		out = append(out, codec)
+	}
+	return out
+}
+
+// CodecMatches checks if the codec has a given name. Name may be a MIME type, like "audio/opus".
+func CodecMatches(c sdp.Codec, name string) bool {
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.EqualFold(c.Name, name)
+}
+
+// associatedPayloadTypes returns payload types RTX or RED codec carries, if any.
+func associatedPayloadTypes(c sdp.Codec) []uint8 {
+	var out []uint8
+	switch {
+	case CodecMatches(c, codecNameRTX):
+		for _, p := range parseFmtpParams(c.Fmtp) {
+			if p.key == "apt" {
+				if pt, err := strconv.ParseUint(p.value, 10, 8); err == nil {
+					out = append(out, uint8(pt))
+				}
+			}
+		}
+	case CodecMatches(c, codecNameRED):
+		for _, s := range strings.Split(c.Fmtp, "/") {
+			if pt, err := strconv.ParseUint(strings.TrimSpace(s), 10, 8); err == nil && !slices.Contains(out, uint8(pt)) {
+				out = append(out, uint8(pt))
+			}
+		}
+	}
+	return out
+}
+
+// attributePayloadType returns the payload type of rtpmap, fmtp and rtcp-fb attributes.
+func attributePayloadType(a sdp.Attribute) (uint8, bool) {
+	switch a.Key {
+	case attrKeyRtpmap, attrKeyFmtp, attrKeyRtcpFb:
+	default:
+		return 0, false
+	}
+	v, _, _ := strings.Cut(a.Value, " ")
+	pt, err := strconv.ParseUint(v, 10, 8)
+	if err != nil {
+		// wildcard rtcp-fb
+		return 0, false
+	}
+	return uint8(pt), true
+}
+
+func removePayloadTypes(m *sdp.MediaDescription, remove func(pt uint8) bool) {
+	m.MediaName.Formats = slices.DeleteFunc(m.MediaName.Formats, func(f string) bool {
+		pt, err := strconv.ParseUint(f, 10, 8)
+		return err == nil && remove(uint8(pt))
+	})
+	m.Attributes = slices.DeleteFunc(m.Attributes, func(a sdp.Attribute) bool {
+		pt, ok := attributePayloadType(a)
+		return ok && remove(pt)
+	})
+}
+
+// FilterCodecs removes codecs for which keep returns false, together with their rtpmap, fmtp and rtcp-fb attributes.
+// RTX and RED payloads are removed as well when all codecs they carry are removed.
+// If no codecs would be left, ErrCodecNotFound is returned and the media section is not changed.
+func FilterCodecs(m *sdp.MediaDescription, keep func(c sdp.Codec) bool) error {
+	codecs := MediaCodecs(m)
+	kept := make(map[uint8]bool, len(codecs))
+	for _, c := range codecs {
+		if len(associatedPayloadTypes(c)) == 0 {
+			kept[c.PayloadType] = keep(c)
+		}
+	}
+	var primary int
+	for _, c := range codecs {
+		if apts := associatedPayloadTypes(c); len(apts) != 0 {
+			kept[c.PayloadType] = keep(c) && slices.ContainsFunc(apts, func(pt uint8) bool { return kept[pt] })
+		} else if kept[c.PayloadType] {
+			primary++
+		}
+	}
+	if primary == 0 {
+		return ErrCodecNotFound
+	}
+	removePayloadTypes(m, func(pt uint8) bool { return !kept[pt] })
+	return nil
+}
+
+// KeepCodecs removes all codecs except the ones with given names, along with their RTX and RED payloads.
+// RED is only kept if it is listed as well.
+func KeepCodecs(m *sdp.MediaDescription, names ...string) error {
+	return FilterCodecs(m, func(c sdp.Codec) bool {
+		if CodecMatches(c, codecNameRTX) {
+			return true
+		}
+		return slices.ContainsFunc(names, func(name string) bool { return CodecMatches(c, name) })
+	})
+}
+
+// RemoveCodecs removes codecs with given names, along with their RTX and RED payloads.
+func RemoveCodecs(m *sdp.MediaDescription, names ...string) error {
+	return FilterCodecs(m, func(c sdp.Codec) bool {
+		return !slices.ContainsFunc(names, func(name string) bool { return CodecMatches(c, name) })
+	})
+}
+
+// ReorderCodecs moves codecs with given names to the front of the m-line, in the order of names.
+// Other codecs follow in their original order. Attributes are not changed.
+func ReorderCodecs(m *sdp.MediaDescription, names ...string) {
+	codecs := MediaCodecs(m)
+	rank := func(f string) int {
+		pt, err := strconv.ParseUint(f, 10, 8)
+		if err != nil {
+			return len(names)
+		}
+		for _, c := range codecs {
+			if c.PayloadType != uint8(pt) {
+				continue
+			}
+			for i, name := range names {
+				if CodecMatches(c, name) {
+					return i
+				}
+			}
+		}
+		return len(names)
+	}
+	slices.SortStableFunc(m.MediaName.Formats, func(a, b string) int {
+		return rank(a) - rank(b)
+	})
+}
+
+// GetBandwidth returns the value of a b= line of a given type, like BandwidthAS or BandwidthTIAS.
+func GetBandwidth(m *sdp.MediaDescription, typ string) (uint64, bool) {
+	for _, b := range m.Bandwidth {
+		if strings.EqualFold(b.Type, typ) {
+			return b.Bandwidth, true
+		}
+	}
+	return 0, false
+}
+
+// SetBandwidth sets the value of a b= line of a given type, replacing the existing one.
+func SetBandwidth(m *sdp.MediaDescription, typ string, value uint64) {
+	for i, b := range m.Bandwidth {
+		if strings.EqualFold(b.Type, typ) {
+			m.Bandwidth[i].Bandwidth = value
+			return
+		}
+	}
+	m.Bandwidth = append(m.Bandwidth, sdp.Bandwidth{Type: typ, Bandwidth: value})
+}
+
+// RemoveBandwidth removes b= lines of a given type.
+func RemoveBandwidth(m *sdp.MediaDescription, typ string) {
+	m.Bandwidth = slices.DeleteFunc(m.Bandwidth, func(b sdp.Bandwidth) bool {
+		return strings.EqualFold(b.Type, typ)
+	})
+}
+
+// SetMaxBitrate limits media bitrate by setting both b=TIAS and b=AS lines. Zero removes the limit.
+func SetMaxBitrate(m *sdp.MediaDescription, bps uint64) {
+	if bps == 0 {
+		RemoveBandwidth(m, BandwidthTIAS)
+		RemoveBandwidth(m, BandwidthAS)
+		return
+	}
+	SetBandwidth(m, BandwidthTIAS, bps)
+	SetBandwidth(m, BandwidthAS, (bps+999)/1000)
+}
+
+// HeaderExtensions returns RTP header extensions negotiated for the media section.
+func HeaderExtensions(m *sdp.MediaDescription) ([]sdp.ExtMap, error) {
+	var out []sdp.ExtMap
+	for _, a := range m.Attributes {
+		if a.Key != sdp.AttrKeyExtMap {
+			continue
+		}
+		var e sdp.ExtMap
+		if err := e.Unmarshal(a.String()); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
+
+// GetHeaderExtensionID returns the ID of an RTP header extension with a given URI.
+func GetHeaderExtensionID(m *sdp.MediaDescription, uri string) (int, bool) {
+	exts, err := HeaderExtensions(m)
+	if err != nil {
+		return 0, false
+	}
+	for _, e := range exts {
+		if e.URI != nil && e.URI.String() == uri {
+			return e.Value, true
+		}
+	}
+	return 0, false
+}
+
+// AddHeaderExtension adds an RTP header extension to the media section and returns its ID.
+//
+// With BUNDLE, the same ID must not be used for different extensions, so an ID already used for the URI
+// by another media section of the session is reused, and IDs used for other URIs are skipped.
+// IDs above 14 are only allocated when extmap-allow-mixed is negotiated.
+func AddHeaderExtension(desc *sdp.SessionDescription, m *sdp.MediaDescription, uri string) (int, error) {
+	if id, ok := GetHeaderExtensionID(m, uri); ok {
+		return id, nil
+	}
+
+	used := make(map[int]bool)
+	id := 0
+	for _, md := range desc.MediaDescriptions {
+		exts, err := HeaderExtensions(md)
+		if err != nil {
+			return 0, err
+		}
+		for _, e := range exts {
+			if e.URI != nil && e.URI.String() == uri {
+				id = e.Value
+			} else {
+				used[e.Value] = true
+			}
+		}
+	}
+	if id == 0 || used[id] {
+		id = 0
+		maxID := maxOneByteExtensionID
+		_, mixed := desc.Attribute(sdp.AttrKeyExtMapAllowMixed)
+		if _, ok := m.Attribute(sdp.AttrKeyExtMapAllowMixed); ok || mixed {
+			maxID = maxTwoByteExtensionID
+		}
+		for i := 1; i <= maxID; i++ {
+			// 15 is reserved in one-byte header
+			if i != 15 && !used[i] {
+				id = i
+				break
+			}
+		}
+		if id == 0 {
+			return 0, ErrNoFreeExtensionID
+		}
+	}
+
+	attr := sdp.NewAttribute(sdp.AttrKeyExtMap, strconv.Itoa(id)+" "+uri)
+	idx := len(m.Attributes)
+	for i, a := range m.Attributes {
+		if a.Key == sdp.AttrKeyExtMap {
+			idx = i + 1
+		}
+	}
+	m.Attributes = slices.Insert(m.Attributes, idx, attr)
+	return id, nil
+}
+
+// RemoveHeaderExtension removes an RTP header extension with a given URI. It returns false if it was not found.
+func RemoveHeaderExtension(m *sdp.MediaDescription, uri string) bool {
+	found := false
+	m.Attributes = slices.DeleteFunc(m.Attributes, func(a sdp.Attribute) bool {
+		if a.Key != sdp.AttrKeyExtMap {
+			return false
+		}
+		var e sdp.ExtMap
+		if err := e.Unmarshal(a.String()); err != nil || e.URI == nil || e.URI.String() != uri {
+			return false
+		}
+		found = true
+		return true
+	})
+	return found
+}
+
+func isDirectionAttribute(a sdp.Attribute) bool {
+	switch a.Key {
+	case sdp.AttrKeySendRecv, sdp.AttrKeySendOnly, sdp.AttrKeyRecvOnly, sdp.AttrKeyInactive:
+		return true
+	}
+	return false
+}
+
+// GetDirection returns the direction of the media section, sendrecv if not set.
+func GetDirection(m *sdp.MediaDescription) sdp.Direction {
+	for _, a := range m.Attributes {
+		if isDirectionAttribute(a) {
+			dir, _ := sdp.NewDirection(a.Key)
+			return dir
+		}
+	}
+	return sdp.DirectionSendRecv
+}
+
+// SetDirection replaces the direction attribute of the media section, keeping its position.
+// An unknown direction removes the attribute, which means sendrecv.
+func SetDirection(m *sdp.MediaDescription, dir sdp.Direction) {
+	if dir.String() == "" {
+		m.Attributes = slices.DeleteFunc(m.Attributes, isDirectionAttribute)
+		return
+	}
+	attr := sdp.NewPropertyAttribute(dir.String())
+	for i, a := range m.Attributes {
+		if isDirectionAttribute(a) {
+			m.Attributes[i] = attr
+			m.Attributes = append(m.Attributes[:i+1], slices.DeleteFunc(m.Attributes[i+1:], isDirectionAttribute)...)
+			return
+		}
+	}
+	m.Attributes = append(m.Attributes, attr)
+}
+
+// CandidateProtocol returns the transport protocol of an ICE candidate attribute value, in lower case.
+func CandidateProtocol(candidate string) string {
+	fields := strings.Fields(strings.TrimPrefix(candidate, "candidate:"))
+	if len(fields) < 3 {
+		return ""
+	}
+	return strings.ToLower(fields[2])
+}
+
+// StripMediaCandidates removes ICE candidates with given transport protocols, like "udp" or "tcp",
+// from the media section. All candidates are removed if no protocols are given. It returns the number of removed candidates.
+func StripMediaCandidates(m *sdp.MediaDescription, protocols ...string) int {
+	n := len(m.Attributes)
+	m.Attributes = slices.DeleteFunc(m.Attributes, func(a sdp.Attribute) bool {
+		if !a.IsICECandidate() {
+			return false
+		}
+		if len(protocols) == 0 {
+			return true
+		}
+		proto := CandidateProtocol(a.Value)
+		return slices.ContainsFunc(protocols, func(p string) bool { return strings.EqualFold(p, proto) })
+	})
+	return n - len(m.Attributes)
+}
+
+// StripCandidates removes ICE candidates with given transport protocols from all media sections.
+// See StripMediaCandidates.
+func StripCandidates(desc *sdp.SessionDescription, protocols ...string) int {
+	n := 0
+	for _, m := range desc.MediaDescriptions {
+		n += StripMediaCandidates(m, protocols...)
+	}
+	return n
+}
+
+type fmtpParam struct {
+	key   string
+	value string
+}
+
+func parseFmtpParams(fmtp string) []fmtpParam {
+	var out []fmtpParam
+	for _, p := range strings.Split(fmtp, ";") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		k, v, _ := strings.Cut(p, "=")
+		out = append(out, fmtpParam{key: strings.TrimSpace(k), value: strings.TrimSpace(v)})
+	}
+	return out
+}
+
+func formatFmtpParams(params []fmtpParam) string {
+	parts := make([]string, 0, len(params))
+	for _, p := range params {
+		if p.value == "" {
+			parts = append(parts, p.key)
+		} else {
+			parts = append(parts, p.key+"="+p.value)
+		}
+	}
+	return strings.Join(parts, ";")
+}
+
+// SetFmtpParam sets a format parameter of a given payload type, adding an fmtp attribute if needed.
+// Other parameters and their order are preserved.
+func SetFmtpParam(m *sdp.MediaDescription, pt uint8, key, value string) {
+	ptStr := strconv.Itoa(int(pt))
+	idx := -1
+	for i, a := range m.Attributes {
+		if apt, ok := attributePayloadType(a); ok && apt == pt {
+			switch a.Key {
+			case attrKeyFmtp:
+				_, fmtp, _ := strings.Cut(a.Value, " ")
+				params := parseFmtpParams(fmtp)
+				j := slices.IndexFunc(params, func(p fmtpParam) bool { return strings.EqualFold(p.key, key) })
+				if j < 0 {
+					params = append(params, fmtpParam{key: key, value: value})
+				} else {
+					params[j].value = value
+				}
+				m.Attributes[i].Value = ptStr + " " + formatFmtpParams(params)
+				return
+			case attrKeyRtpmap:
+				idx = i + 1
+			}
+		}
+	}
+	if idx < 0 {
+		idx = len(m.Attributes)
+	}
+	attr := sdp.NewAttribute(attrKeyFmtp, ptStr+" "+formatFmtpParams([]fmtpParam{{key: key, value: value}}))
+	m.Attributes = slices.Insert(m.Attributes, idx, attr)
+}
+
+// OpusParams are Opus settings applied by SetOpusParams. Nil fields are not changed.
+type OpusParams struct {
+	// Stereo sets stereo and sprop-stereo format parameters.
+	Stereo *bool
+	// DTX sets usedtx format parameter.
+	DTX *bool
+	// FEC sets useinbandfec format parameter.
+	FEC *bool
+	// RED enables redundant audio (RFC 2198) for Opus. When enabled, RED is added if missing and preferred over Opus.
+	// When disabled, RED payloads carrying Opus are removed.
+	RED *bool
+}
+
+func fmtpBool(v bool) string {
+	if v {
+		return "1"
+	}
+	return "0"
+}
+
+// SetOpusParams applies Opus settings to all Opus payload types of the media section.
+// It returns ErrCodecNotFound if the media section has no Opus codec.
+func SetOpusParams(m *sdp.MediaDescription, p OpusParams) error {
+	var opus []uint8
+	for _, c := range MediaCodecs(m) {
+		if CodecMatches(c, codecNameOpus) {
+			opus = append(opus, c.PayloadType)
+		}
+	}
+	if len(opus) == 0 {
+		return ErrCodecNotFound
+	}
+
+	for _, pt := range opus {
+		if p.Stereo != nil {
+			SetFmtpParam(m, pt, "stereo", fmtpBool(*p.Stereo))
+			SetFmtpParam(m, pt, "sprop-stereo", fmtpBool(*p.Stereo))
+		}
+		if p.DTX != nil {
+			SetFmtpParam(m, pt, "usedtx", fmtpBool(*p.DTX))
+		}
+		if p.FEC != nil {
+			SetFmtpParam(m, pt, "useinbandfec", fmtpBool(*p.FEC))
+		}
+	}
+
+	if p.RED != nil {
+		if *p.RED {
+			for _, pt := range opus {
+				if err := enableOpusRED(m, pt); err != nil {
+					return err
+				}
+			}
+		} else {
+			carriesOpus := make(map[uint8]bool)
+			for _, c := range MediaCodecs(m) {
+				if CodecMatches(c, codecNameRED) {
+					carriesOpus[c.PayloadType] = slices.ContainsFunc(associatedPayloadTypes(c), func(pt uint8) bool {
+						return slices.Contains(opus, pt)
+					})
+				}
+			}
+			removePayloadTypes(m, func(pt uint8) bool { return carriesOpus[pt] })
+		}
+	}
+	return nil
+}
+
+func enableOpusRED(m *sdp.MediaDescription, opus uint8) error {
+	redPT := -1
+	for _, c := range MediaCodecs(m) {
+		if CodecMatches(c, codecNameRED) && slices.Contains(associatedPayloadTypes(c), opus) {
+			redPT = int(c.PayloadType)
+			break
+		}
+	}
+	if redPT < 0 {
+		pt, err := freePayloadType(m)
+		if err != nil {
+			return err
+		}
+		redPT = int(pt)
+		opusStr := strconv.Itoa(int(opus))
+		m.Attributes = append(m.Attributes,
+			sdp.NewAttribute(attrKeyRtpmap, strconv.Itoa(redPT)+" "+codecNameRED+"/48000/2"),
+			sdp.NewAttribute(attrKeyFmtp, strconv.Itoa(redPT)+" "+opusStr+"/"+opusStr),
+		)
+	}
+
+	// RED goes right before Opus, so it is preferred.
+	redStr, opusStr := strconv.Itoa(redPT), strconv.Itoa(int(opus))
+	formats := slices.DeleteFunc(m.MediaName.Formats, func(f string) bool { return f == redStr })
+	i := slices.Index(formats, opusStr)
+	m.MediaName.Formats = slices.Insert(formats, i, redStr)
+	return nil
+}
+
+// freePayloadType returns a dynamic payload type not used by the media section.
+func freePayloadType(m *sdp.MediaDescription) (uint8, error) {
+	used := make(map[uint8]bool)
+	for _, f := range m.MediaName.Formats {
+		if pt, err := strconv.ParseUint(f, 10, 8); err == nil {
+			used[uint8(pt)] = true
+		}
+	}
+	for _, a := range m.Attributes {
+		if pt, ok := attributePayloadType(a); ok {
+			used[pt] = true
+		}
+	}
+	// Dynamic range first, then 35-63, which browsers use once 96-127 are taken.
+	for pt := uint8(96); pt <= 127; pt++ {
+		if !used[pt] {
+			return pt, nil
+		}
+	}
+	for pt := uint8(63); pt >= 35; pt-- {
+		if !used[pt] {
+			return pt, nil
+		}
+	}
+	return 0, ErrNoFreePayloadType
+}
@@ -0,0 +1,317 @@
+package sdp
+
+import (
+	"strings"
+	"testing"
+
+	pionsdp "github.com/pion/sdp/v3"
+	"github.com/stretchr/testify/require"
+)
+
+const mungeTestSDP = "v=0\r\n" +
+	"o=- 4648475892259889561 3 IN IP4 127.0.0.1\r\n" +
+	"s=-\r\n" +
+	"t=0 0\r\n" +
+	"a=group:BUNDLE 0 1\r\n" +
+	"a=extmap-allow-mixed\r\n" +
+	"m=audio 9 UDP/TLS/RTP/SAVPF 111 63 0\r\n" +
+	"c=IN IP4 0.0.0.0\r\n" +
+	"a=mid:0\r\n" +
+	"a=extmap:1 urn:ietf:params:rtp-hdrext:ssrc-audio-level\r\n" +
+	"a=extmap:3 urn:ietf:params:rtp-hdrext:sdes:mid\r\n" +
+	"a=sendrecv\r\n" +
+	"a=rtcp-mux\r\n" +
+	"a=rtpmap:111 opus/48000/2\r\n" +
+	"a=rtcp-fb:111 transport-cc\r\n" +
+	"a=fmtp:111 minptime=10;useinbandfec=1\r\n" +
+	"a=rtpmap:63 red/48000/2\r\n" +
+	"a=fmtp:63 111/111\r\n" +
+	"a=candidate:1387637174 1 udp 2122260223 192.0.2.1 61764 typ host generation 0\r\n" +
+	"a=candidate:473322822 1 tcp 1518280447 192.0.2.1 9 typ host tcptype active generation 0\r\n" +
+	"m=video 9 UDP/TLS/RTP/SAVPF 96 97 102 103 45\r\n" +
+	"c=IN IP4 0.0.0.0\r\n" +
+	"b=AS:2500\r\n" +
+	"a=mid:1\r\n" +
+	"a=extmap:3 urn:ietf:params:rtp-hdrext:sdes:mid\r\n" +
+	"a=extmap:4 urn:ietf:params:rtp-hdrext:sdes:rtp-stream-id\r\n" +
+	"a=sendonly\r\n" +
+	"a=rtcp-mux\r\n" +
+	"a=rtcp-fb:* nack\r\n" +
+	"a=rtpmap:96 VP8/90000\r\n" +
+	"a=rtcp-fb:96 goog-remb\r\n" +
+	"a=rtpmap:97 rtx/90000\r\n" +
+	"a=fmtp:97 apt=96\r\n" +
+	"a=rtpmap:102 H264/90000\r\n" +
+	"a=rtcp-fb:102 goog-remb\r\n" +
+	"a=fmtp:102 level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42e01f\r\n" +
+	"a=rtpmap:103 rtx/90000\r\n" +
+	"a=fmtp:103 apt=102\r\n" +
+	"a=rtpmap:45 AV1/90000\r\n" +
+	"a=candidate:1387637174 1 udp 2122260223 192.0.2.1 61764 typ host generation 0\r\n" +
+	"a=candidate:473322822 1 tcp 1518280447 192.0.2.1 9 typ host tcptype active generation 0\r\n"
+
+func parseMungeTestSDP(t *testing.T) *pionsdp.SessionDescription {
+	desc := &pionsdp.SessionDescription{}
+	require.NoError(t, desc.UnmarshalString(mungeTestSDP))
+	return desc
+}
+
+// roundTrip marshals and parses the description again, checking that nothing is lost.
+func roundTrip(t *testing.T, desc *pionsdp.SessionDescription) *pionsdp.SessionDescription {
+	data, err := desc.Marshal()
+	require.NoError(t, err)
+	out := &pionsdp.SessionDescription{}
+	require.NoError(t, out.Unmarshal(data))
+	data2, err := out.Marshal()
+	require.NoError(t, err)
+	require.Equal(t, string(data), string(data2))
+	return out
+}
+
+func codecNames(m *pionsdp.MediaDescription) []string {
+	var names []string
+	for _, c := range MediaCodecs(m) {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+func attributeValues(m *pionsdp.MediaDescription, key string) []string {
+	var out []string
+	for _, a := range m.Attributes {
+		if a.Key == key {
+			out = append(out, a.Value)
+		}
+	}
+	return out
+}
+
+func TestRoundTrip(t *testing.T) {
+	desc := parseMungeTestSDP(t)
+	data, err := roundTrip(t, desc).Marshal()
+	require.NoError(t, err)
+	require.Equal(t, mungeTestSDP, string(data))
+}
+
+func TestFilterCodecs(t *testing.T) {
+	t.Run("keep", func(t *testing.T) {
+		desc := parseMungeTestSDP(t)
+		video := desc.MediaDescriptions[1]
+		require.Equal(t, []string{"VP8", "rtx", "H264", "rtx", "AV1"}, codecNames(video))
+
+		require.NoError(t, KeepCodecs(video, "video/h264"))
+		video = roundTrip(t, desc).MediaDescriptions[1]
+		require.Equal(t, []string{"102", "103"}, video.MediaName.Formats)
+		require.Equal(t, []string{"102 H264/90000", "103 rtx/90000"}, attributeValues(video, "rtpmap"))
+		require.Equal(t, []string{"* nack", "102 goog-remb"}, attributeValues(video, "rtcp-fb"))
+		require.Len(t, attributeValues(video, "fmtp"), 2)
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		desc := parseMungeTestSDP(t)
+		audio := desc.MediaDescriptions[0]
+		require.NoError(t, RemoveCodecs(audio, "opus"))
+		audio = roundTrip(t, desc).MediaDescriptions[0]
+		// RED only carries Opus, so it goes as well.
+		require.Equal(t, []string{"0"}, audio.MediaName.Formats)
+		require.Empty(t, attributeValues(audio, "rtpmap"))
+		require.Empty(t, attributeValues(audio, "fmtp"))
+	})
+
+	t.Run("nothing left", func(t *testing.T) {
+		desc := parseMungeTestSDP(t)
+		video := desc.MediaDescriptions[1]
+		require.ErrorIs(t, KeepCodecs(video, "VP9"), ErrCodecNotFound)
+		require.Len(t, video.MediaName.Formats, 5)
+	})
+
+	t.Run("reorder", func(t *testing.T) {
+		desc := parseMungeTestSDP(t)
+		video := desc.MediaDescriptions[1]
+		ReorderCodecs(video, "AV1", "H264")
+		video = roundTrip(t, desc).MediaDescriptions[1]
+		require.Equal(t, []string{"45", "102", "96", "97", "103"}, video.MediaName.Formats)
+	})
+}
+
+func TestBandwidth(t *testing.T) {
+	desc := parseMungeTestSDP(t)
+	audio, video := desc.MediaDescriptions[0], desc.MediaDescriptions[1]
+
+	as, ok := GetBandwidth(video, BandwidthAS)
+	require.True(t, ok)
+	require.EqualValues(t, 2500, as)
+
+	SetMaxBitrate(video, 1_200_500)
+	SetBandwidth(audio, BandwidthTIAS, 64_000)
+
+	desc = roundTrip(t, desc)
+	audio, video = desc.MediaDescriptions[0], desc.MediaDescriptions[1]
+	as, _ = GetBandwidth(video, BandwidthAS)
+	require.EqualValues(t, 1201, as)
+	tias, _ := GetBandwidth(video, BandwidthTIAS)
+	require.EqualValues(t, 1_200_500, tias)
+	tias, _ = GetBandwidth(audio, BandwidthTIAS)
+	require.EqualValues(t, 64_000, tias)
+	_, ok = GetBandwidth(audio, BandwidthAS)
+	require.False(t, ok)
+
+	SetMaxBitrate(video, 0)
+	require.Empty(t, video.Bandwidth)
+}
+
+func TestHeaderExtensions(t *testing.T) {
+	desc := parseMungeTestSDP(t)
+	audio, video := desc.MediaDescriptions[0], desc.MediaDescriptions[1]
+
+	// Already present.
+	id, err := AddHeaderExtension(desc, video, pionsdp.SDESMidURI)
+	require.NoError(t, err)
+	require.Equal(t, 3, id)
+
+	// Used by another media section, so the same ID is reused.
+	id, err = AddHeaderExtension(desc, video, pionsdp.AudioLevelURI)
+	require.NoError(t, err)
+	require.Equal(t, 1, id)
+
+	// New extension gets the lowest ID not used in the session.
+	id, err = AddHeaderExtension(desc, audio, pionsdp.TransportCCURI)
+	require.NoError(t, err)
+	require.Equal(t, 2, id)
+
+	require.True(t, RemoveHeaderExtension(video, pionsdp.SDESRTPStreamIDURI))
+	require.False(t, RemoveHeaderExtension(video, pionsdp.SDESRTPStreamIDURI))
+
+	desc = roundTrip(t, desc)
+	audio, video = desc.MediaDescriptions[0], desc.MediaDescriptions[1]
+	require.Equal(t, []string{
+		"1 " + pionsdp.AudioLevelURI,
+		"3 " + pionsdp.SDESMidURI,
+		"2 " + pionsdp.TransportCCURI,
+	}, attributeValues(audio, "extmap"))
+	require.Equal(t, []string{
+		"3 " + pionsdp.SDESMidURI,
+		"1 " + pionsdp.AudioLevelURI,
+	}, attributeValues(video, "extmap"))
+
+	exts, err := HeaderExtensions(audio)
+	require.NoError(t, err)
+	require.Len(t, exts, 3)
+	id, ok := GetHeaderExtensionID(audio, pionsdp.TransportCCURI)
+	require.True(t, ok)
+	require.Equal(t, 2, id)
+
+	// Without extmap-allow-mixed, only one-byte IDs are available.
+	m := &pionsdp.MediaDescription{}
+	single := &pionsdp.SessionDescription{MediaDescriptions: []*pionsdp.MediaDescription{m}}
+	for i := 1; i <= 14; i++ {
+		_, err = AddHeaderExtension(single, m, "urn:test:"+strings.Repeat("x", i))
+		require.NoError(t, err)
+	}
+	_, err = AddHeaderExtension(single, m, "urn:test:other")
+	require.ErrorIs(t, err, ErrNoFreeExtensionID)
+
+	single.Attributes = append(single.Attributes, pionsdp.NewPropertyAttribute(pionsdp.AttrKeyExtMapAllowMixed))
+	id, err = AddHeaderExtension(single, m, "urn:test:other")
+	require.NoError(t, err)
+	require.Equal(t, 16, id)
+}
+
+func TestDirection(t *testing.T) {
+	desc := parseMungeTestSDP(t)
+	audio, video := desc.MediaDescriptions[0], desc.MediaDescriptions[1]
+	require.Equal(t, pionsdp.DirectionSendRecv, GetDirection(audio))
+	require.Equal(t, pionsdp.DirectionSendOnly, GetDirection(video))
+
+	SetDirection(audio, pionsdp.DirectionRecvOnly)
+	SetDirection(video, pionsdp.DirectionInactive)
+	desc = roundTrip(t, desc)
+	audio, video = desc.MediaDescriptions[0], desc.MediaDescriptions[1]
+	require.Equal(t, pionsdp.DirectionRecvOnly, GetDirection(audio))
+	require.Equal(t, pionsdp.DirectionInactive, GetDirection(video))
+	// Position is kept.
+	require.Equal(t, "recvonly", audio.Attributes[3].Key)
+
+	m := &pionsdp.MediaDescription{Attributes: []pionsdp.Attribute{
+		pionsdp.NewPropertyAttribute("sendonly"),
+		pionsdp.NewPropertyAttribute("rtcp-mux"),
+		pionsdp.NewPropertyAttribute("recvonly"),
+	}}
+	SetDirection(m, pionsdp.DirectionSendRecv)
+	require.Equal(t, []pionsdp.Attribute{
+		pionsdp.NewPropertyAttribute("sendrecv"),
+		pionsdp.NewPropertyAttribute("rtcp-mux"),
+	}, m.Attributes)
+
+	SetDirection(m, pionsdp.Direction(0))
+	require.Equal(t, []pionsdp.Attribute{pionsdp.NewPropertyAttribute("rtcp-mux")}, m.Attributes)
+	require.Equal(t, pionsdp.DirectionSendRecv, GetDirection(m))
+}
+
+func TestStripCandidates(t *testing.T) {
+	desc := parseMungeTestSDP(t)
+	require.Equal(t, "tcp", CandidateProtocol("candidate:473322822 1 TCP 1518280447 192.0.2.1 9 typ host"))
+
+	require.Equal(t, 2, StripCandidates(desc, "TCP"))
+	desc = roundTrip(t, desc)
+	for _, m := range desc.MediaDescriptions {
+		candidates := attributeValues(m, "candidate")
+		require.Len(t, candidates, 1)
+		require.Equal(t, "udp", CandidateProtocol(candidates[0]))
+	}
+
+	require.Equal(t, 1, StripMediaCandidates(desc.MediaDescriptions[0]))
+	require.Empty(t, attributeValues(desc.MediaDescriptions[0], "candidate"))
+}
+
+func TestSetOpusParams(t *testing.T) {
+	yes, no := true, false
+
+	t.Run("fmtp", func(t *testing.T) {
+		desc := parseMungeTestSDP(t)
+		audio := desc.MediaDescriptions[0]
+		require.NoError(t, SetOpusParams(audio, OpusParams{Stereo: &yes, DTX: &yes, FEC: &no}))
+		audio = roundTrip(t, desc).MediaDescriptions[0]
+		require.Equal(t, []string{
+			"111 minptime=10;useinbandfec=0;stereo=1;sprop-stereo=1;usedtx=1",
+			"63 111/111",
+		}, attributeValues(audio, "fmtp"))
+
+		require.ErrorIs(t, SetOpusParams(desc.MediaDescriptions[1], OpusParams{DTX: &yes}), ErrCodecNotFound)
+	})
+
+	t.Run("fmtp added", func(t *testing.T) {
+		m := &pionsdp.MediaDescription{
+			MediaName: pionsdp.MediaName{Media: "audio", Formats: []string{"109"}},
+			Attributes: []pionsdp.Attribute{
+				pionsdp.NewAttribute("rtpmap", "109 opus/48000/2"),
+				pionsdp.NewPropertyAttribute("rtcp-mux"),
+			},
+		}
+		require.NoError(t, SetOpusParams(m, OpusParams{DTX: &yes}))
+		require.Equal(t, pionsdp.NewAttribute("fmtp", "109 usedtx=1"), m.Attributes[1])
+	})
+
+	t.Run("disable red", func(t *testing.T) {
+		desc := parseMungeTestSDP(t)
+		audio := desc.MediaDescriptions[0]
+		require.NoError(t, SetOpusParams(audio, OpusParams{RED: &no}))
+		audio = roundTrip(t, desc).MediaDescriptions[0]
+		require.Equal(t, []string{"111", "0"}, audio.MediaName.Formats)
+		require.Equal(t, []string{"111 minptime=10;useinbandfec=1"}, attributeValues(audio, "fmtp"))
+	})
+
+	t.Run("enable red", func(t *testing.T) {
+		desc := parseMungeTestSDP(t)
+		audio := desc.MediaDescriptions[0]
+		require.NoError(t, SetOpusParams(audio, OpusParams{RED: &yes}))
+		require.Equal(t, []string{"63", "111", "0"}, audio.MediaName.Formats)
+
+		require.NoError(t, SetOpusParams(audio, OpusParams{RED: &no}))
+		require.NoError(t, SetOpusParams(audio, OpusParams{RED: &yes}))
+		audio = roundTrip(t, desc).MediaDescriptions[0]
+		require.Equal(t, []string{"96", "111", "0"}, audio.MediaName.Formats)
+		require.Equal(t, []string{"111 opus/48000/2", "96 red/48000/2"}, attributeValues(audio, "rtpmap"))
+		require.Equal(t, []string{"111 minptime=10;useinbandfec=1", "96 111/111"}, attributeValues(audio, "fmtp"))
+	})
+}
@@ -0,0 +1,228 @@
+// Copyright 2025 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cdr converts SIP call information into call detail records (CDR) suitable for billing.
+package cdr
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/sip"
+)
+
+// ErrCallInProgress is returned when a record is requested for a call that hasn't ended yet.
+var ErrCallInProgress = errors.New("sip call is still in progress")
+
+// Record is a single call detail record.
+type Record struct {
+	CallID              string
+	Direction           string
+	TrunkID             string
+	DispatchRuleID      string
+	Region              string
+	From                string
+	FromHost            string
+	To                  string
+	ToHost              string
+	RoomName            string
+	RoomID              string
+	ParticipantIdentity string
+	Status              string
+	SIPStatusCode       int
+	DisconnectReason    string
+	Error               string
+	AudioCodec          string
+	MediaEncryption     string
+	CreatedAt           time.Time
+	AnsweredAt          time.Time
+	EndedAt             time.Time
+	RingDuration        time.Duration
+	TalkDuration        time.Duration
+	// BillableSeconds is TalkDuration rounded up to a whole second.
+	BillableSeconds int64
+}
+
+// Answered returns true if the call was answered.
+func (r *Record) Answered() bool {
+	return !r.AnsweredAt.IsZero()
+}
+
+type options struct {
+	Mask   *sip.PhoneMaskPolicy
+	Hidden func(info *livekit.SIPCallInfo) bool
+}
+
+type Option func(opt *options)
+
+// WithPhoneMaskPolicy sets a masking policy for external phone numbers.
+// When a policy is set, the number is masked in every record, unless WithHidden is also set.
+// Without a policy, legacy masking (last 4 digits) is used for hidden numbers, same as in sip.EvaluateDispatchRule.
+func WithPhoneMaskPolicy(p *sip.PhoneMaskPolicy) Option {
+	return func(opt *options) {
+		opt.Mask = p
+	}
+}
+
+// WithHidden overrides the check whether the external phone number of the call must be hidden.
+//
+// By default, the number is always masked if a mask policy is set. Otherwise, it is considered hidden
+// if participant attributes are set by LiveKit SIP, but livekit.AttrSIPPhoneNumber does not match
+// the external number (HidePhoneNumber either omits the attribute or sets it to the masked number).
+func WithHidden(fnc func(info *livekit.SIPCallInfo) bool) Option {
+	return func(opt *options) {
+		opt.Hidden = fnc
+	}
+}
+
+func (opt *options) defaults() {
+	if opt.Hidden == nil {
+		if opt.Mask != nil {
+			opt.Hidden = func(info *livekit.SIPCallInfo) bool { return true }
+		} else {
+			opt.Hidden = isHidden
+		}
+	}
+}
+
+func isHidden(info *livekit.SIPCallInfo) bool {
+	attrs := info.ParticipantAttributes
+	if len(attrs) == 0 {
+		return false
+	}
+	if _, ok := attrs[livekit.AttrSIPCallID]; !ok {
+		// Not set by LiveKit SIP, cannot tell.
+		return false
+	}
+	return normalizeNumber(attrs[livekit.AttrSIPPhoneNumber]) != normalizeNumber(externalNumber(info))
+}
+
+// externalNumber returns the number of the remote party, as opposed to the number of the trunk.
+func externalNumber(info *livekit.SIPCallInfo) string {
+	if info.CallDirection == livekit.SIPCallDirection_SCD_OUTBOUND {
+		return info.ToUri.GetUser()
+	}
+	return info.FromUri.GetUser()
+}
+
+func normalizeNumber(num string) string {
+	return strings.TrimPrefix(num, "+")
+}
+
+func nsTime(ns int64) time.Time {
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns).UTC()
+}
+
+// NewRecord converts a finished SIP call into a call detail record.
+// It returns ErrCallInProgress if the call hasn't ended yet.
+func NewRecord(info *livekit.SIPCallInfo, opts ...Option) (*Record, error) {
+	var opt options
+	for _, fnc := range opts {
+		fnc(&opt)
+	}
+	opt.defaults()
+	switch info.CallStatus {
+	case livekit.SIPCallStatus_SCS_DISCONNECTED, livekit.SIPCallStatus_SCS_ERROR:
+	default:
+		return nil, ErrCallInProgress
+	}
+	r := &Record{
+		CallID:              info.CallId,
+		Direction:           directionName(info.CallDirection),
+		TrunkID:             info.TrunkId,
+		DispatchRuleID:      info.DispatchRuleId,
+		Region:              info.Region,
+		From:                info.FromUri.GetUser(),
+		FromHost:            info.FromUri.GetHost(),
+		To:                  info.ToUri.GetUser(),
+		ToHost:              info.ToUri.GetHost(),
+		RoomName:            info.RoomName,
+		RoomID:              info.RoomId,
+		ParticipantIdentity: info.ParticipantIdentity,
+		Status:              strings.ToLower(strings.TrimPrefix(info.CallStatus.String(), "SCS_")),
+		Error:               info.Error,
+		AudioCodec:          info.AudioCodec,
+		MediaEncryption:     info.MediaEncryption,
+		CreatedAt:           nsTime(info.CreatedAtNs),
+		AnsweredAt:          nsTime(info.StartedAtNs),
+		EndedAt:             nsTime(info.EndedAtNs),
+	}
+	if st := info.CallStatusCode; st != nil {
+		r.SIPStatusCode = int(st.Code)
+	}
+	if info.DisconnectReason != livekit.DisconnectReason_UNKNOWN_REASON {
+		r.DisconnectReason = info.DisconnectReason.String()
+	}
+	if opt.Hidden(info) {
+		// Only the external number is hidden, the number of the trunk is still visible.
+		r.ParticipantIdentity = hideIdentity(r.ParticipantIdentity, externalNumber(info), opt.Mask)
+		switch info.CallDirection {
+		case livekit.SIPCallDirection_SCD_OUTBOUND:
+			r.To = opt.Mask.MaskNumber(r.To)
+			r.ToHost = ""
+		default:
+			r.From = opt.Mask.MaskNumber(r.From)
+			r.FromHost = ""
+		}
+	}
+	r.computeDurations()
+	return r, nil
+}
+
+// hideIdentity replaces the external number in the participant identity with its hash,
+// the same way sip.EvaluateDispatchRule derives the identity when the number is hidden.
+func hideIdentity(id, num string, mask *sip.PhoneMaskPolicy) string {
+	if num == "" {
+		return id
+	}
+	hash := mask.HashNumber(num)
+	id = strings.ReplaceAll(id, num, hash)
+	if norm := normalizeNumber(num); norm != "" && norm != num {
+		id = strings.ReplaceAll(id, norm, hash)
+	}
+	return id
+}
+
+func (r *Record) computeDurations() {
+	if r.CreatedAt.IsZero() {
+		return
+	}
+	ringEnd := r.AnsweredAt
+	if ringEnd.IsZero() {
+		// Never answered, so it was ringing the whole time.
+		ringEnd = r.EndedAt
+	}
+	if !ringEnd.IsZero() && ringEnd.After(r.CreatedAt) {
+		r.RingDuration = ringEnd.Sub(r.CreatedAt)
+	}
+	if r.Answered() && r.EndedAt.After(r.AnsweredAt) {
+		r.TalkDuration = r.EndedAt.Sub(r.AnsweredAt)
+		r.BillableSeconds = int64((r.TalkDuration + time.Second - 1) / time.Second)
+	}
+}
+
+func directionName(d livekit.SIPCallDirection) string {
+	switch d {
+	case livekit.SIPCallDirection_SCD_INBOUND:
+		return "inbound"
+	case livekit.SIPCallDirection_SCD_OUTBOUND:
+		return "outbound"
+	}
+	return "unknown"
+}
@@ -0,0 +1,300 @@
+// Copyright 2025 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdr
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/sip"
+	"github.com/livekit/protocol/utils"
+)
+
+var testStart = time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+
+func testCall() *livekit.SIPCallInfo {
+	return &livekit.SIPCallInfo{
+		CallId:              "SCL_1",
+		TrunkId:             "ST_1",
+		DispatchRuleId:      "SDR_1",
+		RoomName:            "room",
+		ParticipantIdentity: "sip_+14155550100",
+		FromUri:             &livekit.SIPUri{User: "+14155550100", Host: "carrier.example.com"},
+		ToUri:               &livekit.SIPUri{User: "+15550199", Host: "lk.example.com"},
+		CallDirection:       livekit.SIPCallDirection_SCD_INBOUND,
+		CallStatus:          livekit.SIPCallStatus_SCS_DISCONNECTED,
+		CreatedAtNs:         testStart.UnixNano(),
+		StartedAtNs:         testStart.Add(5 * time.Second).UnixNano(),
+		EndedAtNs:           testStart.Add(65*time.Second + 200*time.Millisecond).UnixNano(),
+		DisconnectReason:    livekit.DisconnectReason_CLIENT_INITIATED,
+		CallStatusCode:      &livekit.SIPStatus{Code: livekit.SIPStatusCode_SIP_STATUS_OK},
+		AudioCodec:          "PCMU",
+		ParticipantAttributes: map[string]string{
+			livekit.AttrSIPCallID:      "SCL_1",
+			livekit.AttrSIPPhoneNumber: "+14155550100",
+		},
+	}
+}
+
+func TestNewRecord(t *testing.T) {
+	r, err := NewRecord(testCall())
+	require.NoError(t, err)
+	require.Equal(t, "inbound", r.Direction)
+	require.Equal(t, "disconnected", r.Status)
+	require.Equal(t, 200, r.SIPStatusCode)
+	require.Equal(t, "CLIENT_INITIATED", r.DisconnectReason)
+	require.Equal(t, "+14155550100", r.From)
+	require.Equal(t, 5*time.Second, r.RingDuration)
+	require.Equal(t, 60*time.Second+200*time.Millisecond, r.TalkDuration)
+	require.Equal(t, int64(61), r.BillableSeconds)
+
+	t.Run("unanswered", func(t *testing.T) {
+		c := testCall()
+		c.StartedAtNs = 0
+		c.CallStatus = livekit.SIPCallStatus_SCS_ERROR
+		c.CallStatusCode = &livekit.SIPStatus{Code: livekit.SIPStatusCode_SIP_STATUS_BUSY_HERE}
+		r, err := NewRecord(c)
+		require.NoError(t, err)
+		require.False(t, r.Answered())
+		require.Equal(t, "error", r.Status)
+		require.Equal(t, 486, r.SIPStatusCode)
+		require.Equal(t, 65*time.Second+200*time.Millisecond, r.RingDuration)
+		require.Zero(t, r.TalkDuration)
+		require.Zero(t, r.BillableSeconds)
+	})
+
+	t.Run("in progress", func(t *testing.T) {
+		c := testCall()
+		c.CallStatus = livekit.SIPCallStatus_SCS_ACTIVE
+		_, err := NewRecord(c)
+		require.ErrorIs(t, err, ErrCallInProgress)
+	})
+
+	t.Run("hidden", func(t *testing.T) {
+		c := testCall()
+		delete(c.ParticipantAttributes, livekit.AttrSIPPhoneNumber)
+		r, err := NewRecord(c)
+		require.NoError(t, err)
+		require.Equal(t, "0100", r.From)
+		require.Empty(t, r.FromHost)
+		require.Equal(t, "+15550199", r.To)
+		require.Equal(t, "sip_"+(*sip.PhoneMaskPolicy)(nil).HashNumber("+14155550100"), r.ParticipantIdentity)
+		require.NotContains(t, r.ParticipantIdentity, "4155550100")
+
+		r, err = NewRecord(c, WithPhoneMaskPolicy(&sip.PhoneMaskPolicy{KeepCountryCode: true, KeepLast: 2}))
+		require.NoError(t, err)
+		require.Equal(t, "+1********00", r.From)
+		require.NotContains(t, r.ParticipantIdentity, "4155550100")
+
+		// Identity without the leading plus is hidden as well.
+		c.ParticipantIdentity = "sip_14155550100"
+		r, err = NewRecord(c)
+		require.NoError(t, err)
+		require.NotContains(t, r.ParticipantIdentity, "4155550100")
+
+		// HidePhoneNumber with EmitAttribute sets the masked number as the attribute.
+		c = testCall()
+		c.ParticipantAttributes[livekit.AttrSIPPhoneNumber] = "0100"
+		r, err = NewRecord(c)
+		require.NoError(t, err)
+		require.Equal(t, "0100", r.From)
+		require.Empty(t, r.FromHost)
+
+		// With a policy, the number is always masked.
+		for _, attr := range []string{"+1********00", "+14155550100"} {
+			c.ParticipantAttributes[livekit.AttrSIPPhoneNumber] = attr
+			r, err = NewRecord(c, WithPhoneMaskPolicy(&sip.PhoneMaskPolicy{KeepCountryCode: true, KeepLast: 2, EmitAttribute: true}))
+			require.NoError(t, err)
+			require.Equal(t, "+1********00", r.From)
+		}
+
+		c = testCall()
+		c.CallDirection = livekit.SIPCallDirection_SCD_OUTBOUND
+		r, err = NewRecord(c, WithHidden(func(info *livekit.SIPCallInfo) bool { return true }))
+		require.NoError(t, err)
+		require.Equal(t, "+14155550100", r.From)
+		require.Equal(t, "0199", r.To)
+	})
+}
+
+func TestEncode(t *testing.T) {
+	r, err := NewRecord(testCall())
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	enc, err := NewEncoder(&buf, FormatCSV)
+	require.NoError(t, err)
+	require.NoError(t, enc.Encode(r))
+	require.NoError(t, enc.Encode(r))
+	require.NoError(t, enc.Flush())
+	rows, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	require.Equal(t, Columns, rows[0])
+	row := make(map[string]string)
+	for i, c := range Columns {
+		row[c] = rows[1][i]
+	}
+	require.Equal(t, "SCL_1", row["call_id"])
+	require.Equal(t, "2025-03-01T10:00:05Z", row["answered_at"])
+	require.Equal(t, "60.200", row["talk_seconds"])
+	require.Equal(t, "61", row["billable_seconds"])
+
+	buf.Reset()
+	enc, err = NewEncoder(&buf, FormatJSONLines)
+	require.NoError(t, err)
+	require.NoError(t, enc.Encode(r))
+	var m map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &m))
+	require.Equal(t, "SCL_1", m["call_id"])
+	require.Equal(t, 60.2, m["talk_seconds"])
+	require.Equal(t, float64(61), m["billable_seconds"])
+	for k := range m {
+		require.Contains(t, Columns, k)
+	}
+}
+
+func TestEncodeCSVFormulas(t *testing.T) {
+	r, err := NewRecord(testCall())
+	require.NoError(t, err)
+	r.From = "=HYPERLINK(\"http://evil\")"
+	r.Error = "-2+3"
+
+	var buf bytes.Buffer
+	enc, err := NewEncoder(&buf, FormatCSV)
+	require.NoError(t, err)
+	require.NoError(t, enc.Encode(r))
+	require.NoError(t, enc.Flush())
+	rows, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	row := make(map[string]string)
+	for i, c := range Columns {
+		row[c] = rows[1][i]
+	}
+	require.Equal(t, "'=HYPERLINK(\"http://evil\")", row["from"])
+	require.Equal(t, "'-2+3", row["error"])
+	require.Equal(t, "+15550199", row["to"])
+
+	for _, c := range []struct {
+		in, out string
+	}{
+		{"", ""},
+		{"+14155550100", "+14155550100"},
+		{"+", "'+"},
+		{"+1 (415) 555-0100", "'+1 (415) 555-0100"},
+		{"+1+cmd", "'+1+cmd"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\t=1", "'\t=1"},
+		{"\r=1", "'\r=1"},
+		{"alice", "alice"},
+	} {
+		require.Equal(t, c.out, csvSafe(c.in), c.in)
+	}
+}
+
+func TestWriterConfigYAML(t *testing.T) {
+	var conf WriterConfig
+	require.NoError(t, yaml.Unmarshal([]byte("format: jsonl\nmax_records: 10\n"), &conf))
+	require.Equal(t, FormatJSONLines, conf.Format)
+	require.Equal(t, 10, conf.MaxRecords)
+
+	data, err := yaml.Marshal(conf)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "format: jsonl")
+
+	require.Error(t, yaml.Unmarshal([]byte("format: xml\n"), &conf))
+}
+
+func TestWriter(t *testing.T) {
+	dir := t.TempDir()
+	clk := &utils.SimulatedClock{}
+	clk.Set(testStart)
+	w, err := NewWriter(&DirSink{Dir: dir}, WriterConfig{
+		Format:     FormatJSONLines,
+		MaxRecords: 2,
+		MaxAge:     time.Minute,
+	}, WithClock(clk))
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, w.WriteCallInfo(testCall()))
+	}
+	clk.Add(2 * time.Minute)
+	require.NoError(t, w.WriteCallInfo(testCall()))
+	c := testCall()
+	c.CallStatus = livekit.SIPCallStatus_SCS_ACTIVE
+	require.ErrorIs(t, w.WriteCallInfo(c), ErrCallInProgress)
+	require.NoError(t, w.Close())
+	require.Error(t, w.WriteCallInfo(testCall()))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	require.NoError(t, err)
+	sort.Strings(files)
+	var names []string
+	var lines []int
+	for _, f := range files {
+		names = append(names, filepath.Base(f))
+		data, err := os.ReadFile(f)
+		require.NoError(t, err)
+		lines = append(lines, bytes.Count(data, []byte("\n")))
+	}
+	require.Equal(t, []string{
+		"cdr-20250301T100000Z-0001.jsonl",
+		"cdr-20250301T100000Z-0002.jsonl",
+		"cdr-20250301T100200Z-0003.jsonl",
+	}, names)
+	require.Equal(t, []int{2, 1, 1}, lines)
+}
+
+type memSink struct {
+	segments []*bytes.Buffer
+}
+
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
+
+func (s *memSink) Create(name string) (io.WriteCloser, error) {
+	b := new(bytes.Buffer)
+	s.segments = append(s.segments, b)
+	return nopCloser{b}, nil
+}
+
+func TestWriterMaxBytes(t *testing.T) {
+	s := &memSink{}
+	w, err := NewWriter(s, WriterConfig{Format: FormatCSV, MaxBytes: 10})
+	require.NoError(t, err)
+	require.NoError(t, w.WriteCallInfo(testCall()))
+	require.NoError(t, w.WriteCallInfo(testCall()))
+	require.NoError(t, w.Close())
+	require.Len(t, s.segments, 2)
+	for _, seg := range s.segments {
+		rows, err := csv.NewReader(seg).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, rows, 2, "each segment must have a header")
+	}
+}
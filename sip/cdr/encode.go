@@ -0,0 +1,281 @@
+// Copyright 2025 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdr
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format of the CDR output.
+type Format int
+
+const (
+	FormatCSV Format = iota
+	FormatJSONLines
+)
+
+// Ext returns a file extension for the format.
+func (f Format) Ext() string {
+	switch f {
+	case FormatCSV:
+		return ".csv"
+	case FormatJSONLines:
+		return ".jsonl"
+	}
+	return ""
+}
+
+func (f Format) String() string {
+	switch f {
+	case FormatCSV:
+		return "csv"
+	case FormatJSONLines:
+		return "jsonl"
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+func (f Format) MarshalText() ([]byte, error) {
+	switch f {
+	case FormatCSV, FormatJSONLines:
+		return []byte(f.String()), nil
+	}
+	return nil, fmt.Errorf("unknown cdr format: %d", int(f))
+}
+
+func (f *Format) UnmarshalText(text []byte) error {
+	switch s := string(text); s {
+	case "csv":
+		*f = FormatCSV
+	case "jsonl":
+		*f = FormatJSONLines
+	default:
+		return fmt.Errorf("unknown cdr format: %q", s)
+	}
+	return nil
+}
+
+// Columns lists CDR column names, in order. Same names are used as keys in JSON Lines format.
+var Columns = []string{
+	"call_id",
+	"direction",
+	"trunk_id",
+	"dispatch_rule_id",
+	"region",
+	"from",
+	"from_host",
+	"to",
+	"to_host",
+	"room_name",
+	"room_id",
+	"participant_identity",
+	"status",
+	"sip_status_code",
+	"disconnect_reason",
+	"error",
+	"audio_codec",
+	"media_encryption",
+	"created_at",
+	"answered_at",
+	"ended_at",
+	"ring_seconds",
+	"talk_seconds",
+	"billable_seconds",
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
+}
+
+// Values returns record values in the same order as Columns.
+func (r *Record) Values() []string {
+	code := ""
+	if r.SIPStatusCode != 0 {
+		code = strconv.Itoa(r.SIPStatusCode)
+	}
+	return []string{
+		r.CallID,
+		r.Direction,
+		r.TrunkID,
+		r.DispatchRuleID,
+		r.Region,
+		r.From,
+		r.FromHost,
+		r.To,
+		r.ToHost,
+		r.RoomName,
+		r.RoomID,
+		r.ParticipantIdentity,
+		r.Status,
+		code,
+		r.DisconnectReason,
+		r.Error,
+		r.AudioCodec,
+		r.MediaEncryption,
+		formatTime(r.CreatedAt),
+		formatTime(r.AnsweredAt),
+		formatTime(r.EndedAt),
+		formatSeconds(r.RingDuration),
+		formatSeconds(r.TalkDuration),
+		strconv.FormatInt(r.BillableSeconds, 10),
+	}
+}
+
+type jsonRecord struct {
+	CallID              string  `json:"call_id"`
+	Direction           string  `json:"direction"`
+	TrunkID             string  `json:"trunk_id,omitempty"`
+	DispatchRuleID      string  `json:"dispatch_rule_id,omitempty"`
+	Region              string  `json:"region,omitempty"`
+	From                string  `json:"from"`
+	FromHost            string  `json:"from_host,omitempty"`
+	To                  string  `json:"to"`
+	ToHost              string  `json:"to_host,omitempty"`
+	RoomName            string  `json:"room_name,omitempty"`
+	RoomID              string  `json:"room_id,omitempty"`
+	ParticipantIdentity string  `json:"participant_identity,omitempty"`
+	Status              string  `json:"status"`
+	SIPStatusCode       int     `json:"sip_status_code,omitempty"`
+	DisconnectReason    string  `json:"disconnect_reason,omitempty"`
+	Error               string  `json:"error,omitempty"`
+	AudioCodec          string  `json:"audio_codec,omitempty"`
+	MediaEncryption     string  `json:"media_encryption,omitempty"`
+	CreatedAt           string  `json:"created_at,omitempty"`
+	AnsweredAt          string  `json:"answered_at,omitempty"`
+	EndedAt             string  `json:"ended_at,omitempty"`
+	RingSeconds         float64 `json:"ring_seconds"`
+	TalkSeconds         float64 `json:"talk_seconds"`
+	BillableSeconds     int64   `json:"billable_seconds"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (r *Record) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&jsonRecord{
+		CallID:              r.CallID,
+		Direction:           r.Direction,
+		TrunkID:             r.TrunkID,
+		DispatchRuleID:      r.DispatchRuleID,
+		Region:              r.Region,
+		From:                r.From,
+		FromHost:            r.FromHost,
+		To:                  r.To,
+		ToHost:              r.ToHost,
+		RoomName:            r.RoomName,
+		RoomID:              r.RoomID,
+		ParticipantIdentity: r.ParticipantIdentity,
+		Status:              r.Status,
+		SIPStatusCode:       r.SIPStatusCode,
+		DisconnectReason:    r.DisconnectReason,
+		Error:               r.Error,
+		AudioCodec:          r.AudioCodec,
+		MediaEncryption:     r.MediaEncryption,
+		CreatedAt:           formatTime(r.CreatedAt),
+		AnsweredAt:          formatTime(r.AnsweredAt),
+		EndedAt:             formatTime(r.EndedAt),
+		RingSeconds:         r.RingDuration.Seconds(),
+		TalkSeconds:         r.TalkDuration.Seconds(),
+		BillableSeconds:     r.BillableSeconds,
+	})
+}
+
+// Encoder writes records to a stream in a specific format.
+type Encoder interface {
+	// Encode writes a single record.
+	Encode(r *Record) error
+	// Flush writes any buffered data to the underlying writer.
+	Flush() error
+}
+
+// NewEncoder creates an encoder for a given format. CSV encoder writes the header before the first record,
+// and neutralizes values which could be evaluated as formulas by spreadsheets, see csvSafe.
+func NewEncoder(w io.Writer, f Format) (Encoder, error) {
+	switch f {
+	case FormatCSV:
+		return &csvEncoder{w: csv.NewWriter(w)}, nil
+	case FormatJSONLines:
+		return &jsonEncoder{enc: json.NewEncoder(w)}, nil
+	}
+	return nil, fmt.Errorf("unsupported cdr format: %v", f)
+}
+
+// csvSafe neutralizes values which spreadsheets would evaluate as formulas, by prefixing them with a single quote.
+// Values come from remote SIP parties, so a caller could otherwise inject formulas into CDR files.
+//
+// Phone numbers like "+14155550100" are kept as-is: a '+' followed only by digits is evaluated as a positive
+// number, which is harmless. Any other value starting with '+' is neutralized, as well as values starting
+// with '=', '@', '-', tab or carriage return.
+func csvSafe(v string) string {
+	if v == "" {
+		return v
+	}
+	switch v[0] {
+	case '=', '@', '-', '\t', '\r':
+		return "'" + v
+	case '+':
+		if len(v) == 1 || strings.Trim(v[1:], "0123456789") != "" {
+			return "'" + v
+		}
+	}
+	return v
+}
+
+type csvEncoder struct {
+	w          *csv.Writer
+	headerDone bool
+}
+
+func (e *csvEncoder) Encode(r *Record) error {
+	if !e.headerDone {
+		if err := e.w.Write(Columns); err != nil {
+			return err
+		}
+		e.headerDone = true
+	}
+	values := r.Values()
+	for i, v := range values {
+		values[i] = csvSafe(v)
+	}
+	return e.w.Write(values)
+}
+
+func (e *csvEncoder) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type jsonEncoder struct {
+	enc *json.Encoder
+}
+
+func (e *jsonEncoder) Encode(r *Record) error {
+	return e.enc.Encode(r)
+}
+
+func (e *jsonEncoder) Flush() error {
+	return nil
+}
@@ -0,0 +1,219 @@
+// Copyright 2025 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdr
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/utils"
+)
+
+// Sink stores CDR output segments. Each segment is a complete CSV or JSON Lines file.
+type Sink interface {
+	// Create starts a new segment with a given name. Segment is complete when the writer is closed.
+	Create(name string) (io.WriteCloser, error)
+}
+
+// DirSink writes segments as files in a local directory.
+type DirSink struct {
+	Dir string
+}
+
+func (s *DirSink) Create(name string) (io.WriteCloser, error) {
+	if err := os.MkdirAll(s.Dir, 0755); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(filepath.Join(s.Dir, name), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+}
+
+// WriterConfig controls segment rotation. Zero values disable the corresponding limit.
+type WriterConfig struct {
+	Format Format `yaml:"format,omitempty"`
+	// Prefix of segment names. Defaults to "cdr".
+	Prefix string `yaml:"prefix,omitempty"`
+	// MaxRecords per segment.
+	MaxRecords int `yaml:"max_records,omitempty"`
+	// MaxBytes per segment. A segment may exceed it by the size of one record.
+	MaxBytes int64 `yaml:"max_bytes,omitempty"`
+	// MaxAge of a segment. Rotation is checked when a record is written.
+	MaxAge time.Duration `yaml:"max_age,omitempty"`
+}
+
+// Writer converts SIP calls into CDR and writes them to a sink, rotating segments according to the config.
+type Writer struct {
+	conf  WriterConfig
+	sink  Sink
+	clock utils.Clock
+	opts  []Option
+
+	mu      sync.Mutex
+	seq     int
+	cur     io.WriteCloser
+	cnt     *countingWriter
+	enc     Encoder
+	started time.Time
+	records int
+	closed  bool
+}
+
+type WriterOption func(w *Writer)
+
+// WithClock sets a clock used for segment names and MaxAge rotation.
+func WithClock(c utils.Clock) WriterOption {
+	return func(w *Writer) {
+		w.clock = c
+	}
+}
+
+// WithRecordOptions sets options used when converting livekit.SIPCallInfo to a Record.
+func WithRecordOptions(opts ...Option) WriterOption {
+	return func(w *Writer) {
+		w.opts = opts
+	}
+}
+
+// NewWriter creates a rolling CDR writer. Segments are created lazily, on the first record.
+func NewWriter(sink Sink, conf WriterConfig, opts ...WriterOption) (*Writer, error) {
+	if _, err := NewEncoder(io.Discard, conf.Format); err != nil {
+		return nil, err
+	}
+	if conf.Prefix == "" {
+		conf.Prefix = "cdr"
+	}
+	w := &Writer{
+		conf:  conf,
+		sink:  sink,
+		clock: utils.SystemClock{},
+	}
+	for _, fnc := range opts {
+		fnc(w)
+	}
+	return w, nil
+}
+
+// WriteCallInfo converts a finished call to a Record and writes it.
+// Calls that are still in progress are skipped with ErrCallInProgress.
+func (w *Writer) WriteCallInfo(info *livekit.SIPCallInfo) error {
+	r, err := NewRecord(info, w.opts...)
+	if err != nil {
+		return err
+	}
+	return w.Write(r)
+}
+
+// Write a single record to the current segment, rotating it if necessary.
+func (w *Writer) Write(r *Record) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return errors.New("cdr writer is closed")
+	}
+	now := w.clock.Now()
+	if w.cur != nil && w.shouldRotate(now) {
+		if err := w.closeSegment(); err != nil {
+			return err
+		}
+	}
+	if w.cur == nil {
+		if err := w.openSegment(now); err != nil {
+			return err
+		}
+	}
+	if err := w.enc.Encode(r); err != nil {
+		return err
+	}
+	w.records++
+	// Flush after each record, so that byte limits are accurate and records are not lost on crash.
+	return w.enc.Flush()
+}
+
+func (w *Writer) shouldRotate(now time.Time) bool {
+	if w.conf.MaxRecords > 0 && w.records >= w.conf.MaxRecords {
+		return true
+	}
+	if w.conf.MaxBytes > 0 && w.cnt.n >= w.conf.MaxBytes {
+		return true
+	}
+	if w.conf.MaxAge > 0 && now.Sub(w.started) >= w.conf.MaxAge {
+		return true
+	}
+	return false
+}
+
+func (w *Writer) openSegment(now time.Time) error {
+	w.seq++
+	name := fmt.Sprintf("%s-%s-%04d%s", w.conf.Prefix, now.UTC().Format("20060102T150405Z"), w.seq, w.conf.Format.Ext())
+	f, err := w.sink.Create(name)
+	if err != nil {
+		return err
+	}
+	w.cur = f
+	w.cnt = &countingWriter{w: f}
+	w.enc, _ = NewEncoder(w.cnt, w.conf.Format)
+	w.started = now
+	w.records = 0
+	return nil
+}
+
+func (w *Writer) closeSegment() error {
+	err := w.enc.Flush()
+	if err2 := w.cur.Close(); err == nil {
+		err = err2
+	}
+	w.cur, w.cnt, w.enc = nil, nil, nil
+	return err
+}
+
+// Rotate closes the current segment, if any. Next record will start a new one.
+func (w *Writer) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.cur == nil {
+		return nil
+	}
+	return w.closeSegment()
+}
+
+// Close the current segment and the writer.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if w.cur == nil {
+		return nil
+	}
+	return w.closeSegment()
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
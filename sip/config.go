@@ -0,0 +1,649 @@
+// Copyright 2025 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sip
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"buf.build/go/protoyaml"
+	"github.com/dennwc/iters"
+	"golang.org/x/exp/slices"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v3"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+// Config is a declarative description of all SIP Trunks and Dispatch Rules in a project.
+//
+// Objects are matched against the current state by ID, if set, or by name otherwise.
+type Config struct {
+	InboundTrunks  []*livekit.SIPInboundTrunkInfo
+	OutboundTrunks []*livekit.SIPOutboundTrunkInfo
+	DispatchRules  []*livekit.SIPDispatchRuleInfo
+}
+
+type configYAML struct {
+	InboundTrunks  []yaml.Node `yaml:"inbound_trunks,omitempty"`
+	OutboundTrunks []yaml.Node `yaml:"outbound_trunks,omitempty"`
+	DispatchRules  []yaml.Node `yaml:"dispatch_rules,omitempty"`
+}
+
+// ParseConfig reads a declarative SIP configuration in YAML format.
+//
+// The document has the following structure, where each item uses protobuf field names:
+//
+//	inbound_trunks:
+//	  - name: Main
+//	    numbers: ["+15550100"]
+//	outbound_trunks:
+//	  - name: Carrier
+//	    address: sip.example.com
+//	    numbers: ["+15550100"]
+//	dispatch_rules:
+//	  - name: Support
+//	    rule:
+//	      dispatch_rule_direct:
+//	        room_name: support
+func ParseConfig(r io.Reader) (*Config, error) {
+	var doc configYAML
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	if err := dec.Decode(&doc); err != nil && err != io.EOF {
+		return nil, err
+	}
+	var (
+		c   Config
+		err error
+	)
+	if c.InboundTrunks, err = parseConfigList[*livekit.SIPInboundTrunkInfo](doc.InboundTrunks, "inbound trunk"); err != nil {
+		return nil, err
+	}
+	if c.OutboundTrunks, err = parseConfigList[*livekit.SIPOutboundTrunkInfo](doc.OutboundTrunks, "outbound trunk"); err != nil {
+		return nil, err
+	}
+	if c.DispatchRules, err = parseConfigList[*livekit.SIPDispatchRuleInfo](doc.DispatchRules, "dispatch rule"); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func parseConfigList[T interface {
+	proto.Message
+	*E
+}, E any](nodes []yaml.Node, kind string) ([]T, error) {
+	if len(nodes) == 0 {
+		return nil, nil
+	}
+	out := make([]T, 0, len(nodes))
+	for i := range nodes {
+		// Marshall the Node back to yaml to pass it to the protobuf specific unmarshaller
+		str, err := yaml.Marshal(&nodes[i])
+		if err != nil {
+			return nil, err
+		}
+		v := T(new(E))
+		if err = protoyaml.Unmarshal(str, v); err != nil {
+			return nil, fmt.Errorf("%s #%d: %w", kind, i+1, err)
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+// WriteYAML writes the configuration in the format accepted by ParseConfig.
+func (c *Config) WriteYAML(w io.Writer) error {
+	var doc configYAML
+	for _, t := range c.InboundTrunks {
+		if err := appendConfigNode(&doc.InboundTrunks, t); err != nil {
+			return err
+		}
+	}
+	for _, t := range c.OutboundTrunks {
+		if err := appendConfigNode(&doc.OutboundTrunks, t); err != nil {
+			return err
+		}
+	}
+	for _, r := range c.DispatchRules {
+		if err := appendConfigNode(&doc.DispatchRules, r); err != nil {
+			return err
+		}
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func appendConfigNode(dst *[]yaml.Node, m proto.Message) error {
+	str, err := protoyaml.MarshalOptions{UseProtoNames: true}.Marshal(m)
+	if err != nil {
+		return err
+	}
+	var n yaml.Node
+	if err = yaml.Unmarshal(str, &n); err != nil {
+		return err
+	}
+	if n.Kind == yaml.DocumentNode && len(n.Content) == 1 {
+		n = *n.Content[0]
+	}
+	*dst = append(*dst, n)
+	return nil
+}
+
+// Validate checks each object in the config, as well as conflicts between Trunks and Dispatch Rules.
+//
+// Objects without an ID are matched by name, so their names must be unique among objects of the same kind.
+func (c *Config) Validate() error {
+	if err := validateConfigNames("inbound trunk", c.InboundTrunks, inboundTrunkConfigOps); err != nil {
+		return err
+	}
+	if err := validateConfigNames("outbound trunk", c.OutboundTrunks, outboundTrunkConfigOps); err != nil {
+		return err
+	}
+	if err := validateConfigNames("dispatch rule", c.DispatchRules, dispatchRuleConfigOps); err != nil {
+		return err
+	}
+	for _, t := range c.InboundTrunks {
+		if err := t.Validate(); err != nil {
+			return fmt.Errorf("inbound trunk %s: %w", printConfigName(t.SipTrunkId, t.Name), err)
+		}
+	}
+	for _, t := range c.OutboundTrunks {
+		if err := t.Validate(); err != nil {
+			return fmt.Errorf("outbound trunk %s: %w", printConfigName(t.SipTrunkId, t.Name), err)
+		}
+	}
+	for _, r := range c.DispatchRules {
+		if err := r.Validate(); err != nil {
+			return fmt.Errorf("dispatch rule %s: %w", printConfigName(r.SipDispatchRuleId, r.Name), err)
+		}
+	}
+	return c.validateConflicts()
+}
+
+func (c *Config) validateConflicts() error {
+	if err := ValidateTrunksIter(iters.Slice(c.InboundTrunks)); err != nil {
+		return err
+	}
+	if _, err := ValidateDispatchRulesIter(iters.Slice(c.DispatchRules)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func validateConfigNames[T proto.Message](kind string, list []T, ops configOps[T]) error {
+	byName := make(map[string]T, len(list))
+	for _, v := range list {
+		name := ops.Name(v)
+		if name == "" {
+			continue
+		}
+		if prev, ok := byName[name]; ok && (ops.ID(v) == "" || ops.ID(prev) == "") {
+			return fmt.Errorf("%s %q: defined more than once", kind, name)
+		}
+		byName[name] = v
+	}
+	return nil
+}
+
+func printConfigName(id, name string) string {
+	switch {
+	case id != "" && name != "":
+		return fmt.Sprintf("%s %q", id, name)
+	case id != "":
+		return id
+	case name != "":
+		return fmt.Sprintf("%q", name)
+	}
+	return "<unnamed>"
+}
+
+type ConfigChangeType int
+
+const (
+	ConfigCreate ConfigChangeType = iota
+	ConfigUpdate
+	ConfigDelete
+)
+
+func (t ConfigChangeType) String() string {
+	switch t {
+	case ConfigCreate:
+		return "create"
+	case ConfigUpdate:
+		return "update"
+	case ConfigDelete:
+		return "delete"
+	}
+	return fmt.Sprintf("ConfigChangeType(%d)", int(t))
+}
+
+// ConfigChange is a single API request required to bring the current state to the desired one.
+type ConfigChange struct {
+	Type ConfigChangeType
+	// Kind is a human-readable object kind: "inbound trunk", "outbound trunk" or "dispatch rule".
+	Kind string
+	ID   string
+	Name string
+	// Fields lists protobuf names of top-level fields changed by an update.
+	Fields []string
+	// Request is one of livekit.Create*, livekit.Update* or livekit.Delete* requests for SIP Trunks and Dispatch Rules.
+	Request proto.Message
+}
+
+func (c *ConfigChange) String() string {
+	var b strings.Builder
+	switch c.Type {
+	case ConfigCreate:
+		b.WriteString("+ ")
+	case ConfigUpdate:
+		b.WriteString("~ ")
+	case ConfigDelete:
+		b.WriteString("- ")
+	}
+	b.WriteString(c.Type.String())
+	b.WriteString(" ")
+	b.WriteString(c.Kind)
+	b.WriteString(" ")
+	b.WriteString(printConfigName(c.ID, c.Name))
+	if len(c.Fields) != 0 {
+		b.WriteString(": ")
+		b.WriteString(strings.Join(c.Fields, ", "))
+	}
+	return b.String()
+}
+
+// ConfigPlan is an ordered list of changes. Requests must be executed in this order.
+type ConfigPlan struct {
+	Changes []ConfigChange
+}
+
+// Empty returns true if the current state already matches the desired one.
+func (p *ConfigPlan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+// String returns a human-readable dry-run description of the plan.
+func (p *ConfigPlan) String() string {
+	if p.Empty() {
+		return "No changes.\n"
+	}
+	var (
+		b                         strings.Builder
+		creates, updates, deletes int
+	)
+	for i := range p.Changes {
+		c := &p.Changes[i]
+		switch c.Type {
+		case ConfigCreate:
+			creates++
+		case ConfigUpdate:
+			updates++
+		case ConfigDelete:
+			deletes++
+		}
+		b.WriteString(c.String())
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "Plan: %d to create, %d to update, %d to delete.\n", creates, updates, deletes)
+	return b.String()
+}
+
+type planConfigOpts struct {
+	Prune bool
+}
+
+type PlanConfigOpt func(opt *planConfigOpts)
+
+// WithConfigPrune deletes Trunks and Dispatch Rules which are not mentioned in the desired config.
+func WithConfigPrune() PlanConfigOpt {
+	return func(opt *planConfigOpts) {
+		opt.Prune = true
+	}
+}
+
+// PlanConfig validates the desired config and computes the minimal list of requests required to bring the current state to it.
+// Without WithConfigPrune, objects not mentioned in the desired config are kept, so the result is checked for conflicts with them.
+//
+// Trunks are created and updated before Dispatch Rules, and deleted after them,
+// so that Dispatch Rules never reference missing Trunks.
+func PlanConfig(current, desired *Config, opts ...PlanConfigOpt) (*ConfigPlan, error) {
+	var opt planConfigOpts
+	for _, fnc := range opts {
+		fnc(&opt)
+	}
+	if current == nil {
+		current = &Config{}
+	}
+	if err := desired.Validate(); err != nil {
+		return nil, err
+	}
+	inbound, err := diffConfigList("inbound trunk", current.InboundTrunks, desired.InboundTrunks, inboundTrunkConfigOps, opt.Prune)
+	if err != nil {
+		return nil, err
+	}
+	outbound, err := diffConfigList("outbound trunk", current.OutboundTrunks, desired.OutboundTrunks, outboundTrunkConfigOps, opt.Prune)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := diffConfigList("dispatch rule", current.DispatchRules, desired.DispatchRules, dispatchRuleConfigOps, opt.Prune)
+	if err != nil {
+		return nil, err
+	}
+	if !opt.Prune {
+		result := &Config{
+			InboundTrunks:  inbound.result,
+			OutboundTrunks: outbound.result,
+			DispatchRules:  rules.result,
+		}
+		if err := result.validateConflicts(); err != nil {
+			return nil, fmt.Errorf("conflict with current state: %w", err)
+		}
+	}
+	var p ConfigPlan
+	p.Changes = append(p.Changes, inbound.upserts...)
+	p.Changes = append(p.Changes, outbound.upserts...)
+	p.Changes = append(p.Changes, rules.deletes...)
+	p.Changes = append(p.Changes, rules.upserts...)
+	p.Changes = append(p.Changes, inbound.deletes...)
+	p.Changes = append(p.Changes, outbound.deletes...)
+	return &p, nil
+}
+
+type configOps[T proto.Message] struct {
+	ID     func(v T) string
+	Name   func(v T) string
+	SetID  func(v T, id string)
+	Create func(v T) proto.Message
+	// Update returns a partial update request, or nil, if changed fields cannot be expressed as a partial update.
+	Update func(id string, cur, v T, fields []string) proto.Message
+	// Replace returns a request replacing the whole object.
+	Replace func(id string, v T) proto.Message
+	Delete  func(id string) proto.Message
+}
+
+type configDiff[T proto.Message] struct {
+	upserts []ConfigChange
+	deletes []ConfigChange
+	// result is the list of objects after applying the changes.
+	result []T
+}
+
+func diffConfigList[T proto.Message](kind string, current, desired []T, ops configOps[T], prune bool) (*configDiff[T], error) {
+	byID := make(map[string]T, len(current))
+	byName := make(map[string]T, len(current))
+	dupNames := make(map[string]struct{})
+	for _, v := range current {
+		byID[ops.ID(v)] = v
+		name := ops.Name(v)
+		if name == "" {
+			continue
+		}
+		if _, ok := byName[name]; ok {
+			dupNames[name] = struct{}{}
+		}
+		byName[name] = v
+	}
+	var d configDiff[T]
+	seen := make(map[string]struct{}, len(desired))
+	names := make(map[string]struct{}, len(desired))
+	for _, v := range desired {
+		id, name := ops.ID(v), ops.Name(v)
+		var (
+			cur   T
+			found bool
+		)
+		if id != "" {
+			cur, found = byID[id]
+			if !found {
+				return nil, fmt.Errorf("%s %s: not found, remove the ID to create it", kind, printConfigName(id, name))
+			}
+		} else if name != "" {
+			if _, dup := dupNames[name]; dup {
+				return nil, fmt.Errorf("%s %q: multiple objects with this name exist, specify the ID", kind, name)
+			}
+			cur, found = byName[name]
+			if found {
+				id = ops.ID(cur)
+			}
+		}
+		if name != "" && (!found || ops.Name(cur) != name) {
+			// new names must not collide with objects kept as-is
+			names[name] = struct{}{}
+		}
+		if !found {
+			d.upserts = append(d.upserts, ConfigChange{
+				Type: ConfigCreate, Kind: kind, Name: name,
+				Request: ops.Create(v),
+			})
+			d.result = append(d.result, v)
+			continue
+		}
+		if _, ok := seen[id]; ok {
+			return nil, fmt.Errorf("%s %s: defined more than once", kind, printConfigName(id, name))
+		}
+		seen[id] = struct{}{}
+		v = proto.Clone(v).(T)
+		ops.SetID(v, id)
+		d.result = append(d.result, v)
+		fields := diffProtoFields(cur, v)
+		if len(fields) == 0 {
+			continue
+		}
+		req := ops.Update(id, cur, v, fields)
+		if req == nil {
+			req = ops.Replace(id, v)
+		}
+		d.upserts = append(d.upserts, ConfigChange{
+			Type: ConfigUpdate, Kind: kind, ID: id, Name: name,
+			Fields:  fields,
+			Request: req,
+		})
+	}
+	for _, v := range current {
+		id := ops.ID(v)
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		if !prune {
+			if _, ok := names[ops.Name(v)]; ok {
+				return nil, fmt.Errorf("%s %q: name is already used by %s", kind, ops.Name(v), id)
+			}
+			d.result = append(d.result, v)
+			continue
+		}
+		d.deletes = append(d.deletes, ConfigChange{
+			Type: ConfigDelete, Kind: kind, ID: id, Name: ops.Name(v),
+			Request: ops.Delete(id),
+		})
+	}
+	return &d, nil
+}
+
+// diffProtoFields returns protobuf names of top-level fields that differ between two messages.
+func diffProtoFields(a, b proto.Message) []string {
+	ra, rb := a.ProtoReflect(), b.ProtoReflect()
+	fields := ra.Descriptor().Fields()
+	var out []string
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if ra.Has(fd) != rb.Has(fd) || !ra.Get(fd).Equal(rb.Get(fd)) {
+			out = append(out, string(fd.Name()))
+		}
+	}
+	return out
+}
+
+func onlyFields(fields []string, allowed ...string) bool {
+	for _, f := range fields {
+		if !slices.Contains(allowed, f) {
+			return false
+		}
+	}
+	return true
+}
+
+func hasField(fields []string, name string) bool {
+	return slices.Contains(fields, name)
+}
+
+func listUpdate(fields []string, name string, list []string) *livekit.ListUpdate {
+	if !hasField(fields, name) {
+		return nil
+	}
+	return &livekit.ListUpdate{Set: list}
+}
+
+func stringUpdate(fields []string, name string, val string) *string {
+	if !hasField(fields, name) {
+		return nil
+	}
+	return &val
+}
+
+var inboundTrunkConfigOps = configOps[*livekit.SIPInboundTrunkInfo]{
+	ID:    (*livekit.SIPInboundTrunkInfo).GetSipTrunkId,
+	Name:  (*livekit.SIPInboundTrunkInfo).GetName,
+	SetID: func(v *livekit.SIPInboundTrunkInfo, id string) { v.SipTrunkId = id },
+	Create: func(v *livekit.SIPInboundTrunkInfo) proto.Message {
+		return &livekit.CreateSIPInboundTrunkRequest{Trunk: v}
+	},
+	Update: func(id string, _, v *livekit.SIPInboundTrunkInfo, fields []string) proto.Message {
+		if !onlyFields(fields, "numbers", "allowed_addresses", "allowed_numbers", "auth_username", "auth_password", "name", "metadata") {
+			return nil
+		}
+		return &livekit.UpdateSIPInboundTrunkRequest{
+			SipTrunkId: id,
+			Action: &livekit.UpdateSIPInboundTrunkRequest_Update{Update: &livekit.SIPInboundTrunkUpdate{
+				Numbers:          listUpdate(fields, "numbers", v.Numbers),
+				AllowedAddresses: listUpdate(fields, "allowed_addresses", v.AllowedAddresses),
+				AllowedNumbers:   listUpdate(fields, "allowed_numbers", v.AllowedNumbers),
+				AuthUsername:     stringUpdate(fields, "auth_username", v.AuthUsername),
+				AuthPassword:     stringUpdate(fields, "auth_password", v.AuthPassword),
+				Name:             stringUpdate(fields, "name", v.Name),
+				Metadata:         stringUpdate(fields, "metadata", v.Metadata),
+			}},
+		}
+	},
+	Replace: func(id string, v *livekit.SIPInboundTrunkInfo) proto.Message {
+		return &livekit.UpdateSIPInboundTrunkRequest{
+			SipTrunkId: id,
+			Action:     &livekit.UpdateSIPInboundTrunkRequest_Replace{Replace: v},
+		}
+	},
+	Delete: func(id string) proto.Message {
+		return &livekit.DeleteSIPTrunkRequest{SipTrunkId: id}
+	},
+}
+
+var outboundTrunkConfigOps = configOps[*livekit.SIPOutboundTrunkInfo]{
+	ID:    (*livekit.SIPOutboundTrunkInfo).GetSipTrunkId,
+	Name:  (*livekit.SIPOutboundTrunkInfo).GetName,
+	SetID: func(v *livekit.SIPOutboundTrunkInfo, id string) { v.SipTrunkId = id },
+	Create: func(v *livekit.SIPOutboundTrunkInfo) proto.Message {
+		return &livekit.CreateSIPOutboundTrunkRequest{Trunk: v}
+	},
+	Update: func(id string, _, v *livekit.SIPOutboundTrunkInfo, fields []string) proto.Message {
+		if !onlyFields(fields, "address", "transport", "numbers", "auth_username", "auth_password", "name", "metadata") {
+			return nil
+		}
+		var transport *livekit.SIPTransport
+		if hasField(fields, "transport") {
+			transport = &v.Transport
+		}
+		return &livekit.UpdateSIPOutboundTrunkRequest{
+			SipTrunkId: id,
+			Action: &livekit.UpdateSIPOutboundTrunkRequest_Update{Update: &livekit.SIPOutboundTrunkUpdate{
+				Address:      stringUpdate(fields, "address", v.Address),
+				Transport:    transport,
+				Numbers:      listUpdate(fields, "numbers", v.Numbers),
+				AuthUsername: stringUpdate(fields, "auth_username", v.AuthUsername),
+				AuthPassword: stringUpdate(fields, "auth_password", v.AuthPassword),
+				Name:         stringUpdate(fields, "name", v.Name),
+				Metadata:     stringUpdate(fields, "metadata", v.Metadata),
+			}},
+		}
+	},
+	Replace: func(id string, v *livekit.SIPOutboundTrunkInfo) proto.Message {
+		return &livekit.UpdateSIPOutboundTrunkRequest{
+			SipTrunkId: id,
+			Action:     &livekit.UpdateSIPOutboundTrunkRequest_Replace{Replace: v},
+		}
+	},
+	Delete: func(id string) proto.Message {
+		return &livekit.DeleteSIPTrunkRequest{SipTrunkId: id}
+	},
+}
+
+var dispatchRuleConfigOps = configOps[*livekit.SIPDispatchRuleInfo]{
+	ID:    (*livekit.SIPDispatchRuleInfo).GetSipDispatchRuleId,
+	Name:  (*livekit.SIPDispatchRuleInfo).GetName,
+	SetID: func(v *livekit.SIPDispatchRuleInfo, id string) { v.SipDispatchRuleId = id },
+	Create: func(v *livekit.SIPDispatchRuleInfo) proto.Message {
+		return &livekit.CreateSIPDispatchRuleRequest{DispatchRule: v}
+	},
+	Update: func(id string, cur, v *livekit.SIPDispatchRuleInfo, fields []string) proto.Message {
+		if !onlyFields(fields, "trunk_ids", "rule", "name", "metadata", "attributes") {
+			return nil
+		}
+		var rule *livekit.SIPDispatchRule
+		if hasField(fields, "rule") {
+			rule = v.Rule
+		}
+		var attrs map[string]string
+		if hasField(fields, "attributes") {
+			// Attribute update is a diff: empty values remove the key.
+			attrs = make(map[string]string)
+			for k := range cur.Attributes {
+				if _, ok := v.Attributes[k]; !ok {
+					attrs[k] = ""
+				}
+			}
+			for k, val := range v.Attributes {
+				if val == "" {
+					// Cannot be expressed as a diff.
+					return nil
+				}
+				if cur.Attributes[k] != val {
+					attrs[k] = val
+				}
+			}
+		}
+		return &livekit.UpdateSIPDispatchRuleRequest{
+			SipDispatchRuleId: id,
+			Action: &livekit.UpdateSIPDispatchRuleRequest_Update{Update: &livekit.SIPDispatchRuleUpdate{
+				TrunkIds:   listUpdate(fields, "trunk_ids", v.TrunkIds),
+				Rule:       rule,
+				Name:       stringUpdate(fields, "name", v.Name),
+				Metadata:   stringUpdate(fields, "metadata", v.Metadata),
+				Attributes: attrs,
+			}},
+		}
+	},
+	Replace: func(id string, v *livekit.SIPDispatchRuleInfo) proto.Message {
+		return &livekit.UpdateSIPDispatchRuleRequest{
+			SipDispatchRuleId: id,
+			Action:            &livekit.UpdateSIPDispatchRuleRequest_Replace{Replace: v},
+		}
+	},
+	Delete: func(id string) proto.Message {
+		return &livekit.DeleteSIPDispatchRuleRequest{SipDispatchRuleId: id}
+	},
+}
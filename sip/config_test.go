@@ -0,0 +1,228 @@
+// Copyright 2025 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sip
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+const testConfigYAML = `
+inbound_trunks:
+  - name: Main
+    numbers: ["+15550100"]
+    allowed_addresses: ["10.0.0.0/8"]
+outbound_trunks:
+  - name: Carrier
+    address: sip.example.com
+    numbers: ["+15550100"]
+    auth_username: user
+    auth_password: pass
+dispatch_rules:
+  - name: Support
+    trunk_ids: ["ST_main"]
+    rule:
+      dispatch_rule_direct:
+        room_name: support
+    attributes:
+      team: support
+`
+
+func TestParseConfig(t *testing.T) {
+	c, err := ParseConfig(strings.NewReader(testConfigYAML))
+	require.NoError(t, err)
+	require.NoError(t, c.Validate())
+	require.Len(t, c.InboundTrunks, 1)
+	require.Len(t, c.OutboundTrunks, 1)
+	require.Len(t, c.DispatchRules, 1)
+	require.Equal(t, "support", c.DispatchRules[0].GetRule().GetDispatchRuleDirect().GetRoomName())
+
+	var buf bytes.Buffer
+	require.NoError(t, c.WriteYAML(&buf))
+	c2, err := ParseConfig(&buf)
+	require.NoError(t, err)
+	for i := range c.InboundTrunks {
+		require.True(t, proto.Equal(c.InboundTrunks[i], c2.InboundTrunks[i]))
+	}
+	for i := range c.OutboundTrunks {
+		require.True(t, proto.Equal(c.OutboundTrunks[i], c2.OutboundTrunks[i]))
+	}
+	for i := range c.DispatchRules {
+		require.True(t, proto.Equal(c.DispatchRules[i], c2.DispatchRules[i]))
+	}
+
+	_, err = ParseConfig(strings.NewReader("inbound_trunks:\n  - unknown: 1\n"))
+	require.Error(t, err)
+	_, err = ParseConfig(strings.NewReader("trunks: []\n"))
+	require.Error(t, err)
+}
+
+func TestPlanConfig(t *testing.T) {
+	desired, err := ParseConfig(strings.NewReader(testConfigYAML))
+	require.NoError(t, err)
+
+	t.Run("create", func(t *testing.T) {
+		p, err := PlanConfig(nil, desired)
+		require.NoError(t, err)
+		require.Len(t, p.Changes, 3)
+		require.Equal(t, `+ create inbound trunk "Main"
++ create outbound trunk "Carrier"
++ create dispatch rule "Support"
+Plan: 3 to create, 0 to update, 0 to delete.
+`, p.String())
+		req, ok := p.Changes[0].Request.(*livekit.CreateSIPInboundTrunkRequest)
+		require.True(t, ok)
+		require.NoError(t, req.Validate())
+	})
+
+	current := &Config{
+		InboundTrunks: []*livekit.SIPInboundTrunkInfo{
+			{SipTrunkId: "ST_main", Name: "Main", Numbers: []string{"+15550100"}, AllowedAddresses: []string{"10.0.0.0/8"}},
+			{SipTrunkId: "ST_old", Name: "Old", Numbers: []string{"+15550199"}},
+		},
+		OutboundTrunks: []*livekit.SIPOutboundTrunkInfo{
+			{SipTrunkId: "ST_out", Name: "Carrier", Address: "sip.example.com", Numbers: []string{"+15550100"}, AuthUsername: "user", AuthPassword: "old"},
+		},
+		DispatchRules: []*livekit.SIPDispatchRuleInfo{
+			{
+				SipDispatchRuleId: "SDR_support", Name: "Support", TrunkIds: []string{"ST_main"},
+				Rule:       newDirectDispatch("support", ""),
+				Attributes: map[string]string{"team": "sales", "old": "1"},
+			},
+			{SipDispatchRuleId: "SDR_old", Name: "Old", Rule: newDirectDispatch("old", "")},
+		},
+	}
+
+	t.Run("noop", func(t *testing.T) {
+		p, err := PlanConfig(current, current)
+		require.NoError(t, err)
+		require.True(t, p.Empty())
+		require.Equal(t, "No changes.\n", p.String())
+	})
+
+	t.Run("update", func(t *testing.T) {
+		p, err := PlanConfig(current, desired)
+		require.NoError(t, err)
+		require.Equal(t, `~ update outbound trunk ST_out "Carrier": auth_password
+~ update dispatch rule SDR_support "Support": attributes
+Plan: 0 to create, 2 to update, 0 to delete.
+`, p.String())
+		out := p.Changes[0].Request.(*livekit.UpdateSIPOutboundTrunkRequest)
+		require.NoError(t, out.Validate())
+		require.Equal(t, "ST_out", out.SipTrunkId)
+		upd := out.GetUpdate()
+		require.Equal(t, "pass", upd.GetAuthPassword())
+		require.Nil(t, upd.Numbers)
+		require.Nil(t, upd.Address)
+
+		rule := p.Changes[1].Request.(*livekit.UpdateSIPDispatchRuleRequest)
+		require.NoError(t, rule.Validate())
+		require.Equal(t, map[string]string{"team": "support", "old": ""}, rule.GetUpdate().Attributes)
+		res, err := rule.Action.(livekit.UpdateSIPDispatchRuleRequestAction).Apply(current.DispatchRules[0])
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"team": "support"}, res.Attributes)
+	})
+
+	t.Run("prune", func(t *testing.T) {
+		p, err := PlanConfig(current, desired, WithConfigPrune())
+		require.NoError(t, err)
+		require.Equal(t, `~ update outbound trunk ST_out "Carrier": auth_password
+- delete dispatch rule SDR_old "Old"
+~ update dispatch rule SDR_support "Support": attributes
+- delete inbound trunk ST_old "Old"
+Plan: 0 to create, 2 to update, 2 to delete.
+`, p.String())
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		d := &Config{InboundTrunks: []*livekit.SIPInboundTrunkInfo{
+			{Name: "Main", Numbers: []string{"+15550100"}, AllowedAddresses: []string{"10.0.0.0/8"}, KrispEnabled: true},
+		}}
+		p, err := PlanConfig(current, d)
+		require.NoError(t, err)
+		require.Len(t, p.Changes, 1)
+		req := p.Changes[0].Request.(*livekit.UpdateSIPInboundTrunkRequest)
+		require.Equal(t, "ST_main", req.GetReplace().GetSipTrunkId())
+		require.True(t, req.GetReplace().KrispEnabled)
+	})
+
+	t.Run("unknown id", func(t *testing.T) {
+		d := &Config{InboundTrunks: []*livekit.SIPInboundTrunkInfo{
+			{SipTrunkId: "ST_missing", Numbers: []string{"+15550100"}},
+		}}
+		_, err := PlanConfig(current, d)
+		require.Error(t, err)
+	})
+
+	t.Run("conflicts with kept trunk", func(t *testing.T) {
+		d := &Config{InboundTrunks: []*livekit.SIPInboundTrunkInfo{
+			{Name: "New", Numbers: []string{"+15550199"}},
+		}}
+		_, err := PlanConfig(current, d)
+		require.ErrorContains(t, err, "conflict with current state")
+
+		// pruned trunks don't conflict
+		_, err = PlanConfig(current, d, WithConfigPrune())
+		require.NoError(t, err)
+	})
+
+	t.Run("conflicts with kept dispatch rule", func(t *testing.T) {
+		d := &Config{DispatchRules: []*livekit.SIPDispatchRuleInfo{
+			{Name: "Other", Rule: newDirectDispatch("other", "")},
+		}}
+		_, err := PlanConfig(current, d)
+		require.ErrorContains(t, err, "conflict with current state")
+	})
+
+	t.Run("rename to kept name", func(t *testing.T) {
+		d := &Config{OutboundTrunks: []*livekit.SIPOutboundTrunkInfo{
+			{SipTrunkId: "ST_out", Name: "Carrier", Address: "sip.example.com", Numbers: []string{"+15550100"}},
+		}, InboundTrunks: []*livekit.SIPInboundTrunkInfo{
+			{SipTrunkId: "ST_main", Name: "Old", Numbers: []string{"+15550100"}},
+		}}
+		_, err := PlanConfig(current, d)
+		require.ErrorContains(t, err, `inbound trunk "Old": name is already used by ST_old`)
+	})
+
+	t.Run("duplicate names", func(t *testing.T) {
+		d := &Config{OutboundTrunks: []*livekit.SIPOutboundTrunkInfo{
+			{Name: "New", Address: "sip.example.com", Numbers: []string{"+15550101"}},
+			{Name: "New", Address: "sip2.example.com", Numbers: []string{"+15550102"}},
+		}}
+		require.ErrorContains(t, d.Validate(), `outbound trunk "New": defined more than once`)
+		_, err := PlanConfig(current, d)
+		require.Error(t, err)
+
+		// objects with IDs may share names, as they are not matched by name
+		d.OutboundTrunks[0].SipTrunkId = "ST_1"
+		d.OutboundTrunks[1].SipTrunkId = "ST_2"
+		require.NoError(t, d.Validate())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		d := &Config{InboundTrunks: []*livekit.SIPInboundTrunkInfo{
+			{Name: "A", Numbers: []string{"+15550100"}},
+			{Name: "B", Numbers: []string{"+15550100"}},
+		}}
+		_, err := PlanConfig(current, d)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,136 @@
+// Copyright 2025 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sip
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// DefaultPhoneMaskChar is used to replace hidden digits when PhoneMaskPolicy.MaskChar is not set.
+const DefaultPhoneMaskChar = '*'
+
+// PhoneMaskPolicy controls how phone numbers are masked when HidePhoneNumber is set on a Dispatch Rule.
+//
+// Nil policy preserves the legacy behavior: only the last 4 digits are kept and the identity
+// is derived from an unsalted SHA-256 hash of the number.
+type PhoneMaskPolicy struct {
+	// KeepCountryCode keeps the E.164 country code of the number (only for numbers starting with '+').
+	KeepCountryCode bool
+	// KeepAreaDigits keeps this number of digits following the country code.
+	// It is only effective if KeepCountryCode is set.
+	KeepAreaDigits int
+	// KeepLast keeps this number of trailing digits.
+	KeepLast int
+	// MaskChar replaces each hidden digit. DefaultPhoneMaskChar is used if not set.
+	MaskChar rune
+	// Secret is a per-project key used to compute HMAC-SHA256 of the number for participant identity.
+	// If empty, unsalted SHA-256 is used, which can be reversed by brute force over the number space.
+	Secret []byte
+	// EmitAttribute sets masked number in the livekit.AttrSIPPhoneNumber attribute.
+	// The attribute is omitted otherwise.
+	EmitAttribute bool
+}
+
+// MaskNumber masks the phone number according to the policy.
+// Too short numbers are masked completely, except the last digit.
+func (p *PhoneMaskPolicy) MaskNumber(num string) string {
+	if p == nil {
+		return legacyMaskNumber(num)
+	}
+	plus := strings.HasPrefix(num, "+")
+	digits := make([]byte, 0, len(num))
+	for i := 0; i < len(num); i++ {
+		if c := num[i]; c >= '0' && c <= '9' {
+			digits = append(digits, c)
+		}
+	}
+	if len(digits) == 0 {
+		// Not a phone number, but still must not leak it.
+		return ""
+	}
+	head := 0
+	if plus && p.KeepCountryCode {
+		head = countryCodeLen(digits) + max(p.KeepAreaDigits, 0)
+	}
+	tail := max(p.KeepLast, 0)
+	if head+tail >= len(digits) {
+		// Nothing would be hidden. Number is too short, so be conservative and keep only the last digit.
+		head, tail = 0, min(1, len(digits)-1)
+	}
+	mask := p.MaskChar
+	if mask == 0 {
+		mask = DefaultPhoneMaskChar
+	}
+	var b strings.Builder
+	if plus {
+		b.WriteByte('+')
+	}
+	b.Write(digits[:head])
+	for range len(digits) - head - tail {
+		b.WriteRune(mask)
+	}
+	b.Write(digits[len(digits)-tail:])
+	return b.String()
+}
+
+// HashNumber returns a stable hash of the number suitable for participant identity.
+func (p *PhoneMaskPolicy) HashNumber(num string) string {
+	if p == nil || len(p.Secret) == 0 {
+		h := sha256.Sum256([]byte(num))
+		return hex.EncodeToString(h[:8])
+	}
+	m := hmac.New(sha256.New, p.Secret)
+	m.Write([]byte(num))
+	return hex.EncodeToString(m.Sum(nil)[:8])
+}
+
+func legacyMaskNumber(num string) string {
+	n := 4
+	if len(num) <= 4 {
+		n = 1
+	}
+	if len(num) < n {
+		return num
+	}
+	return num[len(num)-n:]
+}
+
+// countryCodes2 lists all 2-digit E.164 country codes. NANP (1) and Russia/Kazakhstan (7) use a single digit.
+// Everything else is 3 digits long.
+var countryCodes2 = map[string]struct{}{
+	"20": {}, "27": {}, "30": {}, "31": {}, "32": {}, "33": {}, "34": {}, "36": {}, "39": {},
+	"40": {}, "41": {}, "43": {}, "44": {}, "45": {}, "46": {}, "47": {}, "48": {}, "49": {},
+	"51": {}, "52": {}, "53": {}, "54": {}, "55": {}, "56": {}, "57": {}, "58": {},
+	"60": {}, "61": {}, "62": {}, "63": {}, "64": {}, "65": {}, "66": {},
+	"81": {}, "82": {}, "84": {}, "86": {},
+	"90": {}, "91": {}, "92": {}, "93": {}, "94": {}, "95": {}, "98": {},
+}
+
+func countryCodeLen(digits []byte) int {
+	switch {
+	case len(digits) == 0:
+		return 0
+	case digits[0] == '1' || digits[0] == '7':
+		return 1
+	case len(digits) >= 2:
+		if _, ok := countryCodes2[string(digits[:2])]; ok {
+			return 2
+		}
+	}
+	return min(3, len(digits))
+}
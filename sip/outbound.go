@@ -0,0 +1,165 @@
+// Copyright 2025 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sip
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"strings"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+// OutboundRoute describes which destination numbers an outbound Trunk can be used for.
+type OutboundRoute struct {
+	Trunk *livekit.SIPOutboundTrunkInfo
+	// Patterns are destination number prefixes, for example "+1" or "+4420".
+	// A single "*" or an empty list matches any number.
+	Patterns []string
+	// Priority of the route. Lower value means higher priority, as in DispatchRulePriority.
+	Priority int32
+	// Weight is used to distribute calls between routes with the same priority and pattern length.
+	// Zero weight is the same as 1.
+	Weight int32
+}
+
+func (r *OutboundRoute) validate() error {
+	if r.Trunk == nil {
+		return errors.New("missing trunk")
+	}
+	if r.Weight < 0 {
+		return fmt.Errorf("negative weight for trunk %q", r.Trunk.SipTrunkId)
+	}
+	for _, p := range r.Patterns {
+		if p == "" {
+			return fmt.Errorf("empty pattern for trunk %q", r.Trunk.SipTrunkId)
+		}
+	}
+	return nil
+}
+
+// matchLen returns the length of the longest pattern matching the number, or -1 if none matched.
+func (r *OutboundRoute) matchLen(num string) int {
+	if len(r.Patterns) == 0 {
+		return 0
+	}
+	best := -1
+	for _, p := range r.Patterns {
+		if p == "*" {
+			best = max(best, 0)
+			continue
+		}
+		p = NormalizeNumber(p)
+		if strings.HasPrefix(num, p) {
+			best = max(best, len(p))
+		}
+	}
+	return best
+}
+
+type outboundMatcherOpts struct {
+	Rand func() float64
+}
+
+type OutboundMatcherOpt func(opt *outboundMatcherOpts)
+
+// WithOutboundRand sets a random source for weighted route selection. Useful in tests.
+func WithOutboundRand(fnc func() float64) OutboundMatcherOpt {
+	return func(opt *outboundMatcherOpts) {
+		opt.Rand = fnc
+	}
+}
+
+// OutboundMatcher selects outbound Trunks for a destination number.
+type OutboundMatcher struct {
+	routes []OutboundRoute
+	opt    outboundMatcherOpts
+}
+
+// NewOutboundMatcher creates a matcher for a set of outbound routes.
+func NewOutboundMatcher(routes []OutboundRoute, opts ...OutboundMatcherOpt) (*OutboundMatcher, error) {
+	var opt outboundMatcherOpts
+	for _, fnc := range opts {
+		fnc(&opt)
+	}
+	if opt.Rand == nil {
+		opt.Rand = rand.Float64
+	}
+	for i := range routes {
+		if err := routes[i].validate(); err != nil {
+			return nil, err
+		}
+	}
+	return &OutboundMatcher{routes: routes, opt: opt}, nil
+}
+
+// Match returns all Trunks that can be used to call the number, ordered by preference.
+//
+// Routes are ordered by priority first, then by the most specific pattern (longest prefix).
+// Routes with equal priority and pattern length are shuffled according to their weights.
+// Each Trunk is returned at most once.
+func (m *OutboundMatcher) Match(number string) []*livekit.SIPOutboundTrunkInfo {
+	num := NormalizeNumber(number)
+	type candidate struct {
+		route *OutboundRoute
+		plen  int
+		key   float64
+	}
+	var list []candidate
+	for i := range m.routes {
+		r := &m.routes[i]
+		plen := r.matchLen(num)
+		if plen < 0 {
+			continue
+		}
+		w := float64(max(r.Weight, 1))
+		// Weighted random sampling without replacement (Efraimidis-Spirakis).
+		key := math.Pow(m.opt.Rand(), 1/w)
+		list = append(list, candidate{route: r, plen: plen, key: key})
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		if a.route.Priority != b.route.Priority {
+			return a.route.Priority < b.route.Priority
+		}
+		if a.plen != b.plen {
+			return a.plen > b.plen
+		}
+		return a.key > b.key
+	})
+	out := make([]*livekit.SIPOutboundTrunkInfo, 0, len(list))
+	seen := make(map[string]struct{}, len(list))
+	for _, c := range list {
+		id := c.route.Trunk.SipTrunkId
+		if _, ok := seen[id]; ok && id != "" {
+			continue
+		}
+		seen[id] = struct{}{}
+		out = append(out, c.route.Trunk)
+	}
+	return out
+}
+
+// Select returns the most preferred Trunk for the number, or nil if none matched.
+func (m *OutboundMatcher) Select(number string) *livekit.SIPOutboundTrunkInfo {
+	list := m.Match(number)
+	if len(list) == 0 {
+		return nil
+	}
+	return list[0]
+}
@@ -15,8 +15,6 @@
 package sip
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
 	"fmt"
 	"io"
 	"maps"
@@ -758,8 +756,39 @@ func MatchDispatchRuleIter(trunk *livekit.SIPInboundTrunkInfo, rules iters.Iter[
 	return nil, twirp.WrapError(twirp.NewErrorf(twirp.FailedPrecondition, err.Error()), err)
 }
 
+type evaluateDispatchRuleOpts struct {
+	PhoneMask *PhoneMaskPolicy
+}
+
+type EvaluateDispatchRuleOpt func(opt *evaluateDispatchRuleOpts)
+
+// WithPhoneMaskPolicy sets a policy for masking phone numbers when Dispatch Rule has HidePhoneNumber set.
+func WithPhoneMaskPolicy(p *PhoneMaskPolicy) EvaluateDispatchRuleOpt {
+	return func(opt *evaluateDispatchRuleOpts) {
+		opt.PhoneMask = p
+	}
+}
+
+// roomNamePart converts a masked phone number to a form suitable for room names: '+' is dropped
+// and other characters except letters and digits, like mask characters, are replaced with 'x'.
+func roomNamePart(masked string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '+':
+			return -1
+		case r >= '0' && r <= '9', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+			return r
+		}
+		return 'x'
+	}, masked)
+}
+
 // EvaluateDispatchRule checks a selected Dispatch Rule against the provided request.
-func EvaluateDispatchRule(projectID string, trunk *livekit.SIPInboundTrunkInfo, rule *livekit.SIPDispatchRuleInfo, req *rpc.EvaluateSIPDispatchRulesRequest) (*rpc.EvaluateSIPDispatchRulesResponse, error) {
+func EvaluateDispatchRule(projectID string, trunk *livekit.SIPInboundTrunkInfo, rule *livekit.SIPDispatchRuleInfo, req *rpc.EvaluateSIPDispatchRulesRequest, opts ...EvaluateDispatchRuleOpt) (*rpc.EvaluateSIPDispatchRulesResponse, error) {
+	var opt evaluateDispatchRuleOpts
+	for _, fnc := range opts {
+		fnc(&opt)
+	}
 	call := req.SIPCall()
 	sentPin := req.GetPin()
 
@@ -785,17 +814,22 @@ func EvaluateDispatchRule(projectID string, trunk *livekit.SIPInboundTrunkInfo,
 	from := call.From.User
 	fromName := "Phone " + from
 	fromID := "sip_" + from
+	roomFrom := from
 	if rule.HidePhoneNumber {
-		// Mask the phone number, hash identity. Omit number in attrs.
-		h := sha256.Sum256([]byte(call.From.User))
-		fromID = "sip_" + hex.EncodeToString(h[:8])
-		// TODO: Maybe keep regional code, but mask all but 4 last digits?
-		n := 4
-		if len(from) <= 4 {
-			n = 1
-		}
-		from = from[len(from)-n:]
+		// Mask the phone number, hash identity. Omit number in attrs, unless policy says otherwise.
+		mask := opt.PhoneMask
+		hash := mask.HashNumber(call.From.User)
+		fromID = "sip_" + hash
+		from = mask.MaskNumber(from)
+		if from == "" {
+			// Not a phone number, so nothing can be shown.
+			from = hash
+		}
 		fromName = "Phone " + from
+		roomFrom = roomNamePart(from)
+		if mask != nil && mask.EmitAttribute {
+			attrs[livekit.AttrSIPPhoneNumber] = from
+		}
 	} else {
 		attrs[livekit.AttrSIPPhoneNumber] = call.From.User
 		attrs[livekit.AttrSIPHostName] = call.From.Host
@@ -829,7 +863,7 @@ func EvaluateDispatchRule(projectID string, trunk *livekit.SIPInboundTrunkInfo,
 		// TODO: Remove "_" if the prefix is empty for consistency with Callee dispatch rule.
 		// TODO: Do we need to escape specific characters in the number?
 		// TODO: Include actual SIP call ID in the room name?
-		room = fmt.Sprintf("%s_%s_%s", rule.DispatchRuleIndividual.GetRoomPrefix(), from, guid.New(""))
+		room = fmt.Sprintf("%s_%s_%s", rule.DispatchRuleIndividual.GetRoomPrefix(), roomFrom, guid.New(""))
 	case *livekit.SIPDispatchRule_DispatchRuleCallee:
 		room = to
 		if pref := rule.DispatchRuleCallee.GetRoomPrefix(); pref != "" {
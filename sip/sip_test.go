@@ -824,3 +824,139 @@ func TestMatchMasks(t *testing.T) {
 		})
 	}
 }
+
+func TestEvaluateDispatchRuleMaskPolicy(t *testing.T) {
+	d := &livekit.SIPDispatchRuleInfo{
+		SipDispatchRuleId: "rule",
+		Rule:              newDirectDispatch("room", ""),
+		HidePhoneNumber:   true,
+	}
+	r := &rpc.EvaluateSIPDispatchRulesRequest{
+		SipCallId:     "call-id",
+		CallingNumber: "+14155552222",
+		CallingHost:   "sip.example.com",
+		CalledNumber:  "+3333",
+	}
+	tr := &livekit.SIPInboundTrunkInfo{SipTrunkId: "trunk"}
+	res, err := EvaluateDispatchRule("p_123", tr, d, r, WithPhoneMaskPolicy(&PhoneMaskPolicy{
+		KeepCountryCode: true,
+		KeepAreaDigits:  3,
+		KeepLast:        2,
+		Secret:          []byte("secret"),
+		EmitAttribute:   true,
+	}))
+	require.NoError(t, err)
+	require.Equal(t, "Phone +1415*****22", res.ParticipantName)
+	require.Equal(t, "sip_fa25015a25686678", res.ParticipantIdentity)
+	require.Equal(t, "+1415*****22", res.ParticipantAttributes[livekit.AttrSIPPhoneNumber])
+	require.NotContains(t, res.ParticipantAttributes, livekit.AttrSIPHostName)
+	require.NotContains(t, res.ParticipantAttributes, livekit.AttrSIPTrunkNumber)
+}
+
+func TestEvaluateDispatchRuleMaskRoomName(t *testing.T) {
+	d := &livekit.SIPDispatchRuleInfo{
+		SipDispatchRuleId: "rule",
+		Rule:              newIndividualDispatch("pref", ""),
+		HidePhoneNumber:   true,
+	}
+	r := &rpc.EvaluateSIPDispatchRulesRequest{
+		SipCallId:     "call-id",
+		CallingNumber: "+14155552222",
+		CalledNumber:  "+3333",
+	}
+	policy := WithPhoneMaskPolicy(&PhoneMaskPolicy{KeepCountryCode: true, KeepLast: 2})
+
+	res, err := EvaluateDispatchRule("p_123", nil, d, r)
+	require.NoError(t, err)
+	require.Regexp(t, `^pref_2222_[^_]+$`, res.RoomName)
+
+	res, err = EvaluateDispatchRule("p_123", nil, d, r, policy)
+	require.NoError(t, err)
+	require.Equal(t, "Phone +1********22", res.ParticipantName)
+	require.Regexp(t, `^pref_1xxxxxxxx22_[^_]+$`, res.RoomName)
+
+	// Users which are not phone numbers are never shown, but names are not empty.
+	r.CallingNumber = "alice"
+	res, err = EvaluateDispatchRule("p_123", nil, d, r, policy)
+	require.NoError(t, err)
+	hash := (&PhoneMaskPolicy{}).HashNumber("alice")
+	require.Equal(t, "Phone "+hash, res.ParticipantName)
+	require.Equal(t, "sip_"+hash, res.ParticipantIdentity)
+	require.Regexp(t, `^pref_`+hash+`_[^_]+$`, res.RoomName)
+	require.NotContains(t, res.RoomName, "alice")
+}
+
+func TestPhoneMaskPolicy(t *testing.T) {
+	cases := []struct {
+		name   string
+		policy *PhoneMaskPolicy
+		num    string
+		exp    string
+	}{
+		{"legacy", nil, "+11112222", "2222"},
+		{"legacy short", nil, "123", "3"},
+		{"last", &PhoneMaskPolicy{KeepLast: 4}, "+14155552222", "+*******2222"},
+		{"country", &PhoneMaskPolicy{KeepCountryCode: true}, "+14155552222", "+1**********"},
+		{"country 2", &PhoneMaskPolicy{KeepCountryCode: true, KeepLast: 2}, "+442071234567", "+44********67"},
+		{"country 3", &PhoneMaskPolicy{KeepCountryCode: true}, "+380441234567", "+380*********"},
+		{"area", &PhoneMaskPolicy{KeepCountryCode: true, KeepAreaDigits: 3, KeepLast: 4}, "+1 (415) 555-2222", "+1415***2222"},
+		{"no plus", &PhoneMaskPolicy{KeepCountryCode: true, KeepLast: 3}, "4155552222", "*******222"},
+		{"mask char", &PhoneMaskPolicy{KeepLast: 2, MaskChar: 'x'}, "12345", "xxx45"},
+		{"short", &PhoneMaskPolicy{KeepLast: 4}, "1234", "***4"},
+		{"short country", &PhoneMaskPolicy{KeepCountryCode: true, KeepAreaDigits: 5, KeepLast: 4}, "+1234", "+***4"},
+		{"not a number", &PhoneMaskPolicy{KeepLast: 4}, "user", ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require.Equal(t, c.exp, c.policy.MaskNumber(c.num))
+		})
+	}
+}
+
+func TestOutboundMatcher(t *testing.T) {
+	trunk := func(id string) *livekit.SIPOutboundTrunkInfo {
+		return &livekit.SIPOutboundTrunkInfo{SipTrunkId: id}
+	}
+	ids := func(list []*livekit.SIPOutboundTrunkInfo) []string {
+		var out []string
+		for _, t := range list {
+			out = append(out, t.SipTrunkId)
+		}
+		return out
+	}
+	routes := []OutboundRoute{
+		{Trunk: trunk("any"), Priority: 10},
+		{Trunk: trunk("us"), Patterns: []string{"+1"}},
+		{Trunk: trunk("us-ca"), Patterns: []string{"+1415", "+1 (650)"}},
+		{Trunk: trunk("uk-1"), Patterns: []string{"+44"}, Weight: 1},
+		{Trunk: trunk("uk-2"), Patterns: []string{"+44"}, Weight: 3},
+	}
+	vals := []float64{0.5, 0.9}
+	m, err := NewOutboundMatcher(routes, WithOutboundRand(func() float64 {
+		v := vals[0]
+		vals = append(vals[1:], v)
+		return v
+	}))
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"us-ca", "us", "any"}, ids(m.Match("+14155550100")))
+	require.Equal(t, []string{"us-ca", "us", "any"}, ids(m.Match("1 650 555 0100")))
+	require.Equal(t, []string{"us", "any"}, ids(m.Match("+12125550100")))
+	require.Equal(t, []string{"any"}, ids(m.Match("+33123456789")))
+	require.Equal(t, "us", m.Select("+12125550100").SipTrunkId)
+	require.Len(t, m.Match("+442071234567"), 3)
+
+	// Weighted selection must roughly follow the weights.
+	m, err = NewOutboundMatcher(routes)
+	require.NoError(t, err)
+	cnt := make(map[string]int)
+	for i := 0; i < 4000; i++ {
+		cnt[m.Select("+442071234567").SipTrunkId]++
+	}
+	require.InDelta(t, 3000, cnt["uk-2"], 300)
+
+	_, err = NewOutboundMatcher([]OutboundRoute{{Trunk: nil}})
+	require.Error(t, err)
+	_, err = NewOutboundMatcher([]OutboundRoute{{Trunk: trunk("a"), Weight: -1}})
+	require.Error(t, err)
+}
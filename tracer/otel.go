@@ -0,0 +1,143 @@
+// Copyright 2025 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/livekit/protocol"
+
+// Propagator is used to pass trace context across psrpc and Twirp calls. It uses W3C trace context headers.
+var Propagator propagation.TextMapPropagator = propagation.TraceContext{}
+
+// Inject writes trace context of the current span into the carrier.
+func Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	Propagator.Inject(ctx, carrier)
+}
+
+// Extract returns a context with the remote span from the carrier, which becomes a parent of spans started from it.
+func Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	return Propagator.Extract(ctx, carrier)
+}
+
+// OTelTracer is a Tracer backed by OpenTelemetry. Start accepts trace.SpanStartOption values as options,
+// other options are ignored.
+type OTelTracer struct {
+	tracer trace.Tracer
+}
+
+// NewOTelTracer creates a tracer for SetTracer from an OpenTelemetry tracer provider.
+func NewOTelTracer(tp trace.TracerProvider) *OTelTracer {
+	return &OTelTracer{tracer: tp.Tracer(instrumentationName)}
+}
+
+func (t *OTelTracer) Start(ctx context.Context, spanName string, opts ...interface{}) (context.Context, Span) {
+	var startOpts []trace.SpanStartOption
+	for _, o := range opts {
+		if so, ok := o.(trace.SpanStartOption); ok {
+			startOpts = append(startOpts, so)
+		}
+	}
+	ctx, span := t.tracer.Start(ctx, spanName, startOpts...)
+	return ctx, &otelSpan{span: span}
+}
+
+type otelSpan struct {
+	span trace.Span
+}
+
+func (s *otelSpan) RecordError(err error) {
+	if err != nil {
+		s.span.RecordError(err)
+	}
+}
+
+func (s *otelSpan) SetAttributes(keysAndValues ...interface{}) {
+	s.span.SetAttributes(toAttributes(keysAndValues)...)
+}
+
+func (s *otelSpan) AddEvent(name string, keysAndValues ...interface{}) {
+	s.span.AddEvent(name, trace.WithAttributes(toAttributes(keysAndValues)...))
+}
+
+func (s *otelSpan) SetStatus(code StatusCode, description string) {
+	switch code {
+	case StatusOK:
+		s.span.SetStatus(codes.Ok, description)
+	case StatusError:
+		s.span.SetStatus(codes.Error, description)
+	default:
+		s.span.SetStatus(codes.Unset, description)
+	}
+}
+
+func (s *otelSpan) End() {
+	s.span.End()
+}
+
+// toAttributes converts alternating keys and values into attributes. Values of unsupported types are formatted
+// as strings, and a key without a value is dropped.
+func toAttributes(keysAndValues []interface{}) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, len(keysAndValues)/2)
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			key = fmt.Sprint(keysAndValues[i])
+		}
+		attrs = append(attrs, toAttribute(key, keysAndValues[i+1]))
+	}
+	return attrs
+}
+
+func toAttribute(key string, value interface{}) attribute.KeyValue {
+	switch v := value.(type) {
+	case string:
+		return attribute.String(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	case int:
+		return attribute.Int(key, v)
+	case int32:
+		return attribute.Int64(key, int64(v))
+	case int64:
+		return attribute.Int64(key, v)
+	case uint32:
+		return attribute.Int64(key, int64(v))
+	case uint64:
+		return attribute.Int64(key, int64(v))
+	case float32:
+		return attribute.Float64(key, float64(v))
+	case float64:
+		return attribute.Float64(key, v)
+	case time.Duration:
+		return attribute.String(key, v.String())
+	case []string:
+		return attribute.StringSlice(key, v)
+	case error:
+		return attribute.String(key, v.Error())
+	case fmt.Stringer:
+		return attribute.String(key, v.String())
+	default:
+		return attribute.String(key, fmt.Sprint(v))
+	}
+}
@@ -0,0 +1,70 @@
+package tracer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestOTelTracer(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tr := NewOTelTracer(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+
+	ctx, span := tr.Start(context.Background(), "parent", trace.WithSpanKind(trace.SpanKindServer), "ignored")
+	span.SetAttributes("room", "test", "count", 3, "duration", time.Second, "dangling")
+	span.AddEvent("joined", "participant", "p1")
+	span.RecordError(errors.New("failed"))
+	span.SetStatus(StatusError, "failed")
+
+	carrier := propagation.MapCarrier{}
+	Inject(ctx, carrier)
+	require.Contains(t, carrier, "traceparent")
+
+	_, child := tr.Start(Extract(context.Background(), carrier), "child")
+	child.SetStatus(StatusOK, "")
+	child.End()
+	span.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	c, p := spans[0], spans[1]
+
+	require.Equal(t, "parent", p.Name)
+	require.Equal(t, trace.SpanKindServer, p.SpanKind)
+	require.Equal(t, []attribute.KeyValue{
+		attribute.String("room", "test"),
+		attribute.Int("count", 3),
+		attribute.String("duration", "1s"),
+	}, p.Attributes)
+	require.Len(t, p.Events, 2)
+	require.Equal(t, "joined", p.Events[0].Name)
+	require.Equal(t, []attribute.KeyValue{attribute.String("participant", "p1")}, p.Events[0].Attributes)
+	require.Equal(t, "exception", p.Events[1].Name)
+	require.Equal(t, codes.Error, p.Status.Code)
+
+	require.Equal(t, "child", c.Name)
+	require.Equal(t, codes.Ok, c.Status.Code)
+	require.Equal(t, p.SpanContext.TraceID(), c.SpanContext.TraceID())
+	require.Equal(t, p.SpanContext.SpanID(), c.Parent.SpanID())
+}
+
+func TestNoOpTracer(t *testing.T) {
+	ctx, span := (&NoOpTracer{}).Start(context.Background(), "span")
+	span.SetAttributes("key", "value")
+	span.AddEvent("event")
+	span.SetStatus(StatusOK, "")
+	span.End()
+
+	carrier := propagation.MapCarrier{}
+	Inject(ctx, carrier)
+	require.Empty(t, carrier)
+}
@@ -22,9 +22,22 @@ type Tracer interface {
 
 type Span interface {
 	RecordError(err error)
+	// SetAttributes sets span attributes from alternating keys and values, like logger fields.
+	SetAttributes(keysAndValues ...interface{})
+	// AddEvent adds an event with attributes from alternating keys and values.
+	AddEvent(name string, keysAndValues ...interface{})
+	SetStatus(code StatusCode, description string)
 	End()
 }
 
+type StatusCode int
+
+const (
+	StatusUnset StatusCode = iota
+	StatusError
+	StatusOK
+)
+
 var tracer Tracer = &NoOpTracer{}
 
 // Can be used for your own tracing (for example, with Lightstep)
@@ -46,4 +59,10 @@ type NoOpSpan struct{}
 
 func (s *NoOpSpan) RecordError(_ error) {}
 
+func (s *NoOpSpan) SetAttributes(_ ...interface{}) {}
+
+func (s *NoOpSpan) AddEvent(_ string, _ ...interface{}) {}
+
+func (s *NoOpSpan) SetStatus(_ StatusCode, _ string) {}
+
 func (s *NoOpSpan) End() {}
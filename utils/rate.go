@@ -112,3 +112,33 @@ func (lb *LeakyBucket) Take() time.Time {
 
 	return lb.last
 }
+
+// TryTake is the non-blocking version of Take. When the request is over the
+// limit, it returns false and the time left until it would be allowed,
+// without taking from the bucket.
+//
+// TryTake is THREAD SAFE and NON-BLOCKING.
+func (lb *LeakyBucket) TryTake() (time.Duration, bool) {
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	cfg := lb.cfg.Load()
+	now := lb.clock.Now()
+
+	if lb.last.IsZero() {
+		lb.last = now
+		return 0, true
+	}
+
+	sleepFor := lb.sleepFor + cfg.perRequest - now.Sub(lb.last)
+	if sleepFor < cfg.maxSlack {
+		sleepFor = cfg.maxSlack
+	}
+	if sleepFor > 0 {
+		return sleepFor, false
+	}
+
+	lb.sleepFor = sleepFor
+	lb.last = now
+	return 0, true
+}
@@ -555,3 +555,30 @@ func TestSetRateLimitOnTheFly(t *testing.T) {
 		r.assertCountAt(time.Second, 8+slack)
 	})
 }
+
+func TestTryTake(t *testing.T) {
+	c := &SimulatedClock{}
+	c.Set(time.Now())
+	lb := NewLeakyBucket(10, 2, c)
+
+	_, ok := lb.TryTake()
+	require.True(t, ok)
+	wait, ok := lb.TryTake()
+	require.False(t, ok)
+	require.Equal(t, 100*time.Millisecond, wait)
+
+	// A rejected request does not take from the bucket.
+	c.Add(50 * time.Millisecond)
+	wait, ok = lb.TryTake()
+	require.False(t, ok)
+	require.Equal(t, 50*time.Millisecond, wait)
+
+	// Slack accumulates while idle.
+	c.Add(time.Second)
+	for i := 0; i < 3; i++ {
+		_, ok = lb.TryTake()
+		require.True(t, ok, i)
+	}
+	_, ok = lb.TryTake()
+	require.False(t, ok)
+}
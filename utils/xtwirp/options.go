@@ -2,7 +2,7 @@ package xtwirp
 
 import "github.com/twitchtv/twirp"
 
-// DefaultClientOptions returns default Twirp client options.
+// DefaultClientOptions returns default Twirp client options. Tracing is opt-in, see ClientPassTrace.
 func DefaultClientOptions() []twirp.ClientOption {
 	return []twirp.ClientOption{
 		ClientPassTimout(),
@@ -10,7 +10,7 @@ func DefaultClientOptions() []twirp.ClientOption {
 	}
 }
 
-// DefaultServerOptions returns default Twirp server options.
+// DefaultServerOptions returns default Twirp server options. Tracing is opt-in, see ServerPassTrace.
 func DefaultServerOptions() []twirp.ServerOption {
 	return []twirp.ServerOption{
 		ServerPassTimeout(),
@@ -0,0 +1,72 @@
+package xtwirp
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/twitchtv/twirp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/livekit/protocol/tracer"
+)
+
+func twirpSpan(ctx context.Context, kind trace.SpanKind) (context.Context, tracer.Span) {
+	service, _ := twirp.ServiceName(ctx)
+	method, _ := twirp.MethodName(ctx)
+	ctx, span := tracer.Start(ctx, service+"/"+method, trace.WithSpanKind(kind))
+	span.SetAttributes("rpc.system", "twirp", "rpc.service", service, "rpc.method", method)
+	return ctx, span
+}
+
+func endTwirpSpan(span tracer.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(tracer.StatusError, err.Error())
+		span.SetAttributes("twirp.error_code", string(ToError(err).Code()))
+	}
+	span.End()
+}
+
+// ClientPassTrace starts a client span for each call and passes W3C trace context to the server in request headers.
+func ClientPassTrace() twirp.ClientOption {
+	return twirp.WithClientInterceptors(func(fnc twirp.Method) twirp.Method {
+		return func(ctx context.Context, req any) (_ any, err error) {
+			ctx, span := twirpSpan(ctx, trace.SpanKindClient)
+			defer func() {
+				endTwirpSpan(span, err)
+			}()
+
+			// headers in ctx may be shared with other calls, so inject into a copy
+			h, ok := twirp.HTTPRequestHeaders(ctx)
+			if ok {
+				h = h.Clone()
+			} else {
+				h = make(http.Header)
+			}
+			tracer.Inject(ctx, propagation.HeaderCarrier(h))
+			ctx, err = twirp.WithHTTPRequestHeaders(ctx, h)
+			if err != nil {
+				return nil, err
+			}
+			return fnc(ctx, req)
+		}
+	})
+}
+
+// ServerPassTrace starts a server span for each call, continuing the trace passed by ClientPassTrace.
+// Requires the handler to be wrapped with PassHeadersHandler.
+func ServerPassTrace() twirp.ServerOption {
+	return twirp.WithServerInterceptors(func(fnc twirp.Method) twirp.Method {
+		return func(ctx context.Context, req any) (_ any, err error) {
+			if h := GetHeaders(ctx); h != nil {
+				ctx = tracer.Extract(ctx, propagation.HeaderCarrier(h))
+			}
+			ctx, span := twirpSpan(ctx, trace.SpanKindServer)
+			defer func() {
+				endTwirpSpan(span, err)
+			}()
+			return fnc(ctx, req)
+		}
+	})
+}
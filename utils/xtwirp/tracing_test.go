@@ -0,0 +1,44 @@
+package xtwirp
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/twitchtv/twirp"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/livekit/protocol/tracer"
+	"github.com/livekit/protocol/tracer/tracertest"
+)
+
+func TestClientPassTrace(t *testing.T) {
+	tr, _ := tracertest.NewInMemoryTracer()
+	tracer.SetTracer(tr)
+	t.Cleanup(func() {
+		tracer.SetTracer(&tracer.NoOpTracer{})
+	})
+
+	var opts twirp.ClientOptions
+	ClientPassTrace()(&opts)
+	require.Len(t, opts.Interceptors, 1)
+
+	ctx, err := twirp.WithHTTPRequestHeaders(context.Background(), http.Header{"X-Test": []string{"1"}})
+	require.NoError(t, err)
+
+	for range 2 {
+		_, err = opts.Interceptors[0](func(ctx context.Context, req any) (any, error) {
+			h, ok := twirp.HTTPRequestHeaders(ctx)
+			require.True(t, ok)
+			require.Equal(t, "1", h.Get("X-Test"))
+			require.NotEmpty(t, h.Get("traceparent"))
+			require.True(t, trace.SpanContextFromContext(ctx).IsValid())
+			return nil, nil
+		})(ctx, nil)
+		require.NoError(t, err)
+	}
+	// the headers stored in ctx are shared by all calls made with it
+	shared, _ := twirp.HTTPRequestHeaders(ctx)
+	require.Equal(t, http.Header{"X-Test": []string{"1"}}, shared)
+}
@@ -0,0 +1,35 @@
+// Copyright 2025 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+// EgressTransitionEvent creates a webhook event for an egress status change.
+// Changes into a final status produce egress_ended, all others produce egress_updated.
+func EgressTransitionEvent(info *livekit.EgressInfo, t livekit.EgressTransition) *livekit.WebhookEvent {
+	event := EventEgressUpdated
+	if t.To.IsFinal() {
+		event = EventEgressEnded
+	}
+	return &livekit.WebhookEvent{
+		Event:      event,
+		EgressInfo: info,
+		CreatedAt:  time.Unix(0, t.At).Unix(),
+	}
+}
@@ -217,6 +217,37 @@ func eventKey(event *livekit.WebhookEvent) string {
 	return "default"
 }
 
+// eventLogger returns a logger with the resources referenced by event. A logger added to ctx with
+// logger.NewContext is used in place of l. The logger is built the first time a message is logged,
+// so events that are not logged don't pay for adding the values.
+func eventLogger(ctx context.Context, l logger.Logger, event *livekit.WebhookEvent) logger.Logger {
+	return logger.Lazy(l, func() logger.Logger {
+		ctx := eventContext(ctx, event)
+		if logger.HasContextLogger(ctx) {
+			return logger.FromContext(ctx).WithComponent("webhook")
+		}
+		return logger.WithContext(l, ctx)
+	})
+}
+
+func eventContext(ctx context.Context, event *livekit.WebhookEvent) context.Context {
+	if event.Room != nil {
+		ctx = logger.ContextWithRoom(ctx, livekit.RoomName(event.Room.Name))
+		ctx = logger.ContextWithRoomID(ctx, livekit.RoomID(event.Room.Sid))
+	}
+	if event.Participant != nil {
+		ctx = logger.ContextWithParticipant(ctx, livekit.ParticipantIdentity(event.Participant.Identity))
+		ctx = logger.ContextWithParticipantID(ctx, livekit.ParticipantID(event.Participant.Sid))
+	}
+	if event.Track != nil {
+		ctx = logger.ContextWithTrackID(ctx, livekit.TrackID(event.Track.Sid))
+	}
+	if event.EgressInfo != nil {
+		ctx = logger.ContextWithEgressID(ctx, event.EgressInfo.EgressId)
+	}
+	return ctx
+}
+
 func logFields(event *livekit.WebhookEvent, url string) []interface{} {
 	fields := make([]interface{}, 0, 20)
 	fields = append(fields,
@@ -226,26 +257,8 @@ func logFields(event *livekit.WebhookEvent, url string) []interface{} {
 		"url", url,
 	)
 
-	if event.Room != nil {
-		fields = append(fields,
-			"room", event.Room.Name,
-			"roomID", event.Room.Sid,
-		)
-	}
-	if event.Participant != nil {
-		fields = append(fields,
-			"participant", event.Participant.Identity,
-			"pID", event.Participant.Sid,
-		)
-	}
-	if event.Track != nil {
-		fields = append(fields,
-			"trackID", event.Track.Sid,
-		)
-	}
 	if event.EgressInfo != nil {
 		fields = append(fields,
-			"egressID", event.EgressInfo.EgressId,
 			"status", event.EgressInfo.Status,
 		)
 		if event.EgressInfo.Error != "" {
@@ -211,6 +211,7 @@ func (r *ResourceURLNotifier) QueueNotify(ctx context.Context, event *livekit.We
 	}
 	r.mu.Unlock()
 
+	params.Logger = eventLogger(ctx, params.Logger, event)
 	err := rqi.resourceQueue.Enqueue(ctx, event, &params)
 	if err != nil {
 		fields := logFields(event, params.URL)
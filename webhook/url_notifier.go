@@ -169,6 +169,7 @@ func (n *URLNotifier) QueueNotify(ctx context.Context, event *livekit.WebhookEve
 	if params.APIKey == "" || params.APISecret == "" {
 		return errNoKey
 	}
+	params.Logger = eventLogger(ctx, params.Logger, event)
 
 	if !n.pool.Submit(key, func() {
 		fields := logFields(event, params.URL)
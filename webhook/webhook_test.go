@@ -15,7 +15,9 @@
 package webhook
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"net"
 	"net/http"
@@ -25,9 +27,12 @@ import (
 
 	"github.com/stretchr/testify/require"
 	"go.uber.org/atomic"
+	"go.uber.org/zap/zapcore"
 
 	"github.com/livekit/protocol/auth"
 	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/logger/zaputil"
 )
 
 const (
@@ -84,6 +89,61 @@ func TestWebHook(t *testing.T) {
 
 }
 
+type testLogWriter struct {
+	bytes.Buffer
+}
+
+func (w *testLogWriter) Sync() error { return nil }
+
+func newTestLogger(t *testing.T) (logger.Logger, *testLogWriter) {
+	w := &testLogWriter{}
+	l, err := logger.NewZapLogger(&logger.Config{}, logger.WithTap(zaputil.NewWriteEnabler(w, zapcore.DebugLevel)))
+	require.NoError(t, err)
+	return l, w
+}
+
+func readTestLog(t *testing.T, w *testLogWriter) map[string]any {
+	log := map[string]any{}
+	require.NoError(t, json.Unmarshal(bytes.TrimSpace(w.Bytes()), &log))
+	w.Reset()
+	return log
+}
+
+func TestEventLogger(t *testing.T) {
+	event := &livekit.WebhookEvent{
+		Event: EventParticipantJoined,
+		Room:  &livekit.Room{Name: "room1", Sid: "RM_1"},
+		Participant: &livekit.ParticipantInfo{
+			Identity: "alice",
+			Sid:      "PA_1",
+		},
+	}
+
+	t.Run("uses notifier logger", func(t *testing.T) {
+		l, w := newTestLogger(t)
+		ctx := logger.ContextWithValues(context.Background(), "request", "r1")
+		eventLogger(ctx, l, event).Infow("test")
+
+		log := readTestLog(t, w)
+		require.Equal(t, "room1", log["room"])
+		require.Equal(t, "alice", log["participant"])
+		require.Equal(t, "r1", log["request"])
+	})
+
+	t.Run("prefers context logger", func(t *testing.T) {
+		l, w := newTestLogger(t)
+		cl, cw := newTestLogger(t)
+		ctx := logger.NewContext(context.Background(), cl.WithValues("service", "test"))
+		eventLogger(ctx, l, event).Infow("test")
+
+		require.Zero(t, w.Len())
+		log := readTestLog(t, cw)
+		require.Equal(t, "test", log["service"])
+		require.Equal(t, "room1", log["room"])
+		require.Equal(t, "PA_1", log["pID"])
+	})
+}
+
 func TestURLNotifierDropped(t *testing.T) {
 	s := newServer(testAddr)
 	require.NoError(t, s.Start())